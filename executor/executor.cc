@@ -273,6 +273,8 @@ struct thread_t {
 	bool fault_injected;
 	cover_t cov;
 	bool soft_fail_state;
+	uint64 start_ms;
+	uint64 end_ms;
 };
 
 static thread_t threads[kMaxThreads];
@@ -333,6 +335,7 @@ struct call_reply {
 	uint32 call_num;
 	uint32 reserrno;
 	uint32 flags;
+	uint32 duration_ms;
 	uint32 signal_size;
 	uint32 cover_size;
 	uint32 comps_size;
@@ -993,6 +996,7 @@ thread_t* schedule_call(int call_index, int call_num, uint64 copyout_index, uint
 	th->call_props = call_props;
 	for (int i = 0; i < kMaxArgs; i++)
 		th->args[i] = args[i];
+	th->start_ms = current_time_ms();
 	event_set(&th->ready);
 	running++;
 	return th;
@@ -1116,6 +1120,8 @@ void write_call_output(thread_t* th, bool finished)
 	uint32 reserrno = 999;
 	const bool blocked = finished && th != last_scheduled;
 	uint32 call_flags = call_flag_executed | (blocked ? call_flag_blocked : 0);
+	// For unfinished calls it's the time until we stopped waiting for them.
+	uint32 duration_ms = (finished ? th->end_ms : current_time_ms()) - th->start_ms;
 	if (finished) {
 		reserrno = th->res != -1 ? 0 : th->reserrno;
 		call_flags |= call_flag_finished |
@@ -1127,6 +1133,7 @@ void write_call_output(thread_t* th, bool finished)
 	write_output(th->call_num);
 	write_output(reserrno);
 	write_output(call_flags);
+	write_output(duration_ms);
 	uint32* signal_count_pos = write_output(0); // filled in later
 	uint32* cover_count_pos = write_output(0); // filled in later
 	uint32* comps_count_pos = write_output(0); // filled in later
@@ -1157,8 +1164,8 @@ void write_call_output(thread_t* th, bool finished)
 		else
 			write_coverage_signal<uint32>(&th->cov, signal_count_pos, cover_count_pos);
 	}
-	debug_verbose("out #%u: index=%u num=%u errno=%d finished=%d blocked=%d sig=%u cover=%u comps=%u\n",
-		      completed, th->call_index, th->call_num, reserrno, finished, blocked,
+	debug_verbose("out #%u: index=%u num=%u errno=%d finished=%d blocked=%d duration=%ums sig=%u cover=%u comps=%u\n",
+		      completed, th->call_index, th->call_num, reserrno, finished, blocked, duration_ms,
 		      *signal_count_pos, *cover_count_pos, *comps_count_pos);
 	completed++;
 	write_completed(completed);
@@ -1172,6 +1179,7 @@ void write_call_output(thread_t* th, bool finished)
 	reply.call_num = th->call_num;
 	reply.reserrno = reserrno;
 	reply.flags = call_flags;
+	reply.duration_ms = duration_ms;
 	reply.signal_size = 0;
 	reply.cover_size = 0;
 	reply.comps_size = 0;
@@ -1195,6 +1203,7 @@ void write_extra_output()
 	write_output(-1); // call num
 	write_output(999); // errno
 	write_output(0); // call flags
+	write_output(0); // duration
 	uint32* signal_count_pos = write_output(0); // filled in later
 	uint32* cover_count_pos = write_output(0); // filled in later
 	write_output(0); // comps_count_pos
@@ -1280,6 +1289,7 @@ void execute_call(thread_t* th)
 	errno = EFAULT;
 	NONFAILING(th->res = execute_syscall(call, th->args));
 	th->reserrno = errno;
+	th->end_ms = current_time_ms();
 	// Our pseudo-syscalls may misbehave.
 	if ((th->res == -1 && th->reserrno == 0) || call->attrs.ignore_return)
 		th->reserrno = EINVAL;
@@ -16,6 +16,9 @@ type Impl struct {
 	Frames    []Frame
 	Symbolize func(pcs map[*Module][]uint64) ([]Frame, error)
 	RestorePC func(pc uint32) uint64
+	// CallGraph returns direct calls between symbols of the kernel (not including modules),
+	// nil if call graph extraction is not supported for the target.
+	CallGraph func() (CallGraph, error)
 }
 
 type Module struct {
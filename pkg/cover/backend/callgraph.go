@@ -0,0 +1,81 @@
+// Copyright 2026 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package backend
+
+import (
+	"sort"
+
+	"github.com/google/syzkaller/sys/targets"
+)
+
+// CallGraph maps symbols to symbols they call directly.
+// Indirect calls and calls of symbols without coverage points are not included.
+type CallGraph map[*Symbol][]*Symbol
+
+// readCallGraph finds direct calls between the kernel symbols in the kernel text data.
+// Symbols must be sorted by address.
+func readCallGraph(target *targets.Target, symbols []*Symbol, data []byte, textAddr uint64) CallGraph {
+	var kernelSymbols []*Symbol
+	starts := make(map[uint64]*Symbol)
+	for _, s := range symbols {
+		if s.Module.Name == "" {
+			kernelSymbols = append(kernelSymbols, s)
+			starts[s.Start] = s
+		}
+	}
+	edges := make(map[[2]*Symbol]bool)
+	graph := make(CallGraph)
+	forEachCall(target, data, textAddr, func(pc, addr uint64) {
+		callee := starts[addr]
+		if callee == nil {
+			return
+		}
+		idx := sort.Search(len(kernelSymbols), func(i int) bool {
+			return kernelSymbols[i].End > pc
+		})
+		if idx == len(kernelSymbols) || pc < kernelSymbols[idx].Start {
+			return
+		}
+		caller := kernelSymbols[idx]
+		if caller == callee || edges[[2]*Symbol{caller, callee}] {
+			return
+		}
+		edges[[2]*Symbol{caller, callee}] = true
+		graph[caller] = append(graph[caller], callee)
+	})
+	return graph
+}
+
+// Distances returns the minimal number of calls needed to reach any of the destination symbols
+// from each symbol that can reach them in at most maxDistance calls. Destinations have distance 0.
+func (graph CallGraph) Distances(dests []*Symbol, maxDistance int) map[*Symbol]int {
+	callers := make(map[*Symbol][]*Symbol)
+	for caller, callees := range graph {
+		for _, callee := range callees {
+			callers[callee] = append(callers[callee], caller)
+		}
+	}
+	dist := make(map[*Symbol]int)
+	var queue []*Symbol
+	for _, s := range dests {
+		if _, ok := dist[s]; !ok {
+			dist[s] = 0
+			queue = append(queue, s)
+		}
+	}
+	for len(queue) != 0 {
+		s := queue[0]
+		queue = queue[1:]
+		if dist[s] == maxDistance {
+			continue
+		}
+		for _, caller := range callers[s] {
+			if _, ok := dist[caller]; !ok {
+				dist[caller] = dist[s] + 1
+				queue = append(queue, caller)
+			}
+		}
+	}
+	return dist
+}
@@ -0,0 +1,50 @@
+// Copyright 2026 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package backend
+
+import (
+	"encoding/binary"
+	"reflect"
+	"testing"
+
+	"github.com/google/syzkaller/sys/targets"
+)
+
+func TestCallGraph(t *testing.T) {
+	const textAddr = 0x1000
+	kernel, module := &Module{}, &Module{Name: "mod"}
+	sym := func(name string, start uint64) *Symbol {
+		return &Symbol{ObjectUnit: ObjectUnit{Name: name}, Module: kernel, Start: start, End: start + 0x100}
+	}
+	a, b, c, d := sym("a", 0x1000), sym("b", 0x1100), sym("c", 0x1200), sym("d", 0x1300)
+	m := &Symbol{ObjectUnit: ObjectUnit{Name: "m"}, Module: module, Start: 0x1400, End: 0x1500}
+	data := make([]byte, 0x500)
+	call := func(pc, target uint64) {
+		insn := data[pc-textAddr:]
+		insn[0] = 0xe8
+		binary.LittleEndian.PutUint32(insn[1:], uint32(target-pc-5))
+	}
+	call(0x1010, b.Start)   // a -> b
+	call(0x1020, b.Start)   // duplicate
+	call(0x1030, b.Start+8) // not a symbol start
+	call(0x1110, c.Start)   // b -> c
+	call(0x1120, b.Start)   // recursion
+	call(0x1210, a.Start)   // c -> a
+	call(0x1310, m.Start)   // d -> module symbol
+	graph := readCallGraph(targets.Get(targets.Linux, targets.AMD64), []*Symbol{a, b, c, d, m}, data, textAddr)
+	want := CallGraph{
+		a: {b},
+		b: {c},
+		c: {a},
+	}
+	if !reflect.DeepEqual(graph, want) {
+		t.Fatalf("got graph %v, want %v", graph, want)
+	}
+	if got, want := graph.Distances([]*Symbol{c}, 10), map[*Symbol]int{a: 2, b: 1, c: 0}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got distances %v, want %v", got, want)
+	}
+	if got, want := graph.Distances([]*Symbol{c, d}, 1), map[*Symbol]int{b: 1, c: 0, d: 0}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got distances %v, want %v", got, want)
+	}
+}
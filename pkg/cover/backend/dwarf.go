@@ -107,6 +107,7 @@ func makeDWARFUnsafe(params *dwarfParams) (*Impl, error) {
 	var allRanges []pcRange
 	var allUnits []*CompileUnit
 	var pcBase uint64
+	var kernelModule *Module
 	for _, module := range modules {
 		errc := make(chan error, 1)
 		go func() {
@@ -123,6 +124,7 @@ func makeDWARFUnsafe(params *dwarfParams) (*Impl, error) {
 			allSymbols = append(allSymbols, symbols...)
 			if module.Name == "" {
 				pcBase = info.textAddr
+				kernelModule = module
 			}
 			var data []byte
 			var coverPoints [2][]uint64
@@ -183,6 +185,7 @@ func makeDWARFUnsafe(params *dwarfParams) (*Impl, error) {
 	if len(allSymbols) == 0 || len(allUnits) == 0 {
 		return nil, fmt.Errorf("failed to parse DWARF (set CONFIG_DEBUG_INFO=y on linux)")
 	}
+	textAddr := pcBase
 	if target.OS == targets.FreeBSD {
 		// On FreeBSD .text address in ELF is 0, but .text is actually mapped at 0xffffffff.
 		pcBase = ^uint64(0)
@@ -195,6 +198,15 @@ func makeDWARFUnsafe(params *dwarfParams) (*Impl, error) {
 		},
 		RestorePC: makeRestorePC(params, pcBase),
 	}
+	if _, ok := arches[target.Arch]; ok && kernelModule != nil {
+		impl.CallGraph = func() (CallGraph, error) {
+			data, err := params.readTextData(kernelModule)
+			if err != nil {
+				return nil, err
+			}
+			return readCallGraph(target, allSymbols, data, textAddr), nil
+		}
+	}
 	return impl, nil
 }
 
@@ -435,6 +447,20 @@ func readCoverPoints(target *targets.Target, info *symbolInfo, data []byte) ([2]
 	// opcode. When found, it compares the call target address with those of the
 	// __sanitizer_cov_trace_* functions we previously collected. When found,
 	// we collect the pc as a coverage point.
+	forEachCall(target, data, info.textAddr, func(pc, target uint64) {
+		if target == info.tracePC {
+			pcs[0] = append(pcs[0], pc)
+		} else if info.traceCmp[target] {
+			pcs[1] = append(pcs[1], pc)
+		}
+	})
+	return pcs, nil
+}
+
+// forEachCall calls fn for every direct call instruction in the text data with its pc and call target.
+// Since instructions are not decoded, some of the calls are false positives,
+// so callers need to check that the call target is meaningful.
+func forEachCall(target *targets.Target, data []byte, textAddr uint64, fn func(pc, target uint64)) {
 	arch := arches[target.Arch]
 	for i, opcode := range data {
 		if opcode != arch.opcodes[0] && opcode != arch.opcodes[1] {
@@ -444,15 +470,9 @@ func readCoverPoints(target *targets.Target, info *symbolInfo, data []byte) ([2]
 		if i < 0 || i+arch.callLen > len(data) {
 			continue
 		}
-		pc := info.textAddr + uint64(i)
-		target := arch.target(&arch, data[i:], pc, opcode)
-		if target == info.tracePC {
-			pcs[0] = append(pcs[0], pc)
-		} else if info.traceCmp[target] {
-			pcs[1] = append(pcs[1], pc)
-		}
+		pc := textAddr + uint64(i)
+		fn(pc, arch.target(&arch, data[i:], pc, opcode))
 	}
-	return pcs, nil
 }
 
 func cleanPath(path, objDir, srcDir, buildDir string) (string, string) {
@@ -92,6 +92,9 @@ type CallInfo struct {
 	// if dedup == false, then cov effectively contains a trace, otherwise duplicates are removed
 	Comps prog.CompMap // per-call comparison operands
 	Errno int          // call errno (0 if the call was successful)
+	// Wall time of the call (with millisecond precision),
+	// for unfinished calls it's the time until the executor stopped waiting for them.
+	Duration time.Duration
 }
 
 type ProgInfo struct {
@@ -355,6 +358,7 @@ func (env *Env) parseOutput(p *prog.Prog, opts *ExecOpts) (*ProgInfo, error) {
 			}
 			inf.Errno = int(reply.errno)
 			inf.Flags = CallFlags(reply.flags)
+			inf.Duration = time.Duration(reply.duration) * time.Millisecond
 		} else {
 			extraParts = append(extraParts, CallInfo{})
 			inf = &extraParts[len(extraParts)-1]
@@ -542,6 +546,7 @@ type callReply struct {
 	num        uint32 // syscall number (for cross-checking)
 	errno      uint32
 	flags      uint32 // see CallFlags
+	duration   uint32 // in milliseconds
 	signalSize uint32
 	coverSize  uint32
 	compsSize  uint32
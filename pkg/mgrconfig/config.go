@@ -5,6 +5,7 @@ package mgrconfig
 
 import (
 	"encoding/json"
+	"time"
 
 	"github.com/google/syzkaller/pkg/asset"
 )
@@ -150,6 +151,11 @@ type Config struct {
 
 	// Reproduce, localize and minimize crashers (default: true).
 	Reproduce bool `json:"reproduce"`
+	// Minimize reproducers with delta debugging (see prog.MinimizeDD), experimental, default: false.
+	// It needs fewer test runs for long synthetic programs where most of calls are irrelevant,
+	// but more runs for short programs, there are no measurements on real reproducers yet.
+	// The number of test runs is shown in repro logs and can be compared with the default minimization.
+	ReproMinimizeDD bool `json:"repro_minimize_dd,omitempty"`
 
 	// The number of VMs that are reserved to only perform fuzzing and nothing else.
 	// Can be helpful e.g. to ensure that the pool of fuzzing VMs is never exhausted and
@@ -186,6 +192,87 @@ type Config struct {
 	// the output.
 	StraceBin string `json:"strace_bin"`
 
+	// Weights and rate limits of candidate queues (optional).
+	// Candidates are inputs that need triage, they come from 3 sources with separate queues:
+	// "corpus" (corpus and seeds loaded on start), "hub" (inputs from syz-hub)
+	// and "enrich" (external programs loaded with -enrich flag).
+	// Non-empty queues are served in proportion to their weights (default: 1),
+	// non-zero rate limits the number of candidates handed out from the queue per minute. For example:
+	//	"candidate_queues": {"corpus": {"weight": 3}, "enrich": {"weight": 1, "rate": 600}}
+	CandidateQueues map[string]CandidateQueue `json:"candidate_queues,omitempty"`
+	// Max number of near-identical corpus inputs and pending candidates per structural fingerprint
+	// (see prog.Prog.Fingerprint) to admit candidates from seeds, syz-hub and enrichment, 0 means no limit.
+	// Programs loaded from corpus.db are always admitted, but count towards the limit.
+	MaxCandidatesPerFingerprint int `json:"max_candidates_per_fingerprint,omitempty"`
+
+	// Path to a JSON file with external call-to-call priorities (optional).
+	// The file maps call names to relative weights of calls that are likely related to them, e.g.:
+	//	{"ioctl$KVM_CREATE_VM": {"ioctl$KVM_CREATE_VCPU": 10, "ioctl$KVM_CHECK_EXTENSION_VM": 1}}
+	// The priors are blended into the choice table of fuzzers (see prog.MakeCallPriors).
+	CallPriors string `json:"call_priors,omitempty"`
+	// Share of the call priors in the blended priorities in [0..1] range (default: 0.5).
+	CallPriorsFactor float64 `json:"call_priors_factor"`
+	// Share of call priors learned from outcomes of call insertions in the blended priorities
+	// in [0..1] range (default: 0, learned priors are disabled).
+	// Fuzzers report which inserted calls gave new inputs and the manager periodically folds
+	// the aggregated success rates into the choice tables of fuzzers.
+	// The aggregated outcomes are saved in workdir/learned_priors.json.
+	LearnedPriorsFactor float64 `json:"learned_priors_factor"`
+	// Power schedule that chooses corpus programs for mutation (default: signal):
+	//	signal: programs are chosen with probability proportional to their signal size;
+	//	rare: favor programs that cover signal covered by few other corpus programs;
+	//	recent: favor recently added programs;
+	//	productive: favor programs whose mutations recently gave new inputs.
+	// Fuzzers report "schedule mutations" and "schedule new inputs" stats to compare schedules.
+	PowerSchedule string `json:"power_schedule,omitempty"`
+
+	// Focus fuzzing on a set of syscalls for a while (optional), e.g.:
+	//	"focus": {"calls": ["io_uring_setup", "syz_io_uring_*"], "strength": 0.5, "duration": "2h"}
+	// While the focus lasts, fuzzers choose corpus programs with the focus calls for mutation more often,
+	// insert and generate the focus calls and calls related to them more often, and spend more
+	// smash and hint executions on inputs with the focus calls.
+	// Focus can also be changed at runtime on the /focus manager page.
+	Focus *Focus `json:"focus,omitempty"`
+
+	// Directory with program templates (optional).
+	// Templates are programs where some arguments are replaced with holes: ? or ?int[begin:end], e.g.:
+	//	ioctl$KVM_CREATE_VM(r0, 0xae01, ?int[0:2])
+	// Fuzzers generate some of the programs by filling holes of templates with random values,
+	// and mutations of such programs change only arguments inside of the holes (see prog.GenerateFromTemplate).
+	Templates string `json:"templates,omitempty"`
+
+	// Direct fuzzing towards kernel functions or PCs (optional), e.g.:
+	//	"directed": {"functions": ["tcp_rcv_established"], "pcs": ["0xffffffff81234567"]}
+	// The manager computes call graph distances to the targets from the kernel binary (requires kernel_obj),
+	// fuzzers choose corpus programs for mutation and spend smash executions preferring inputs
+	// whose coverage gets closer to the targets. Progress towards the targets is shown on the /directed page.
+	Directed *Directed `json:"directed,omitempty"`
+
+	// Send each VM a subset of the corpus that fits into the budget instead of the full corpus (optional), e.g.:
+	//	"corpus_shards": {"budget": 65536, "swap_period": 30}
+	// The corpus is split into shards, the subset for a shard includes programs of the shard
+	// and programs of other shards that cover signal not covered by the shard.
+	// Running VMs periodically switch to another shard. Shards are shown on the /shards page.
+	CorpusShards *CorpusShards `json:"corpus_shards,omitempty"`
+
+	// Record how fuzzers generate and mutate programs (see prog.Trace), default: false.
+	// Traces of new corpus inputs are saved to workdir/traces/<sig>.json,
+	// traces of executed programs are printed in execution logs (and so in crash logs).
+	// A trace can be replayed with syz-mutate -replay.
+	TraceMutations bool `json:"trace_mutations,omitempty"`
+
+	// Schedule of experiment phases (optional).
+	// Phases are applied in order starting from the moment the first fuzzer connects.
+	// Each phase lasts for the given duration and overrides some of the settings while it lasts,
+	// after the last phase the manager returns to the base configuration. For example:
+	//	"phases": [
+	//		{"name": "no-seeds", "duration": "6h", "enrich": "none"},
+	//		{"name": "seeds", "duration": "6h", "enrich": "/seeds", "enrich_period": "30m"},
+	//		{"name": "kvm", "duration": "2h", "enable_syscalls": ["openat$kvm", "ioctl$KVM*"]}
+	//	]
+	// Phase boundaries and per-phase deltas are written to the bench file and to <workdir>/phases.json.
+	Phases []Phase `json:"phases,omitempty"`
+
 	// Type of virtual machine to use, e.g. "qemu", "gce", "android", "isolated", etc.
 	Type string `json:"type"`
 	// VM-type-specific parameters.
@@ -211,6 +298,71 @@ type Subsystem struct {
 	Paths []string `json:"path"`
 }
 
+type CandidateQueue struct {
+	Weight int `json:"weight,omitempty"`
+	Rate   int `json:"rate,omitempty"`
+}
+
+type Phase struct {
+	// Phase name used in logs and reports (defaults to the phase index).
+	Name string `json:"name,omitempty"`
+	// Phase duration in time.ParseDuration format, e.g. "6h".
+	Duration string `json:"duration"`
+	// Restrict fuzzing to these syscalls while the phase lasts (same format as enable_syscalls).
+	// VMs are restarted when the phase starts and ends to apply the restriction.
+	EnabledSyscalls  []string `json:"enable_syscalls,omitempty"`
+	DisabledSyscalls []string `json:"disable_syscalls,omitempty"`
+	// Directory with external programs to enrich corpus with ("none" disables enrichment).
+	// If empty, the -enrich flag value is used.
+	Enrich string `json:"enrich,omitempty"`
+	// Enrichment period, if empty the -period flag value is used.
+	EnrichPeriod string `json:"enrich_period,omitempty"`
+	// Enable/disable syncing with syz-hub (by default hub sync is not changed).
+	HubSync *bool `json:"hub_sync,omitempty"`
+	// Enable/disable crash reproduction (by default reproduce setting is not changed).
+	Reproduce *bool `json:"reproduce,omitempty"`
+
+	// Filled after parsing.
+	Length    time.Duration `json:"-"`
+	EnrichDur time.Duration `json:"-"`
+	Syscalls  []int         `json:"-"`
+}
+
+type Focus struct {
+	// Focus syscalls (same format as enable_syscalls).
+	Calls []string `json:"calls"`
+	// Strength of the bias towards the focus calls in (0, 1] range (default: 0.5),
+	// with strength 1 fuzzers mutate only programs with the focus calls (if there are any).
+	Strength float64 `json:"strength,omitempty"`
+	// Focus duration since it was set in time.ParseDuration format (default: "1h").
+	Duration string `json:"duration,omitempty"`
+
+	// Filled after parsing.
+	Length   time.Duration `json:"-"`
+	Syscalls []int         `json:"-"`
+}
+
+type CorpusShards struct {
+	// Budget for the corpus of a VM in KB, estimated as the size of serialized programs and their signal.
+	Budget int `json:"budget"`
+	// Period of switching VMs to another shard in minutes (default: 30, -1 disables switching).
+	SwapPeriod int `json:"swap_period,omitempty"`
+}
+
+type Directed struct {
+	// Target kernel functions (exact symbol names).
+	Functions []string `json:"functions,omitempty"`
+	// Target kernel coverage PCs in hex, other PCs of their functions are at distance 1.
+	PCs []string `json:"pcs,omitempty"`
+	// Share of corpus programs chosen for mutation by the distance in (0, 1] range (default: 0.5).
+	Strength float64 `json:"strength,omitempty"`
+	// Call graph distance from which coverage is not considered close to the targets (default: 10).
+	MaxDistance int `json:"max_distance,omitempty"`
+
+	// Filled after parsing.
+	RawPCs []uint64 `json:"-"`
+}
+
 type covFilterCfg struct {
 	Files     []string `json:"files,omitempty"`
 	Functions []string `json:"functions,omitempty"`
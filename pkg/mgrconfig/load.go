@@ -4,12 +4,15 @@
 package mgrconfig
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
 	"regexp"
 	"runtime"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/google/syzkaller/pkg/config"
 	"github.com/google/syzkaller/pkg/osutil"
@@ -36,6 +39,13 @@ type Derived struct {
 	Syscalls      []int
 	NoMutateCalls map[int]bool // Set of IDs of syscalls which should not be mutated.
 	Timeouts      targets.Timeouts
+
+	// Contents of the call_priors file and the priors themselves.
+	CallPriorWeights map[string]map[string]float64
+	CallPriorsTable  *prog.CallPriors
+
+	// Contents of the program templates.
+	TemplateData [][]byte
 }
 
 func LoadData(data []byte) (*Config, error) {
@@ -86,6 +96,8 @@ func defaultValues() *Config {
 		MaxCrashLogs:   100,
 		Procs:          6,
 		PreserveCorpus: true,
+
+		CallPriorsFactor: 0.5,
 	}
 }
 
@@ -181,6 +193,55 @@ func Complete(cfg *Config) error {
 	if err != nil {
 		return err
 	}
+	for name, queue := range cfg.CandidateQueues {
+		switch name {
+		case "corpus", "hub", "enrich":
+		default:
+			return fmt.Errorf("unknown candidate queue %q, must be one of corpus/hub/enrich", name)
+		}
+		if queue.Weight < 0 || queue.Rate < 0 {
+			return fmt.Errorf("candidate queue %v: weight and rate can't be negative", name)
+		}
+	}
+	if err := ParsePhases(cfg.Target, cfg.Phases); err != nil {
+		return err
+	}
+	if err := cfg.loadCallPriors(); err != nil {
+		return err
+	}
+	if cfg.LearnedPriorsFactor < 0 || cfg.LearnedPriorsFactor > 1 {
+		return fmt.Errorf("learned_priors_factor %v is out of [0, 1] range", cfg.LearnedPriorsFactor)
+	}
+	switch cfg.PowerSchedule {
+	case "", "signal", "rare", "recent", "productive":
+	default:
+		return fmt.Errorf("unknown power_schedule %q, must be one of signal/rare/recent/productive",
+			cfg.PowerSchedule)
+	}
+	if cfg.Focus != nil {
+		if err := ParseFocus(cfg.Target, cfg.Focus); err != nil {
+			return err
+		}
+	}
+	if err := cfg.loadTemplates(); err != nil {
+		return err
+	}
+	if cfg.Directed != nil {
+		if err := cfg.parseDirected(); err != nil {
+			return err
+		}
+	}
+	if shards := cfg.CorpusShards; shards != nil {
+		if shards.Budget <= 0 {
+			return fmt.Errorf("corpus_shards requires a positive budget")
+		}
+		if shards.SwapPeriod == 0 {
+			shards.SwapPeriod = 30
+		}
+		if shards.SwapPeriod < -1 {
+			return fmt.Errorf("bad corpus_shards swap_period %v", shards.SwapPeriod)
+		}
+	}
 	if !cfg.AssetStorage.IsEmpty() {
 		if cfg.DashboardClient == "" {
 			return fmt.Errorf("asset storage also requires dashboard client")
@@ -361,6 +422,144 @@ func ParseNoMutateSyscalls(target *prog.Target, syscalls []string) (map[int]bool
 	return result, nil
 }
 
+func (cfg *Config) loadCallPriors() error {
+	if cfg.CallPriors == "" {
+		return nil
+	}
+	cfg.CallPriors = osutil.Abs(cfg.CallPriors)
+	data, err := os.ReadFile(cfg.CallPriors)
+	if err != nil {
+		return fmt.Errorf("failed to read call_priors: %w", err)
+	}
+	if err := json.Unmarshal(data, &cfg.CallPriorWeights); err != nil {
+		return fmt.Errorf("failed to parse call_priors: %w", err)
+	}
+	cfg.CallPriorsTable, err = cfg.Target.MakeCallPriors(cfg.CallPriorWeights, cfg.CallPriorsFactor)
+	if err != nil {
+		return fmt.Errorf("bad call_priors: %w", err)
+	}
+	return nil
+}
+
+func (cfg *Config) loadTemplates() error {
+	if cfg.Templates == "" {
+		return nil
+	}
+	cfg.Templates = osutil.Abs(cfg.Templates)
+	files, err := os.ReadDir(cfg.Templates)
+	if err != nil {
+		return fmt.Errorf("failed to read templates: %w", err)
+	}
+	for _, file := range files {
+		if file.IsDir() {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(cfg.Templates, file.Name()))
+		if err != nil {
+			return fmt.Errorf("failed to read template: %w", err)
+		}
+		p, err := cfg.Target.Deserialize(data, prog.NonStrict)
+		if err != nil {
+			return fmt.Errorf("bad template %v: %w", file.Name(), err)
+		}
+		if !p.IsTemplate() {
+			return fmt.Errorf("template %v has no holes", file.Name())
+		}
+		if !p.IsMutableTemplate(cfg.NoMutateCalls) {
+			return fmt.Errorf("template %v has holes only in no_mutate_syscalls", file.Name())
+		}
+		cfg.TemplateData = append(cfg.TemplateData, data)
+	}
+	if len(cfg.TemplateData) == 0 {
+		return fmt.Errorf("no templates in %v", cfg.Templates)
+	}
+	return nil
+}
+
+// ParsePhases validates the experiment phase schedule and fills in the derived phase fields.
+func ParsePhases(target *prog.Target, phases []Phase) error {
+	for i := range phases {
+		phase := &phases[i]
+		if phase.Name == "" {
+			phase.Name = fmt.Sprint(i)
+		}
+		var err error
+		phase.Length, err = time.ParseDuration(phase.Duration)
+		if err != nil || phase.Length <= 0 {
+			return fmt.Errorf("bad duration %q for phase %v", phase.Duration, phase.Name)
+		}
+		if phase.EnrichPeriod != "" {
+			phase.EnrichDur, err = time.ParseDuration(phase.EnrichPeriod)
+			if err != nil || phase.EnrichDur <= 0 {
+				return fmt.Errorf("bad enrich_period %q for phase %v", phase.EnrichPeriod, phase.Name)
+			}
+		}
+		if len(phase.EnabledSyscalls) != 0 || len(phase.DisabledSyscalls) != 0 {
+			phase.Syscalls, err = ParseEnabledSyscalls(target, phase.EnabledSyscalls, phase.DisabledSyscalls)
+			if err != nil {
+				return fmt.Errorf("phase %v: %w", phase.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// ParseFocus validates the focus and fills in the derived focus fields.
+func ParseFocus(target *prog.Target, focus *Focus) error {
+	if len(focus.Calls) == 0 {
+		return fmt.Errorf("focus has no calls")
+	}
+	var err error
+	focus.Syscalls, err = ParseEnabledSyscalls(target, focus.Calls, nil)
+	if err != nil {
+		return fmt.Errorf("focus: %w", err)
+	}
+	if focus.Strength == 0 {
+		focus.Strength = 0.5
+	}
+	if focus.Strength < 0 || focus.Strength > 1 {
+		return fmt.Errorf("focus strength %v is out of (0, 1] range", focus.Strength)
+	}
+	if focus.Duration == "" {
+		focus.Duration = "1h"
+	}
+	focus.Length, err = time.ParseDuration(focus.Duration)
+	if err != nil || focus.Length <= 0 {
+		return fmt.Errorf("bad focus duration %q", focus.Duration)
+	}
+	return nil
+}
+
+func (cfg *Config) parseDirected() error {
+	directed := cfg.Directed
+	if !cfg.Cover {
+		return fmt.Errorf("directed fuzzing requires cover")
+	}
+	if len(directed.Functions)+len(directed.PCs) == 0 {
+		return fmt.Errorf("directed fuzzing has no targets")
+	}
+	for _, pc := range directed.PCs {
+		v, err := strconv.ParseUint(pc, 0, 64)
+		if err != nil {
+			return fmt.Errorf("bad directed pc %q: %w", pc, err)
+		}
+		directed.RawPCs = append(directed.RawPCs, v)
+	}
+	if directed.Strength == 0 {
+		directed.Strength = 0.5
+	}
+	if directed.Strength < 0 || directed.Strength > 1 {
+		return fmt.Errorf("directed strength %v is out of (0, 1] range", directed.Strength)
+	}
+	if directed.MaxDistance == 0 {
+		directed.MaxDistance = 10
+	}
+	if directed.MaxDistance < 0 {
+		return fmt.Errorf("bad directed max_distance %v", directed.MaxDistance)
+	}
+	return nil
+}
+
 func MatchSyscall(name, pattern string) bool {
 	if pattern == name || strings.HasPrefix(name, pattern+"$") {
 		return true
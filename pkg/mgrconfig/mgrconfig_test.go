@@ -6,9 +6,12 @@ package mgrconfig_test
 import (
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/google/syzkaller/pkg/config"
 	. "github.com/google/syzkaller/pkg/mgrconfig"
+	"github.com/google/syzkaller/prog"
+	"github.com/google/syzkaller/sys/targets"
 	"github.com/google/syzkaller/vm/gce"
 	"github.com/google/syzkaller/vm/proxyapp"
 	"github.com/google/syzkaller/vm/qemu"
@@ -66,3 +69,37 @@ func TestMatchSyscall(t *testing.T) {
 		}
 	}
 }
+
+func TestParsePhases(t *testing.T) {
+	target, err := prog.GetTarget(targets.TestOS, targets.TestArch64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	phases := []Phase{
+		{Duration: "6h", Enrich: "none"},
+		{Name: "seeds", Duration: "30m", EnrichPeriod: "5m"},
+		{Name: "restricted", Duration: "1h", EnabledSyscalls: []string{"test$res0"}},
+	}
+	if err := ParsePhases(target, phases); err != nil {
+		t.Fatal(err)
+	}
+	if phases[0].Name != "0" || phases[0].Length != 6*time.Hour {
+		t.Errorf("bad phase 0: %+v", phases[0])
+	}
+	if phases[1].EnrichDur != 5*time.Minute {
+		t.Errorf("bad phase 1 enrich period: %v", phases[1].EnrichDur)
+	}
+	if len(phases[2].Syscalls) != 1 || target.Syscalls[phases[2].Syscalls[0]].Name != "test$res0" {
+		t.Errorf("bad phase 2 syscalls: %v", phases[2].Syscalls)
+	}
+	for _, bad := range [][]Phase{
+		{{Duration: ""}},
+		{{Duration: "-1h"}},
+		{{Duration: "1h", EnrichPeriod: "foo"}},
+		{{Duration: "1h", EnabledSyscalls: []string{"nonexistent"}}},
+	} {
+		if err := ParsePhases(target, bad); err == nil {
+			t.Errorf("no error for bad phases %+v", bad)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+// Copyright 2026 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+// Package progring keeps recently executed programs in memory and transfers them
+// over the console in a compact encoded form. This allows to reproduce crashes
+// even if fuzzers don't log executed programs (syz-fuzzer -output=none).
+package progring
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Ring holds the last programs added to it.
+// Programs are serialized only when they are requested, since that's rare compared to additions.
+type Ring struct {
+	mu    sync.Mutex
+	progs []Prog
+	pos   int
+	full  bool
+}
+
+// Prog is a program kept in the ring, e.g. *prog.Prog.
+type Prog interface {
+	Serialize() []byte
+}
+
+// Serialized is an already serialized program.
+type Serialized []byte
+
+func (data Serialized) Serialize() []byte {
+	return data
+}
+
+func New(size int) *Ring {
+	return &Ring{progs: make([]Prog, size)}
+}
+
+// Add adds a program to the ring, the oldest program is evicted if the ring is full.
+// The ring takes ownership of p, so it must not be changed afterwards.
+func (r *Ring) Add(p Prog) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.progs) == 0 {
+		return
+	}
+	r.progs[r.pos] = p
+	r.pos++
+	if r.pos == len(r.progs) {
+		r.pos = 0
+		r.full = true
+	}
+}
+
+// Progs returns the serialized programs from the oldest to the newest.
+func (r *Ring) Progs() [][]byte {
+	r.mu.Lock()
+	var progs []Prog
+	if r.full {
+		progs = append(progs, r.progs[r.pos:]...)
+	}
+	progs = append(progs, r.progs[:r.pos]...)
+	r.mu.Unlock()
+	var res [][]byte
+	for _, p := range progs {
+		res = append(res, p.Serialize())
+	}
+	return res
+}
+
+// Line length of the encoded data, consoles may truncate or wrap longer lines.
+const chunkSize = 512
+
+const marker = "syz-recent-progs"
+
+var lineRe = regexp.MustCompile(marker + ` proc=(\d+) part=(\d+)/(\d+): ([A-Za-z0-9+/=]+)`)
+
+// Dump writes the programs in the ring to w in the encoded form that can be extracted with Extract.
+// proc is the number of the fuzzer proc the programs were executed by.
+func (r *Ring) Dump(w io.Writer, proc int) error {
+	progs := r.Progs()
+	if len(progs) == 0 {
+		return nil
+	}
+	compressed := new(bytes.Buffer)
+	zw := zlib.NewWriter(compressed)
+	for _, data := range progs {
+		fmt.Fprintf(zw, "executing program %v:\n%s\n", proc, data)
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	encoded := base64.StdEncoding.EncodeToString(compressed.Bytes())
+	parts := (len(encoded) + chunkSize - 1) / chunkSize
+	buf := new(bytes.Buffer)
+	for i := 0; i < parts; i++ {
+		end := (i + 1) * chunkSize
+		if end > len(encoded) {
+			end = len(encoded)
+		}
+		fmt.Fprintf(buf, "%v proc=%v part=%v/%v: %v\n", marker, proc, i+1, parts, encoded[i*chunkSize:end])
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// Extract decodes all complete dumps in the console output.
+// The result is an execution log in the format understood by prog.ParseLog,
+// programs of each dump go in the order they were executed. If the same proc
+// dumped its programs several times, only the last dump is extracted.
+func Extract(output []byte) []byte {
+	type dump struct {
+		parts []string
+		seen  int
+	}
+	dumps := make(map[int]*dump)
+	for _, match := range lineRe.FindAllSubmatch(output, -1) {
+		proc, _ := strconv.Atoi(string(match[1]))
+		part, _ := strconv.Atoi(string(match[2]))
+		parts, _ := strconv.Atoi(string(match[3]))
+		if parts <= 0 || part <= 0 || part > parts {
+			continue
+		}
+		d := dumps[proc]
+		if d == nil || part == 1 || len(d.parts) != parts {
+			d = &dump{parts: make([]string, parts)}
+			dumps[proc] = d
+		}
+		if d.parts[part-1] == "" {
+			d.seen++
+		}
+		d.parts[part-1] = string(match[4])
+	}
+	var procs []int
+	for proc := range dumps {
+		procs = append(procs, proc)
+	}
+	sort.Ints(procs)
+	res := new(bytes.Buffer)
+	for _, proc := range procs {
+		d := dumps[proc]
+		if d.seen != len(d.parts) {
+			continue
+		}
+		compressed, err := base64.StdEncoding.DecodeString(strings.Join(d.parts, ""))
+		if err != nil {
+			continue
+		}
+		zr, err := zlib.NewReader(bytes.NewReader(compressed))
+		if err != nil {
+			continue
+		}
+		data, err := io.ReadAll(zr)
+		if err != nil {
+			continue
+		}
+		res.Write(data)
+	}
+	return res.Bytes()
+}
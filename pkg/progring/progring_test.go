@@ -0,0 +1,68 @@
+// Copyright 2026 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package progring
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestRing(t *testing.T) {
+	r := New(3)
+	if progs := r.Progs(); len(progs) != 0 {
+		t.Fatalf("empty ring returned %q", progs)
+	}
+	for i := 0; i < 5; i++ {
+		r.Add(Serialized(fmt.Sprintf("prog%v()", i)))
+	}
+	got := fmt.Sprintf("%s", r.Progs())
+	if want := "[prog2() prog3() prog4()]"; got != want {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	New(0).Add(Serialized("prog()"))
+}
+
+func TestDumpExtract(t *testing.T) {
+	r0, r1 := New(100), New(100)
+	for i := 0; i < 100; i++ {
+		// Large programs to produce multi-line dumps.
+		r0.Add(Serialized(fmt.Sprintf("prog%v(%v)", i, strings.Repeat(fmt.Sprintf("0x%x, ", i*i), i))))
+	}
+	r1.Add(Serialized("foo()"))
+	out0, out1 := new(bytes.Buffer), new(bytes.Buffer)
+	if err := r0.Dump(out0, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := r1.Dump(out1, 1); err != nil {
+		t.Fatal(err)
+	}
+	lines0 := strings.Split(strings.TrimSpace(out0.String()), "\n")
+	if len(lines0) < 2 {
+		t.Fatalf("expected a multi-line dump, got %v lines", len(lines0))
+	}
+	// Interleave the dumps with other console output.
+	output := new(bytes.Buffer)
+	output.WriteString("[  100.000] kernel message\n")
+	for i, line := range lines0 {
+		fmt.Fprintf(output, "[  101.%03v] %v\n", i, line)
+		if i == 0 {
+			output.Write(out1.Bytes())
+		}
+	}
+	want := new(bytes.Buffer)
+	for _, p := range r0.Progs() {
+		fmt.Fprintf(want, "executing program 0:\n%s\n", p)
+	}
+	want.WriteString("executing program 1:\nfoo()\n")
+	if got := Extract(output.Bytes()); !bytes.Equal(got, want.Bytes()) {
+		t.Fatalf("got:\n%s\nwant:\n%s", got, want.Bytes())
+	}
+	// Incomplete dumps are ignored.
+	truncated := strings.Join(lines0[:len(lines0)-1], "\n")
+	if got := Extract([]byte(truncated)); len(got) != 0 {
+		t.Fatalf("extracted a truncated dump:\n%s", got)
+	}
+}
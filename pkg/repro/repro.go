@@ -38,6 +38,7 @@ type Stats struct {
 	Log              []byte
 	ExtractProgTime  time.Duration
 	MinimizeProgTime time.Duration
+	MinimizeProgRuns int
 	SimplifyProgTime time.Duration
 	ExtractCTime     time.Duration
 	SimplifyCTime    time.Duration
@@ -63,6 +64,7 @@ type context struct {
 	stats        *Stats
 	report       *report.Report
 	timeouts     targets.Timeouts
+	minimizeDD   bool
 }
 
 // execInterface describes what's needed from a VM by a pkg/repro.
@@ -143,6 +145,7 @@ func prepareCtx(crashLog []byte, cfg *mgrconfig.Config, features *host.Features,
 		startOpts:    createStartOptions(cfg, features, crashType),
 		stats:        new(Stats),
 		timeouts:     cfg.Timeouts,
+		minimizeDD:   cfg.ReproMinimizeDD,
 	}
 	ctx.reproLogf(0, "%v programs, %v VMs, timeouts %v", len(entries), VMs, testTimeouts)
 	return ctx, nil
@@ -408,8 +411,13 @@ func (ctx *context) minimizeProg(res *Result) (*Result, error) {
 		ctx.stats.MinimizeProgTime = time.Since(start)
 	}()
 
-	res.Prog, _ = prog.Minimize(res.Prog, -1, true,
+	minimize := prog.Minimize
+	if ctx.minimizeDD {
+		minimize = prog.MinimizeDD
+	}
+	res.Prog, _ = minimize(res.Prog, -1, true,
 		func(p1 *prog.Prog, callIndex int) bool {
+			ctx.stats.MinimizeProgRuns++
 			crashed, err := ctx.testProg(p1, res.Duration, res.Opts)
 			if err != nil {
 				ctx.reproLogf(0, "minimization failed with %v", err)
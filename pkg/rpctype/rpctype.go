@@ -21,12 +21,21 @@ type Input struct {
 	CallID     int // seq number of call in the prog to which the item is related (-1 for extra)
 	RawCover   []uint32
 	CoverCalls map[string]struct{} // covered calls in the prog
+	Origin     string              // what produced the input (gen, fuzz, smash, etc, or the candidate source)
+	// JSON-serialized prog.Trace of the input (only with trace_mutations).
+	Trace []byte
+	// Minimal call graph distance of the input coverage to the directed fuzzing targets,
+	// -1 if it's beyond the max distance. It's computed by the fuzzer that found the input,
+	// since coverage is not sent to other fuzzers.
+	DirectedDistance int
 }
 
 type Candidate struct {
 	Prog      []byte
 	Minimized bool
 	Smashed   bool
+	Source    string // where the candidate comes from (corpus, hub, enrich)
+	Seed      bool   // externally sourced seed that deserves more attention than a regular candidate
 }
 
 type ExecTask struct {
@@ -50,6 +59,27 @@ type ConnectRes struct {
 	MemoryLeakFrames  []string
 	DataRaceFrames    []string
 	CoverFilterBitmap []byte
+	// External call priors for the choice table, see prog.MakeCallPriors.
+	CallPriors       map[string]map[string]float64
+	CallPriorsFactor float64
+	// Call priors learned from call insertion outcomes reported by fuzzers.
+	LearnedCallPriors       map[string]map[string]float64
+	LearnedCallPriorsFactor float64
+	TraceMutations          bool
+	PowerSchedule           string
+	// Program templates, see prog.GenerateFromTemplate.
+	Templates [][]byte
+	Directed  *Directed
+	// Share of flaky triaged inputs per syscall ID learned by the manager, see CallFlakeStats.
+	CallFlakiness map[int]float64
+}
+
+// Directed guides fuzzing towards target PCs, see mgrconfig.Config.Directed.
+type Directed struct {
+	// Call graph distance to the closest target for coverage PCs within MaxDistance.
+	Distances   map[uint32]uint32
+	Strength    float64
+	MaxDistance int
 }
 
 type CheckArgs struct {
@@ -76,12 +106,66 @@ type PollArgs struct {
 	NeedCandidates bool
 	MaxSignal      signal.Serial
 	Stats          map[string]uint64
+	CallPairs      []CallPairStats
+	CallResults    []CallResultStats
+	CallFlakes     []CallFlakeStats
+	// Heap memory in use by the fuzzer in bytes.
+	Memory uint64
+	// Hashes (hash.String of Candidate.Prog) of candidates the fuzzer has finished triaging
+	// since the previous poll, including triage of the inputs they gave.
+	TriagedCandidates []string
+}
+
+// CallPairStats are outcomes of insertions of call Next biased to call Prev (syscall IDs)
+// since the previous poll: the number of insertions and the number of resulting new corpus inputs.
+type CallPairStats struct {
+	Prev      int
+	Next      int
+	Inserts   uint32
+	NewInputs uint32
+}
+
+// CallResultStats are results of executions of the call ID (syscall ID) since the previous poll:
+// the number of executions, the number of successful executions, the number of failures per errno,
+// the histogram of call durations and the number of calls that hit the syscall timeout.
+type CallResultStats struct {
+	ID      int
+	Execs   uint32
+	Success uint32
+	Errnos  map[int]uint32
+	// Durations[0] is the number of calls that took less than 1ms,
+	// Durations[i] is the number of calls that took [2^(i-1), 2^i) ms.
+	Durations []uint32
+	Timeouts  uint32
+}
+
+// CallFlakeStats are results of triage of inputs for the call ID (syscall ID) since the previous poll:
+// the number of triaged inputs, the number of inputs with some new signal that was not reproduced
+// by all triage runs, the total amount of new signal and the amount of new signal that was not reproduced.
+type CallFlakeStats struct {
+	ID       int
+	Triaged  uint32
+	Flaky    uint32
+	Signal   uint32
+	Unstable uint32
 }
 
 type PollRes struct {
 	Candidates []Candidate
 	NewInputs  []Input
 	MaxSignal  signal.Serial
+	// Current focus of fuzzing, nil if there is none.
+	Focus *Focus
+	// The fuzzer needs to drop its corpus before adding NewInputs (see mgrconfig.Config.CorpusShards).
+	ResetCorpus bool
+	// Updated learned call priors (see ConnectRes.LearnedCallPriors), nil if they have not changed.
+	LearnedCallPriors map[string]map[string]float64
+}
+
+// Focus biases fuzzing towards a set of syscalls, see mgrconfig.Config.Focus.
+type Focus struct {
+	Calls    []int
+	Strength float64
 }
 
 type RunnerConnectArgs struct {
@@ -0,0 +1,394 @@
+// Copyright 2026 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+// Package summary generates the final report of a fuzzing campaign from the manager workdir.
+// The report is generated only from files in the workdir, so it can be regenerated offline:
+//
+//	cmdline            - command line of the manager (written on start)
+//	config.json        - effective manager config (written on start)
+//	timeline.jsonl     - manager stats, one JSON object per minute (a subset of the -bench file keys plus "time")
+//	covered_syscalls   - "<unix time> <syscall>" lines in the order syscalls first appeared in the corpus
+//	crashes/           - crashes saved by the manager
+package summary
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/syzkaller/pkg/html/pages"
+	"github.com/google/syzkaller/pkg/osutil"
+)
+
+const (
+	TimelineFile        = "timeline.jsonl"
+	CoveredSyscallsFile = "covered_syscalls"
+	ConfigFile          = "config.json"
+	CmdlineFile         = "cmdline"
+	FirstSeenFile       = "first_seen" // in crashes/<id>/
+)
+
+type Summary struct {
+	Name      string          `json:"name,omitempty"`
+	Generated time.Time       `json:"generated"`
+	Cmdline   string          `json:"cmdline"`
+	Config    json.RawMessage `json:"config,omitempty"`
+	Start     time.Time       `json:"start"`
+	End       time.Time       `json:"end"`
+	Uptime    uint64          `json:"uptime_sec"`
+	Fuzzing   uint64          `json:"fuzzing_sec"` // total VM time spent fuzzing
+	Execs     uint64          `json:"execs"`
+	ExecRate  float64         `json:"exec_rate"` // executions per second of uptime
+	Coverage  uint64          `json:"coverage"`
+	Signal    uint64          `json:"signal"`
+	MaxSignal uint64          `json:"max_signal"`
+	Corpus    uint64          `json:"corpus"`
+	Timeline  []Point         `json:"timeline"`
+	Syscalls  []Syscall       `json:"syscalls"` // covered syscalls in the order of coverage
+	Enrich    Enrich          `json:"enrich"`
+	// Number of corpus inputs per origin (gen, fuzz, smash, corpus, hub, enrich, etc).
+	Origins map[string]uint64 `json:"origins,omitempty"`
+	Crashes []Crash           `json:"crashes"`
+}
+
+type Point struct {
+	Uptime   uint64 `json:"uptime_sec"`
+	Coverage uint64 `json:"coverage"`
+	Signal   uint64 `json:"signal"`
+	Corpus   uint64 `json:"corpus"`
+	Execs    uint64 `json:"execs"`
+}
+
+type Syscall struct {
+	Name    string    `json:"name"`
+	Covered time.Time `json:"covered"`
+}
+
+type Enrich struct {
+	Seeds  uint64 `json:"seeds"`  // enriched seeds loaded
+	Inputs uint64 `json:"inputs"` // corpus inputs originated from enriched seeds
+	// Inputs per seed (note that a seed can give several inputs for different calls).
+	AcceptanceRate float64 `json:"acceptance_rate"`
+}
+
+type Crash struct {
+	Title     string    `json:"title"`
+	Count     int       `json:"count"` // number of saved logs
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+	Repro     string    `json:"repro,omitempty"`
+}
+
+// Generate collects the summary from the workdir.
+func Generate(workdir string) (*Summary, error) {
+	s := &Summary{
+		Generated: time.Now(),
+		Origins:   make(map[string]uint64),
+	}
+	if data, err := os.ReadFile(filepath.Join(workdir, CmdlineFile)); err == nil {
+		s.Cmdline = strings.TrimSpace(string(data))
+	}
+	if data, err := os.ReadFile(filepath.Join(workdir, ConfigFile)); err == nil {
+		cfg := struct {
+			Name string `json:"name"`
+		}{}
+		if err := json.Unmarshal(data, &cfg); err != nil {
+			return nil, fmt.Errorf("failed to parse %v: %w", ConfigFile, err)
+		}
+		s.Name = cfg.Name
+		s.Config = data
+	}
+	if err := s.readTimeline(filepath.Join(workdir, TimelineFile)); err != nil {
+		return nil, err
+	}
+	if err := s.readSyscalls(filepath.Join(workdir, CoveredSyscallsFile)); err != nil {
+		return nil, err
+	}
+	if err := s.readCrashes(filepath.Join(workdir, "crashes")); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *Summary) readTimeline(file string) error {
+	f, err := os.Open(file)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	defer f.Close()
+	var last map[string]uint64
+	for dec := json.NewDecoder(f); ; {
+		var vals map[string]uint64
+		if err := dec.Decode(&vals); err != nil {
+			// Either EOF, or the manager was killed in the middle of writing, ignore the tail.
+			break
+		}
+		if s.Start.IsZero() {
+			s.Start = time.Unix(int64(vals["time"]), 0)
+		}
+		s.Timeline = append(s.Timeline, Point{
+			Uptime:   vals["uptime"],
+			Coverage: vals["coverage"],
+			Signal:   vals["signal"],
+			Corpus:   vals["corpus"],
+			Execs:    vals["exec total"],
+		})
+		last = vals
+	}
+	if last == nil {
+		return nil
+	}
+	s.End = time.Unix(int64(last["time"]), 0)
+	s.Uptime = last["uptime"]
+	s.Fuzzing = last["fuzzing"]
+	s.Execs = last["exec total"]
+	if s.Uptime != 0 {
+		s.ExecRate = float64(s.Execs) / float64(s.Uptime)
+	}
+	s.Coverage = last["coverage"]
+	s.Signal = last["signal"]
+	s.MaxSignal = last["max signal"]
+	s.Corpus = last["corpus"]
+	for k, v := range last {
+		if origin := strings.TrimPrefix(k, "origin "); origin != k {
+			s.Origins[origin] = v
+		}
+	}
+	s.Enrich.Seeds = last["EnrichCnt"]
+	s.Enrich.Inputs = s.Origins["enrich"]
+	if s.Enrich.Seeds != 0 {
+		s.Enrich.AcceptanceRate = float64(s.Enrich.Inputs) / float64(s.Enrich.Seeds)
+	}
+	return nil
+}
+
+func (s *Summary) readSyscalls(file string) error {
+	data, err := os.ReadFile(file)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	for sc := bufio.NewScanner(bytes.NewReader(data)); sc.Scan(); {
+		ts, name, ok := strings.Cut(sc.Text(), " ")
+		if !ok {
+			continue
+		}
+		sec, err := strconv.ParseInt(ts, 10, 64)
+		if err != nil {
+			continue
+		}
+		s.Syscalls = append(s.Syscalls, Syscall{Name: name, Covered: time.Unix(sec, 0)})
+	}
+	return nil
+}
+
+func (s *Summary) readCrashes(crashdir string) error {
+	dirs, err := osutil.ListDir(crashdir)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	for _, dir := range dirs {
+		if crash := readCrash(filepath.Join(crashdir, dir)); crash != nil {
+			s.Crashes = append(s.Crashes, *crash)
+		}
+	}
+	sort.Slice(s.Crashes, func(i, j int) bool {
+		return s.Crashes[i].FirstSeen.Before(s.Crashes[j].FirstSeen)
+	})
+	return nil
+}
+
+func readCrash(dir string) *Crash {
+	desc, err := os.ReadFile(filepath.Join(dir, "description"))
+	if err != nil || len(desc) == 0 {
+		return nil
+	}
+	files, err := osutil.ListDir(dir)
+	if err != nil {
+		return nil
+	}
+	crash := &Crash{Title: strings.TrimSpace(string(desc))}
+	hasRepro, hasCRepro, reproAttempts := false, false, 0
+	for _, f := range files {
+		switch {
+		case strings.HasPrefix(f, "log"):
+			crash.Count++
+			if info, err := os.Stat(filepath.Join(dir, f)); err == nil {
+				if crash.FirstSeen.IsZero() || info.ModTime().Before(crash.FirstSeen) {
+					crash.FirstSeen = info.ModTime()
+				}
+				if info.ModTime().After(crash.LastSeen) {
+					crash.LastSeen = info.ModTime()
+				}
+			}
+		case f == "repro.prog":
+			hasRepro = true
+		case f == "repro.cprog":
+			hasCRepro = true
+		case f == "repro0" || f == "repro1" || f == "repro2":
+			reproAttempts++
+		}
+	}
+	// Old logs are overwritten, so the oldest log is not necessarily the first crash.
+	if data, err := os.ReadFile(filepath.Join(dir, FirstSeenFile)); err == nil {
+		if t, err := time.Parse(time.RFC3339, strings.TrimSpace(string(data))); err == nil {
+			crash.FirstSeen = t
+		}
+	}
+	switch {
+	case hasCRepro:
+		crash.Repro = "has C repro"
+	case hasRepro:
+		crash.Repro = "has repro"
+	case reproAttempts >= 3:
+		crash.Repro = "non-reproducible"
+	}
+	return crash
+}
+
+// Write writes summary.json and summary.html to the workdir.
+func (s *Summary) Write(workdir string) error {
+	data, err := json.MarshalIndent(s, "", "\t")
+	if err != nil {
+		return err
+	}
+	if err := osutil.WriteFile(filepath.Join(workdir, "summary.json"), data); err != nil {
+		return err
+	}
+	buf := new(bytes.Buffer)
+	if err := summaryTemplate.Execute(buf, s); err != nil {
+		return err
+	}
+	return osutil.WriteFile(filepath.Join(workdir, "summary.html"), buf.Bytes())
+}
+
+const (
+	sparklineWidth  = 600
+	sparklineHeight = 60
+)
+
+// CoverageSparkline returns points of the SVG polyline for coverage over time.
+func (s *Summary) CoverageSparkline() string {
+	if len(s.Timeline) == 0 {
+		return ""
+	}
+	last := s.Timeline[len(s.Timeline)-1]
+	maxTime, maxCover := last.Uptime, uint64(0)
+	for _, p := range s.Timeline {
+		if p.Coverage > maxCover {
+			maxCover = p.Coverage
+		}
+	}
+	if maxTime == 0 {
+		maxTime = 1
+	}
+	if maxCover == 0 {
+		maxCover = 1
+	}
+	var points []string
+	for _, p := range s.Timeline {
+		x := float64(p.Uptime) * sparklineWidth / float64(maxTime)
+		y := sparklineHeight - float64(p.Coverage)*sparklineHeight/float64(maxCover)
+		points = append(points, fmt.Sprintf("%.1f,%.1f", x, y))
+	}
+	return strings.Join(points, " ")
+}
+
+func (s *Summary) UptimeString() string {
+	return (time.Duration(s.Uptime) * time.Second).String()
+}
+
+func (s *Summary) FuzzingString() string {
+	return (time.Duration(s.Fuzzing) * time.Second).String()
+}
+
+var summaryTemplate = pages.Create(`
+<!doctype html>
+<html>
+<head>
+	<title>{{.Name}} syzkaller campaign summary</title>
+	{{HEAD}}
+</head>
+<body>
+<b>{{.Name}} syzkaller campaign summary</b>
+<br>
+
+<table class="list_table">
+	<caption>Stats:</caption>
+	<tr><td class="stat_name">start</td><td class="stat_value">{{formatTime .Start}}</td></tr>
+	<tr><td class="stat_name">end</td><td class="stat_value">{{formatTime .End}}</td></tr>
+	<tr><td class="stat_name">uptime</td><td class="stat_value">{{.UptimeString}}</td></tr>
+	<tr><td class="stat_name">fuzzing</td><td class="stat_value">{{.FuzzingString}}</td></tr>
+	<tr><td class="stat_name">executions</td><td class="stat_value">{{.Execs}} ({{printf "%.1f" .ExecRate}}/sec)</td></tr>
+	<tr><td class="stat_name">coverage</td><td class="stat_value">{{.Coverage}}</td></tr>
+	<tr><td class="stat_name">signal</td><td class="stat_value">{{.Signal}} (max {{.MaxSignal}})</td></tr>
+	<tr><td class="stat_name">corpus</td><td class="stat_value">{{.Corpus}}</td></tr>
+	<tr><td class="stat_name">syscalls covered</td><td class="stat_value">{{len .Syscalls}}</td></tr>
+	<tr><td class="stat_name">enriched seeds</td><td class="stat_value">{{.Enrich.Seeds}}
+		({{.Enrich.Inputs}} inputs, {{printf "%.2f" .Enrich.AcceptanceRate}} per seed)</td></tr>
+	{{range $origin, $n := .Origins}}
+	<tr><td class="stat_name">inputs from {{$origin}}</td><td class="stat_value">{{$n}}</td></tr>
+	{{end}}
+</table>
+
+{{with .CoverageSparkline}}
+<b>Coverage over time:</b>
+<br>
+<svg width="600" height="60" style="border: 1px solid #ccc">
+	<polyline fill="none" stroke="#36c" stroke-width="1.5" points="{{.}}"/>
+</svg>
+<br>
+{{end}}
+
+<table class="list_table">
+	<caption>Crashes:</caption>
+	<tr>
+		<th><a onclick="return sortTable(this, 'Title', textSort)" href="#">Title</a></th>
+		<th><a onclick="return sortTable(this, 'Count', numSort)" href="#">Count</a></th>
+		<th><a onclick="return sortTable(this, 'First Seen', textSort)" href="#">First Seen</a></th>
+		<th><a onclick="return sortTable(this, 'Last Seen', textSort)" href="#">Last Seen</a></th>
+		<th><a onclick="return sortTable(this, 'Repro', textSort)" href="#">Repro</a></th>
+	</tr>
+	{{range $c := .Crashes}}
+	<tr>
+		<td class="title">{{$c.Title}}</td>
+		<td class="stat">{{$c.Count}}</td>
+		<td class="time">{{formatTime $c.FirstSeen}}</td>
+		<td class="time">{{formatTime $c.LastSeen}}</td>
+		<td>{{$c.Repro}}</td>
+	</tr>
+	{{end}}
+</table>
+
+<table class="list_table">
+	<caption>Covered syscalls:</caption>
+	<tr>
+		<th>Syscall</th>
+		<th>First covered</th>
+	</tr>
+	{{range $c := .Syscalls}}
+	<tr>
+		<td>{{$c.Name}}</td>
+		<td class="time">{{formatTime $c.Covered}}</td>
+	</tr>
+	{{end}}
+</table>
+
+<b>Command line:</b>
+<pre>{{.Cmdline}}</pre>
+<b>Config:</b>
+<pre>{{printf "%s" .Config}}</pre>
+</body></html>
+`)
@@ -0,0 +1,91 @@
+// Copyright 2026 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package summary
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/syzkaller/pkg/osutil"
+)
+
+func TestGenerate(t *testing.T) {
+	workdir := t.TempDir()
+	crashdir := filepath.Join("crashes", "0123456789012345678901234567890123456789")
+	files := map[string]string{
+		CmdlineFile: "2026/01/02 15:04:05 [cmdline] syz-manager -config=x.cfg\n",
+		ConfigFile:  `{"name": "test", "procs": 8}`,
+		TimelineFile: `{"time": 1000, "uptime": 60, "fuzzing": 120, "exec total": 600, "coverage": 10, "signal": 20}
+{"time": 1060, "uptime": 120, "fuzzing": 240, "exec total": 1200, "coverage": 30, "signal": 40,` +
+			` "corpus": 5, "EnrichCnt": 4, "origin enrich": 2, "origin fuzz": 3}
+{"time": 11`,
+		CoveredSyscallsFile:                    "1000 open\n1030 read\n",
+		filepath.Join(crashdir, "description"): "KASAN: use-after-free\n",
+		filepath.Join(crashdir, "log0"):        "log",
+		filepath.Join(crashdir, "log1"):        "log",
+		filepath.Join(crashdir, "repro.prog"):  "prog",
+		filepath.Join(crashdir, FirstSeenFile): "2026-01-02T15:04:05Z\n",
+	}
+	for name, data := range files {
+		file := filepath.Join(workdir, name)
+		osutil.MkdirAll(filepath.Dir(file))
+		if err := osutil.WriteFile(file, []byte(data)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s, err := Generate(workdir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Name != "test" || s.Cmdline == "" || len(s.Config) == 0 {
+		t.Errorf("bad name/cmdline/config: %q %q %q", s.Name, s.Cmdline, s.Config)
+	}
+	if len(s.Timeline) != 2 || s.Uptime != 120 || s.Fuzzing != 240 || s.Execs != 1200 ||
+		s.ExecRate != 10 || s.Coverage != 30 || s.Signal != 40 || s.Corpus != 5 {
+		t.Errorf("bad stats: %+v", s)
+	}
+	if !s.Start.Equal(time.Unix(1000, 0)) || !s.End.Equal(time.Unix(1060, 0)) {
+		t.Errorf("bad start/end: %v %v", s.Start, s.End)
+	}
+	if s.Enrich.Seeds != 4 || s.Enrich.Inputs != 2 || s.Enrich.AcceptanceRate != 0.5 {
+		t.Errorf("bad enrich stats: %+v", s.Enrich)
+	}
+	if s.Origins["fuzz"] != 3 {
+		t.Errorf("bad origins: %v", s.Origins)
+	}
+	if len(s.Syscalls) != 2 || s.Syscalls[1].Name != "read" || !s.Syscalls[1].Covered.Equal(time.Unix(1030, 0)) {
+		t.Errorf("bad syscalls: %+v", s.Syscalls)
+	}
+	if len(s.Crashes) != 1 {
+		t.Fatalf("bad crashes: %+v", s.Crashes)
+	}
+	crash := s.Crashes[0]
+	if crash.Title != "KASAN: use-after-free" || crash.Count != 2 || crash.Repro != "has repro" ||
+		!crash.FirstSeen.Equal(time.Date(2026, 1, 2, 15, 4, 5, 0, time.UTC)) {
+		t.Errorf("bad crash: %+v", crash)
+	}
+	if s.CoverageSparkline() != "300.0,40.0 600.0,0.0" {
+		t.Errorf("bad sparkline: %q", s.CoverageSparkline())
+	}
+	if err := s.Write(workdir); err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range []string{"summary.json", "summary.html"} {
+		if _, err := os.Stat(filepath.Join(workdir, file)); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestGenerateEmpty(t *testing.T) {
+	s, err := Generate(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Write(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+}
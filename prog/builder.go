@@ -0,0 +1,337 @@
+// Copyright 2026 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package prog
+
+import (
+	"fmt"
+)
+
+// The high-level Builder interface allows to write programs in Go in a type-checked way:
+//
+//	b := MakeProgGen(target)
+//	b.Call("openat").Arg("file", b.String("./file0")).Arg("flags", b.Flags("O_RDWR")).Ret("fd")
+//	b.Call("read").Arg("fd", b.Ref("fd")).Arg("buf", b.Data(make([]byte, 16)))
+//	p, err := b.Finalize()
+//
+// Values are checked against the types of the syscall arguments when they are set,
+// pointers to values are created automatically, unspecified arguments get default values,
+// and length arguments are computed automatically. Errors are reported by Finalize.
+
+// CallBuilder sets arguments of a call added with Builder.Call.
+type CallBuilder struct {
+	pg *Builder
+	c  *Call
+}
+
+// BuilderValue is a value of an argument, it's converted to an argument of the right type when set.
+type BuilderValue struct {
+	// Pointer values are used for pointer types as is,
+	// for other values a pointer to the value is created automatically.
+	pointer bool
+	build   func(typ Type, dir Dir) (Arg, error)
+}
+
+// Call adds a call of the named syscall to the program.
+func (pg *Builder) Call(name string) *CallBuilder {
+	pg.flush()
+	cb := &CallBuilder{pg: pg}
+	meta := pg.target.SyscallMap[name]
+	if meta == nil {
+		pg.fail(fmt.Errorf("unknown syscall %v", name))
+		return cb
+	}
+	var args []Arg
+	for _, field := range meta.Args {
+		args = append(args, field.DefaultArg(field.Dir(DirIn)))
+	}
+	cb.c = MakeCall(meta, args)
+	pg.call = cb
+	return cb
+}
+
+// Arg sets the named argument of the call.
+func (cb *CallBuilder) Arg(name string, val BuilderValue) *CallBuilder {
+	if cb.c == nil {
+		return cb
+	}
+	for i, field := range cb.c.Meta.Args {
+		if field.Name != name {
+			continue
+		}
+		arg, err := cb.pg.makeArg(field.Type, field.Dir(DirIn), val)
+		if err != nil {
+			cb.pg.fail(fmt.Errorf("%v: argument %v: %w", cb.c.Meta.Name, name, err))
+			return cb
+		}
+		cb.c.Args[i] = arg
+		return cb
+	}
+	cb.pg.fail(fmt.Errorf("%v: no argument %v", cb.c.Meta.Name, name))
+	return cb
+}
+
+// Ret names the resource returned by the call, so that it can be used in subsequent calls with Builder.Ref.
+func (cb *CallBuilder) Ret(name string) *CallBuilder {
+	if cb.c == nil {
+		return cb
+	}
+	if cb.c.Ret == nil {
+		cb.pg.fail(fmt.Errorf("%v does not return a resource", cb.c.Meta.Name))
+		return cb
+	}
+	cb.pg.setVar(name, cb.c.Ret)
+	return cb
+}
+
+// Int is an integer value, it can be used for all integer types and for special values of resources.
+func (pg *Builder) Int(v uint64) BuilderValue {
+	return BuilderValue{build: func(typ Type, dir Dir) (Arg, error) {
+		switch t := typ.(type) {
+		case *ResourceType:
+			return MakeResultArg(t, dir, nil, v), nil
+		case *IntType, *FlagsType, *ConstType, *LenType, *ProcType, *CsumType:
+			return MakeConstArg(t, dir, v), nil
+		}
+		return nil, fmt.Errorf("integer value for %v", typ)
+	}}
+}
+
+// Flags is an integer value that is a combination of the named constants (e.g. O_RDWR).
+func (pg *Builder) Flags(names ...string) BuilderValue {
+	if pg.consts == nil {
+		pg.consts = make(map[string]uint64)
+		for _, c := range pg.target.Consts {
+			pg.consts[c.Name] = c.Value
+		}
+	}
+	v := uint64(0)
+	for _, name := range names {
+		val, ok := pg.consts[name]
+		if !ok {
+			return pg.failValue(fmt.Errorf("unknown const %v", name))
+		}
+		v |= val
+	}
+	return BuilderValue{build: func(typ Type, dir Dir) (Arg, error) {
+		switch t := typ.(type) {
+		case *IntType, *FlagsType, *ConstType:
+			return MakeConstArg(t, dir, v), nil
+		}
+		return nil, fmt.Errorf("flags value for %v", typ)
+	}}
+}
+
+// String is a string value, the terminating zero is added unless the string type does not need it.
+func (pg *Builder) String(s string) BuilderValue {
+	return BuilderValue{build: func(typ Type, dir Dir) (Arg, error) {
+		t, ok := typ.(*BufferType)
+		if !ok {
+			return nil, fmt.Errorf("string value for %v", typ)
+		}
+		data := []byte(s)
+		if (t.Kind == BufferString || t.Kind == BufferFilename) && !t.NoZ {
+			data = append(data, 0)
+		}
+		return makeDataArg(t, dir, data)
+	}}
+}
+
+// Data is a value of a buffer, for output buffers only length of the data matters.
+func (pg *Builder) Data(data []byte) BuilderValue {
+	return BuilderValue{build: func(typ Type, dir Dir) (Arg, error) {
+		t, ok := typ.(*BufferType)
+		if !ok {
+			return nil, fmt.Errorf("data value for %v", typ)
+		}
+		return makeDataArg(t, dir, data)
+	}}
+}
+
+func makeDataArg(t *BufferType, dir Dir, data []byte) (Arg, error) {
+	if !t.Varlen() && uint64(len(data)) != t.Size() {
+		return nil, fmt.Errorf("%v bytes for %v of size %v", len(data), t, t.Size())
+	}
+	if dir == DirOut {
+		return MakeOutDataArg(t, dir, uint64(len(data))), nil
+	}
+	return MakeDataArg(t, dir, data), nil
+}
+
+// Ref is a use of the named resource (see CallBuilder.Ret and Builder.Out).
+func (pg *Builder) Ref(name string) BuilderValue {
+	return BuilderValue{build: func(typ Type, dir Dir) (Arg, error) {
+		t, ok := typ.(*ResourceType)
+		if !ok {
+			return nil, fmt.Errorf("resource value for %v", typ)
+		}
+		res := pg.vars[name]
+		if res == nil {
+			return nil, fmt.Errorf("unknown resource %v", name)
+		}
+		if !pg.target.isCompatibleResource(t.Desc.Name, res.Type().Name()) {
+			return nil, fmt.Errorf("resource %v of type %v can't be used as %v", name, res.Type().Name(), t.Desc.Name)
+		}
+		return MakeResultArg(t, dir, res, 0), nil
+	}}
+}
+
+// Out names an output resource inside of an argument (e.g. a file descriptor returned by pipe),
+// so that it can be used in subsequent calls with Builder.Ref.
+func (pg *Builder) Out(name string) BuilderValue {
+	return BuilderValue{build: func(typ Type, dir Dir) (Arg, error) {
+		t, ok := typ.(*ResourceType)
+		if !ok || dir == DirIn {
+			return nil, fmt.Errorf("output resource value for %v (%v)", typ, dir)
+		}
+		res := MakeResultArg(t, dir, nil, t.Default())
+		pg.setVar(name, res)
+		return res, nil
+	}}
+}
+
+// Struct is a value of a struct, unspecified fields get default values.
+func (pg *Builder) Struct(fields map[string]BuilderValue) BuilderValue {
+	return BuilderValue{build: func(typ Type, dir Dir) (Arg, error) {
+		t, ok := typ.(*StructType)
+		if !ok {
+			return nil, fmt.Errorf("struct value for %v", typ)
+		}
+		used := 0
+		var inner []Arg
+		for _, field := range t.Fields {
+			fieldDir := field.Dir(dir)
+			val, ok := fields[field.Name]
+			if !ok {
+				inner = append(inner, field.DefaultArg(fieldDir))
+				continue
+			}
+			used++
+			arg, err := pg.makeArg(field.Type, fieldDir, val)
+			if err != nil {
+				return nil, fmt.Errorf("field %v: %w", field.Name, err)
+			}
+			inner = append(inner, arg)
+		}
+		if used != len(fields) {
+			return nil, fmt.Errorf("unknown fields for %v", t)
+		}
+		return MakeGroupArg(t, dir, inner), nil
+	}}
+}
+
+// Array is a value of an array.
+func (pg *Builder) Array(elems ...BuilderValue) BuilderValue {
+	return BuilderValue{build: func(typ Type, dir Dir) (Arg, error) {
+		t, ok := typ.(*ArrayType)
+		if !ok {
+			return nil, fmt.Errorf("array value for %v", typ)
+		}
+		if t.Kind == ArrayRangeLen && (uint64(len(elems)) < t.RangeBegin || uint64(len(elems)) > t.RangeEnd) {
+			return nil, fmt.Errorf("%v elements for %v with [%v:%v] elements",
+				len(elems), t, t.RangeBegin, t.RangeEnd)
+		}
+		var inner []Arg
+		for i, elem := range elems {
+			arg, err := pg.makeArg(t.Elem, dir, elem)
+			if err != nil {
+				return nil, fmt.Errorf("element %v: %w", i, err)
+			}
+			inner = append(inner, arg)
+		}
+		return MakeGroupArg(t, dir, inner), nil
+	}}
+}
+
+// Union is a value of the named union option.
+func (pg *Builder) Union(option string, val BuilderValue) BuilderValue {
+	return BuilderValue{build: func(typ Type, dir Dir) (Arg, error) {
+		t, ok := typ.(*UnionType)
+		if !ok {
+			return nil, fmt.Errorf("union value for %v", typ)
+		}
+		for i, field := range t.Fields {
+			if field.Name != option {
+				continue
+			}
+			arg, err := pg.makeArg(field.Type, field.Dir(dir), val)
+			if err != nil {
+				return nil, fmt.Errorf("option %v: %w", option, err)
+			}
+			return MakeUnionArg(t, dir, arg, i), nil
+		}
+		return nil, fmt.Errorf("no option %v in %v", option, t)
+	}}
+}
+
+// Nil is a nil pointer.
+func (pg *Builder) Nil() BuilderValue {
+	return BuilderValue{pointer: true, build: func(typ Type, dir Dir) (Arg, error) {
+		switch typ.(type) {
+		case *PtrType, *VmaType:
+			return MakeSpecialPointerArg(typ, dir, 0), nil
+		}
+		return nil, fmt.Errorf("nil value for %v", typ)
+	}}
+}
+
+// VMA is a newly allocated memory range of npages pages.
+func (pg *Builder) VMA(npages uint64) BuilderValue {
+	return BuilderValue{pointer: true, build: func(typ Type, dir Dir) (Arg, error) {
+		if _, ok := typ.(*VmaType); !ok {
+			return nil, fmt.Errorf("vma value for %v", typ)
+		}
+		if npages == 0 || npages > pg.target.NumPages {
+			return nil, fmt.Errorf("bad number of vma pages %v", npages)
+		}
+		return MakeVmaPointerArg(typ, dir, pg.AllocateVMA(npages), npages*pg.target.PageSize), nil
+	}}
+}
+
+func (pg *Builder) makeArg(typ Type, dir Dir, val BuilderValue) (Arg, error) {
+	if val.build == nil {
+		// The value failed to build, the error is already recorded.
+		return nil, pg.err
+	}
+	if t, ok := typ.(*PtrType); ok && !val.pointer {
+		inner, err := pg.makeArg(t.Elem, t.ElemDir, val)
+		if err != nil {
+			return nil, err
+		}
+		if inner.Size() > pg.target.NumPages*pg.target.PageSize {
+			return nil, fmt.Errorf("too large pointee of size %v", inner.Size())
+		}
+		return MakePointerArg(t, dir, pg.Allocate(inner.Size(), t.Elem.Alignment()), inner), nil
+	}
+	return val.build(typ, dir)
+}
+
+// flush appends the call that is being built to the program.
+func (pg *Builder) flush() {
+	if pg.call != nil {
+		pg.Append(pg.call.c)
+		pg.call = nil
+	}
+}
+
+func (pg *Builder) setVar(name string, res *ResultArg) {
+	if pg.vars == nil {
+		pg.vars = make(map[string]*ResultArg)
+	}
+	if pg.vars[name] != nil {
+		pg.fail(fmt.Errorf("duplicate resource %v", name))
+		return
+	}
+	pg.vars[name] = res
+}
+
+func (pg *Builder) fail(err error) {
+	if pg.err == nil {
+		pg.err = err
+	}
+}
+
+func (pg *Builder) failValue(err error) BuilderValue {
+	pg.fail(err)
+	return BuilderValue{}
+}
@@ -0,0 +1,95 @@
+// Copyright 2026 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package prog
+
+import (
+	"testing"
+)
+
+func TestBuilder(t *testing.T) {
+	target, err := GetTarget("linux", "amd64")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := MakeProgGen(target)
+	b.Call("openat").Arg("file", b.String("./file0")).Arg("flags", b.Flags("O_RDWR", "O_CREAT")).Ret("fd")
+	b.Call("pipe").Arg("pipefd", b.Struct(map[string]BuilderValue{
+		"rfd": b.Out("rfd"),
+		"wfd": b.Out("wfd"),
+	}))
+	b.Call("read").Arg("fd", b.Ref("rfd")).Arg("buf", b.Data(make([]byte, 16)))
+	b.Call("write").Arg("fd", b.Ref("fd")).Arg("buf", b.Data([]byte("abc")))
+	b.Call("close").Arg("fd", b.Ref("wfd"))
+	p, err := b.Finalize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `r0 = openat(0xffffffffffffffff, &(0x7f0000000000)='./file0\x00', 0x42, 0x0)
+pipe(&(0x7f0000000040)={<r1=>0xffffffffffffffff, <r2=>0xffffffffffffffff})
+read(r1, &(0x7f0000000080)=""/16, 0x10)
+write(r0, &(0x7f00000000c0)='abc', 0x3)
+close(r2)
+`
+	if got := string(p.Serialize()); got != want {
+		t.Fatalf("got program:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestBuilderErrors(t *testing.T) {
+	target, err := GetTarget("linux", "amd64")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		build func(b *Builder)
+		err   string
+	}{
+		{
+			build: func(b *Builder) { b.Call("no_such_call") },
+			err:   "unknown syscall no_such_call",
+		},
+		{
+			build: func(b *Builder) { b.Call("close").Arg("file", b.Int(1)) },
+			err:   "close: no argument file",
+		},
+		{
+			build: func(b *Builder) { b.Call("close").Arg("fd", b.String("foo")) },
+			err:   "close: argument fd: string value for fd",
+		},
+		{
+			build: func(b *Builder) { b.Call("openat").Arg("flags", b.Flags("NO_SUCH_FLAG")) },
+			err:   "unknown const NO_SUCH_FLAG",
+		},
+		{
+			build: func(b *Builder) { b.Call("close").Arg("fd", b.Ref("fd")) },
+			err:   "close: argument fd: unknown resource fd",
+		},
+		{
+			build: func(b *Builder) { b.Call("close").Ret("fd") },
+			err:   "close does not return a resource",
+		},
+		{
+			build: func(b *Builder) {
+				b.Call("socket$inet_tcp").Ret("sock")
+				b.Call("socket$nl_route").Ret("sock")
+			},
+			err: "duplicate resource sock",
+		},
+		{
+			build: func(b *Builder) {
+				b.Call("openat").Ret("fd")
+				b.Call("sched_getparam").Arg("pid", b.Ref("fd"))
+			},
+			err: "sched_getparam: argument pid: resource fd of type fd can't be used as pid",
+		},
+	}
+	for i, test := range tests {
+		b := MakeProgGen(target)
+		test.build(b)
+		_, err := b.Finalize()
+		if err == nil || err.Error() != test.err {
+			t.Errorf("#%v: got error %v, want %v", i, err, test.err)
+		}
+	}
+}
@@ -13,6 +13,9 @@ func (p *Prog) Clone() *Prog {
 		Target: p.Target,
 		Calls:  cloneCalls(p.Calls, newargs),
 	}
+	if len(p.holes) != 0 {
+		cloneHoles(p, p1)
+	}
 	p1.debugValidate()
 	return p1
 }
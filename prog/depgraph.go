@@ -0,0 +1,207 @@
+// Copyright 2026 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package prog
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ResourceGraph is the graph of resources produced and consumed by syscalls.
+type ResourceGraph struct {
+	Calls     []*CallNode     `json:"calls"`
+	Resources []*ResourceNode `json:"resources"`
+	Edges     []ResourceEdge  `json:"edges"`
+}
+
+// CallNode is a syscall that produces or consumes resources.
+type CallNode struct {
+	Name string `json:"name"`
+	// If set, the call can't be used because some of its input resources can't be created
+	// by the enabled calls (see TransitivelyEnabledCalls).
+	Unsupported string `json:"unsupported,omitempty"`
+}
+
+// ResourceNode is a resource, resources form a hierarchy: a resource can be passed
+// where its parent resource is expected (e.g. sock where fd is expected).
+type ResourceNode struct {
+	Name   string   `json:"name"`
+	Kind   []string `json:"kind"`             // names of the resource and all its parents, outermost first
+	Parent string   `json:"parent,omitempty"` // the immediate parent resource
+	// Whether any of the enabled syscalls can create the resource (or one of its subtypes).
+	Creatable bool `json:"creatable"`
+}
+
+// ResourceEdge connects a call and a resource it produces (Output) or consumes.
+type ResourceEdge struct {
+	Call     string `json:"call"`
+	Resource string `json:"resource"`
+	Output   bool   `json:"output"`
+	// Weight of the resource in the call that is used for static call priorities (see calcResourceUsage).
+	Weight int32 `json:"weight"`
+}
+
+// ResourceGraph returns the resource graph of the enabled syscalls (of all syscalls if enabled is nil).
+// Calls that neither produce nor consume resources are omitted.
+func (target *Target) ResourceGraph(enabled map[*Syscall]bool) *ResourceGraph {
+	if enabled == nil {
+		enabled = make(map[*Syscall]bool)
+		for _, c := range target.Syscalls {
+			enabled[c] = true
+		}
+	}
+	_, unsupported := target.TransitivelyEnabledCalls(enabled)
+	_, canCreate := target.transitivelyEnabled(enabled)
+	uses := target.calcResourceUsage()
+	g := new(ResourceGraph)
+	resources := make(map[string]*ResourceNode)
+	var addResource func(res *ResourceDesc)
+	addResource = func(res *ResourceDesc) {
+		if resources[res.Name] != nil {
+			return
+		}
+		node := &ResourceNode{
+			Name:      res.Name,
+			Kind:      res.Kind,
+			Creatable: canCreate[res.Name],
+		}
+		if len(res.Kind) > 1 {
+			node.Parent = res.Kind[len(res.Kind)-2]
+			if parent := target.resourceMap[node.Parent]; parent != nil {
+				addResource(parent)
+			}
+		}
+		resources[res.Name] = node
+		g.Resources = append(g.Resources, node)
+	}
+	weight := func(c *Syscall, res *ResourceDesc, output bool) int32 {
+		id := "res" + res.Name
+		if !target.AuxResources[res.Name] {
+			id = "res-" + strings.Join(res.Kind, "-")
+		}
+		w := uses[id][c.ID]
+		if output {
+			return w.inout
+		}
+		return w.in
+	}
+	for _, c := range target.Syscalls {
+		if !enabled[c] || len(c.inputResources)+len(c.outputResources) == 0 {
+			continue
+		}
+		g.Calls = append(g.Calls, &CallNode{
+			Name:        c.Name,
+			Unsupported: unsupported[c],
+		})
+		for _, res := range c.inputResources {
+			addResource(res)
+			g.Edges = append(g.Edges, ResourceEdge{c.Name, res.Name, false, weight(c, res, false)})
+		}
+		for _, res := range c.outputResources {
+			addResource(res)
+			g.Edges = append(g.Edges, ResourceEdge{c.Name, res.Name, true, weight(c, res, true)})
+		}
+	}
+	sort.Slice(g.Resources, func(i, j int) bool {
+		return g.Resources[i].Name < g.Resources[j].Name
+	})
+	return g
+}
+
+// FilterKind returns the subgraph with resources of the given kind (the resource and its subtypes)
+// and calls that produce or consume them.
+func (g *ResourceGraph) FilterKind(kind string) (*ResourceGraph, error) {
+	resources := make(map[string]bool)
+	for _, res := range g.Resources {
+		if res.isA(kind) {
+			resources[res.Name] = true
+		}
+	}
+	if len(resources) == 0 {
+		return nil, fmt.Errorf("no resources of kind %v", kind)
+	}
+	calls := make(map[string]bool)
+	for _, edge := range g.Edges {
+		if resources[edge.Resource] {
+			calls[edge.Call] = true
+		}
+	}
+	return g.subgraph(calls, resources), nil
+}
+
+// FilterRoot returns the subgraph with the root call, all calls that are (transitively) needed
+// to create its input resources and calls that consume its output resources.
+func (g *ResourceGraph) FilterRoot(root string) (*ResourceGraph, error) {
+	found := false
+	for _, c := range g.Calls {
+		found = found || c.Name == root
+	}
+	if !found {
+		return nil, fmt.Errorf("no call %v in the graph", root)
+	}
+	calls := map[string]bool{root: true}
+	resources := make(map[string]bool)
+	for queue := []string{root}; len(queue) != 0; queue = queue[1:] {
+		for _, edge := range g.Edges {
+			if edge.Call != queue[0] || edge.Output {
+				continue
+			}
+			// The input resource can be created by producers of the resource and of its subtypes.
+			for _, res := range g.Resources {
+				if !res.isA(edge.Resource) || resources[res.Name] {
+					continue
+				}
+				resources[res.Name] = true
+				for _, producer := range g.Edges {
+					if producer.Output && producer.Resource == res.Name && !calls[producer.Call] {
+						calls[producer.Call] = true
+						queue = append(queue, producer.Call)
+					}
+				}
+			}
+		}
+	}
+	for _, edge := range g.Edges {
+		if edge.Call != root || !edge.Output {
+			continue
+		}
+		resources[edge.Resource] = true
+		for _, consumer := range g.Edges {
+			if !consumer.Output && consumer.Resource == edge.Resource {
+				calls[consumer.Call] = true
+			}
+		}
+	}
+	return g.subgraph(calls, resources), nil
+}
+
+func (g *ResourceGraph) subgraph(calls, resources map[string]bool) *ResourceGraph {
+	sub := new(ResourceGraph)
+	for _, c := range g.Calls {
+		if calls[c.Name] {
+			sub.Calls = append(sub.Calls, c)
+		}
+	}
+	for _, res := range g.Resources {
+		if resources[res.Name] {
+			sub.Resources = append(sub.Resources, res)
+		}
+	}
+	for _, edge := range g.Edges {
+		if calls[edge.Call] && resources[edge.Resource] {
+			sub.Edges = append(sub.Edges, edge)
+		}
+	}
+	return sub
+}
+
+func (res *ResourceNode) isA(kind string) bool {
+	for _, k := range res.Kind {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
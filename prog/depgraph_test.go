@@ -0,0 +1,102 @@
+// Copyright 2026 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package prog
+
+import (
+	"testing"
+)
+
+func TestResourceGraph(t *testing.T) {
+	target, err := GetTarget("linux", "amd64")
+	if err != nil {
+		t.Fatal(err)
+	}
+	enabled := map[*Syscall]bool{
+		target.SyscallMap["read"]:        true,
+		target.SyscallMap["getpid"]:      true,
+		target.SyscallMap["accept"]:      true,
+		target.SyscallMap["socket$inet"]: true,
+	}
+	g := target.ResourceGraph(enabled)
+	calls := make(map[string]*CallNode)
+	for _, c := range g.Calls {
+		calls[c.Name] = c
+	}
+	if calls["getpid"] == nil || calls["read"] == nil || calls["accept"] == nil || len(calls) != 4 {
+		t.Fatalf("bad calls: %+v", calls)
+	}
+	for _, name := range []string{"read", "getpid", "accept", "socket$inet"} {
+		if calls[name].Unsupported != "" {
+			t.Fatalf("%v is unsupported: %v", name, calls[name].Unsupported)
+		}
+	}
+	resources := make(map[string]*ResourceNode)
+	for _, res := range g.Resources {
+		resources[res.Name] = res
+	}
+	if res := resources["sock"]; res == nil || res.Parent != "fd" || !res.Creatable {
+		t.Fatalf("bad sock resource: %+v", res)
+	}
+	if res := resources["fd"]; res == nil || !res.Creatable {
+		t.Fatalf("bad fd resource: %+v", res)
+	}
+
+	// Without socket nothing can create fd for read and sock for accept.
+	delete(enabled, target.SyscallMap["socket$inet"])
+	g = target.ResourceGraph(enabled)
+	for _, c := range g.Calls {
+		if unsupported := c.Unsupported != ""; unsupported != (c.Name != "getpid") {
+			t.Fatalf("%v: unsupported %q", c.Name, c.Unsupported)
+		}
+	}
+	for _, res := range g.Resources {
+		if res.Creatable != (res.Name == "pid") {
+			t.Fatalf("%v: creatable %v", res.Name, res.Creatable)
+		}
+	}
+}
+
+func TestResourceGraphFilter(t *testing.T) {
+	target, err := GetTarget("linux", "amd64")
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := target.ResourceGraph(nil)
+	sub, err := g.FilterRoot("accept$inet")
+	if err != nil {
+		t.Fatal(err)
+	}
+	calls := make(map[string]bool)
+	for _, c := range sub.Calls {
+		calls[c.Name] = true
+	}
+	for _, name := range []string{"accept$inet", "socket$inet", "getsockname$inet"} {
+		if !calls[name] {
+			t.Errorf("no %v in the root subgraph", name)
+		}
+	}
+	if calls["getpid"] || calls["socket$nl_route"] || len(sub.Calls) >= len(g.Calls) {
+		t.Errorf("unrelated calls in the root subgraph")
+	}
+	sub, err = g.FilterKind("sock_nl_route")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, res := range sub.Resources {
+		if res.Name != "sock_nl_route" {
+			t.Errorf("unexpected resource %v", res.Name)
+		}
+	}
+	for _, edge := range sub.Edges {
+		if edge.Resource != "sock_nl_route" {
+			t.Errorf("unexpected edge %+v", edge)
+		}
+	}
+	if _, err := g.FilterKind("no_such_resource"); err == nil {
+		t.Errorf("no error for an unknown kind")
+	}
+	if _, err := g.FilterRoot("no_such_call"); err == nil {
+		t.Errorf("no error for an unknown call")
+	}
+}
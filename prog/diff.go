@@ -0,0 +1,431 @@
+// Copyright 2026 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package prog
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/google/syzkaller/pkg/image"
+)
+
+// ProgDiff describes structural differences between two programs.
+type ProgDiff struct {
+	// Inserted, removed and changed calls in program order (identical calls are omitted).
+	Calls []CallDiff `json:"calls,omitempty"`
+	// Similarity of the programs from 0 (nothing in common) to 1 (identical).
+	Similarity float64 `json:"similarity"`
+}
+
+// CallDiff describes an inserted, removed or changed call.
+type CallDiff struct {
+	Kind    string    `json:"kind"` // DiffInserted, DiffRemoved or DiffChanged
+	Old     int       `json:"old"`  // index of the call in the first program, -1 for inserted calls
+	New     int       `json:"new"`  // index of the call in the second program, -1 for removed calls
+	Syscall string    `json:"syscall"`
+	OldText string    `json:"old_text,omitempty"` // serialized call in the first program
+	NewText string    `json:"new_text,omitempty"` // serialized call in the second program
+	Args    []ArgDiff `json:"args,omitempty"`     // for changed calls
+}
+
+// ArgDiff describes a changed argument of a call.
+type ArgDiff struct {
+	Path string `json:"path"` // e.g. "*addr.sin_port", "ret" or "props" for call properties
+	Kind string `json:"kind"` // one of Diff* argument kinds
+	Old  string `json:"old,omitempty"`
+	New  string `json:"new,omitempty"`
+	// Changed byte ranges for DiffData (for compressed data the ranges refer to the decompressed data).
+	Ranges []DiffRange `json:"ranges,omitempty"`
+}
+
+// DiffRange is a changed range of a data argument, Old and New are hex-encoded contents
+// (they may be shorter than Size if the data is shorter in one of the programs).
+type DiffRange struct {
+	Offset int    `json:"offset"`
+	Size   int    `json:"size"`
+	Old    string `json:"old"`
+	New    string `json:"new"`
+}
+
+const (
+	// Call kinds.
+	DiffInserted = "inserted"
+	DiffRemoved  = "removed"
+	DiffChanged  = "changed"
+	// Argument kinds.
+	DiffValue    = "value"    // integer value
+	DiffResource = "resource" // resource references a different call result or a special value
+	DiffData     = "data"     // contents of an input data argument
+	DiffSize     = "size"     // size of an output data argument
+	DiffAddress  = "address"  // pointer address
+	DiffPointer  = "pointer"  // pointer became nil or non-nil
+	DiffUnion    = "union"    // union option
+	DiffElements = "elements" // number of array elements
+	DiffProps    = "props"    // call properties
+	DiffType     = "type"     // argument type (should not happen for the same syscall)
+)
+
+// Diff returns structural differences between programs p0 and p1.
+// Calls are matched by syscall with the longest common subsequence,
+// then matched calls are compared argument by argument.
+func Diff(p0, p1 *Prog) *ProgDiff {
+	d := &differ{
+		res0: resultLocations(p0),
+		res1: resultLocations(p1),
+	}
+	text0, text1 := callTexts(p0), callTexts(p1)
+	d.match0, d.match1 = matchCalls(p0, p1)
+	diff := new(ProgDiff)
+	total, common := 0, 0
+	for _, c := range p0.Calls {
+		total += callSize(c)
+	}
+	for _, c := range p1.Calls {
+		total += callSize(c)
+	}
+	// The matching preserves order of calls, so removed calls go before inserted calls
+	// between each pair of matched calls.
+	for i, j := 0, 0; i < len(p0.Calls) || j < len(p1.Calls); {
+		switch {
+		case i < len(p0.Calls) && d.match0[i] == -1:
+			diff.Calls = append(diff.Calls, CallDiff{
+				Kind:    DiffRemoved,
+				Old:     i,
+				New:     -1,
+				Syscall: p0.Calls[i].Meta.Name,
+				OldText: text0[i],
+			})
+			i++
+		case j < len(p1.Calls) && d.match1[j] == -1:
+			diff.Calls = append(diff.Calls, CallDiff{
+				Kind:    DiffInserted,
+				Old:     -1,
+				New:     j,
+				Syscall: p1.Calls[j].Meta.Name,
+				NewText: text1[j],
+			})
+			j++
+		default:
+			d.args, d.common = nil, 1
+			d.diffCall(p0.Calls[i], p1.Calls[j])
+			common += d.common
+			if len(d.args) != 0 {
+				diff.Calls = append(diff.Calls, CallDiff{
+					Kind:    DiffChanged,
+					Old:     i,
+					New:     j,
+					Syscall: p1.Calls[j].Meta.Name,
+					OldText: text0[i],
+					NewText: text1[j],
+					Args:    d.args,
+				})
+			}
+			i++
+			j++
+		}
+	}
+	diff.Similarity = 1
+	if total != 0 {
+		diff.Similarity = float64(2*common) / float64(total)
+	}
+	return diff
+}
+
+// Similarity returns similarity of programs p0 and p1 from 0 to 1, see Diff.
+func Similarity(p0, p1 *Prog) float64 {
+	return Diff(p0, p1).Similarity
+}
+
+// String formats the diff in a human-readable form: removed calls are prefixed with "-",
+// inserted calls with "+" and changed calls with "~" followed by the changed arguments.
+func (diff *ProgDiff) String() string {
+	buf := new(bytes.Buffer)
+	fmt.Fprintf(buf, "similarity %.3f\n", diff.Similarity)
+	for _, c := range diff.Calls {
+		switch c.Kind {
+		case DiffRemoved:
+			fmt.Fprintf(buf, "- #%v: %v\n", c.Old, c.OldText)
+		case DiffInserted:
+			fmt.Fprintf(buf, "+ #%v: %v\n", c.New, c.NewText)
+		case DiffChanged:
+			fmt.Fprintf(buf, "~ #%v -> #%v: %v\n", c.Old, c.New, c.Syscall)
+			for _, arg := range c.Args {
+				fmt.Fprintf(buf, "\t%v: %v", arg.Path, arg.Kind)
+				if arg.Old != "" || arg.New != "" {
+					fmt.Fprintf(buf, " %v -> %v", arg.Old, arg.New)
+				}
+				for _, r := range arg.Ranges {
+					fmt.Fprintf(buf, " [%v:%v] %v -> %v", r.Offset, r.Offset+r.Size, r.Old, r.New)
+				}
+				buf.WriteByte('\n')
+			}
+		}
+	}
+	return buf.String()
+}
+
+type differ struct {
+	res0, res1     map[*ResultArg]resultLoc
+	match0, match1 []int
+	args           []ArgDiff
+	common         int
+}
+
+func (d *differ) add(path, kind, old, new string) {
+	d.args = append(d.args, ArgDiff{Path: path, Kind: kind, Old: old, New: new})
+}
+
+func (d *differ) diffCall(c0, c1 *Call) {
+	if !reflect.DeepEqual(c0.Props, c1.Props) {
+		d.add("props", DiffProps, fmt.Sprintf("%+v", c0.Props), fmt.Sprintf("%+v", c1.Props))
+	}
+	if c0.Ret != nil && c1.Ret != nil {
+		d.diffArg("ret", c0.Ret, c1.Ret)
+	}
+	for i := range c0.Args {
+		d.diffArg(c0.Meta.Args[i].Name, c0.Args[i], c1.Args[i])
+	}
+}
+
+// diffArg compares arguments with the same path and counts arguments that are the same.
+func (d *differ) diffArg(path string, a0, a1 Arg) {
+	if reflect.TypeOf(a0) != reflect.TypeOf(a1) || a0.Type().Name() != a1.Type().Name() {
+		d.add(path, DiffType, a0.Type().Name(), a1.Type().Name())
+		return
+	}
+	n := len(d.args)
+	defer func() {
+		if len(d.args) == n {
+			d.common++
+		}
+	}()
+	switch a0 := a0.(type) {
+	case *ConstArg:
+		if a1 := a1.(*ConstArg); a0.Val != a1.Val {
+			d.add(path, DiffValue, fmt.Sprintf("0x%x", a0.Val), fmt.Sprintf("0x%x", a1.Val))
+		}
+	case *ResultArg:
+		d.diffResult(path, a0, a1.(*ResultArg))
+	case *PointerArg:
+		a1 := a1.(*PointerArg)
+		if (a0.Res == nil) != (a1.Res == nil) {
+			d.add(path, DiffPointer, pointerString(a0), pointerString(a1))
+			return
+		}
+		if a0.Address != a1.Address || a0.VmaSize != a1.VmaSize {
+			d.add(path, DiffAddress, pointerString(a0), pointerString(a1))
+		}
+		if a0.Res != nil {
+			d.diffArg("*"+path, a0.Res, a1.Res)
+		}
+	case *DataArg:
+		d.diffData(path, a0, a1.(*DataArg))
+	case *GroupArg:
+		a1 := a1.(*GroupArg)
+		if len(a0.Inner) != len(a1.Inner) {
+			d.add(path, DiffElements, fmt.Sprint(len(a0.Inner)), fmt.Sprint(len(a1.Inner)))
+		}
+		typ, isStruct := a0.Type().(*StructType)
+		for i := 0; i < len(a0.Inner) && i < len(a1.Inner); i++ {
+			if isStruct && typ.Fields[i].Name != "" {
+				d.diffArg(path+"."+typ.Fields[i].Name, a0.Inner[i], a1.Inner[i])
+			} else {
+				d.diffArg(fmt.Sprintf("%v[%v]", path, i), a0.Inner[i], a1.Inner[i])
+			}
+		}
+	case *UnionArg:
+		a1 := a1.(*UnionArg)
+		fields := a0.Type().(*UnionType).Fields
+		if a0.Index != a1.Index {
+			d.add(path, DiffUnion, fields[a0.Index].Name, fields[a1.Index].Name)
+			return
+		}
+		d.diffArg(path+"."+fields[a0.Index].Name, a0.Option, a1.Option)
+	}
+}
+
+func (d *differ) diffResult(path string, a0, a1 *ResultArg) {
+	same := a0.OpDiv == a1.OpDiv && a0.OpAdd == a1.OpAdd
+	switch {
+	case a0.Res == nil && a1.Res == nil:
+		same = same && a0.Val == a1.Val
+	case a0.Res != nil && a1.Res != nil:
+		// The referenced results are the same if they are at the same path of matched calls.
+		loc0, loc1 := d.res0[a0.Res], d.res1[a1.Res]
+		same = same && d.match0[loc0.call] == loc1.call && loc0.path == loc1.path
+	default:
+		same = false
+	}
+	if !same {
+		d.add(path, DiffResource, d.resultString(a0, d.res0), d.resultString(a1, d.res1))
+	}
+}
+
+func (d *differ) resultString(arg *ResultArg, locs map[*ResultArg]resultLoc) string {
+	res := fmt.Sprintf("0x%x", arg.Val)
+	if arg.Res != nil {
+		loc := locs[arg.Res]
+		res = fmt.Sprintf("#%v.%v", loc.call, loc.path)
+	}
+	if arg.OpDiv != 0 {
+		res += fmt.Sprintf("/%v", arg.OpDiv)
+	}
+	if arg.OpAdd != 0 {
+		res += fmt.Sprintf("+%v", arg.OpAdd)
+	}
+	return res
+}
+
+func (d *differ) diffData(path string, a0, a1 *DataArg) {
+	if a0.Dir() == DirOut {
+		if a0.Size() != a1.Size() {
+			d.add(path, DiffSize, fmt.Sprint(a0.Size()), fmt.Sprint(a1.Size()))
+		}
+		return
+	}
+	data0, data1 := a0.Data(), a1.Data()
+	if a0.Type().(*BufferType).Kind == BufferCompressed {
+		var dtor0, dtor1 func()
+		data0, dtor0 = image.MustDecompress(data0)
+		defer dtor0()
+		data1, dtor1 = image.MustDecompress(data1)
+		defer dtor1()
+	}
+	ranges := diffRanges(data0, data1)
+	if len(ranges) == 0 {
+		return
+	}
+	arg := ArgDiff{
+		Path:   path,
+		Kind:   DiffData,
+		Ranges: ranges,
+	}
+	if len(data0) != len(data1) {
+		arg.Old, arg.New = fmt.Sprintf("%v bytes", len(data0)), fmt.Sprintf("%v bytes", len(data1))
+	}
+	d.args = append(d.args, arg)
+}
+
+// diffRanges returns ranges of differing bytes, trailing bytes of the longer data form the last range.
+func diffRanges(data0, data1 []byte) []DiffRange {
+	var ranges []DiffRange
+	size := len(data0)
+	if size < len(data1) {
+		size = len(data1)
+	}
+	for start := 0; start < size; {
+		if start < len(data0) && start < len(data1) && data0[start] == data1[start] {
+			start++
+			continue
+		}
+		end := start + 1
+		for end < size && (end >= len(data0) || end >= len(data1) || data0[end] != data1[end]) {
+			end++
+		}
+		ranges = append(ranges, DiffRange{
+			Offset: start,
+			Size:   end - start,
+			Old:    hex.EncodeToString(dataRange(data0, start, end)),
+			New:    hex.EncodeToString(dataRange(data1, start, end)),
+		})
+		start = end
+	}
+	return ranges
+}
+
+func dataRange(data []byte, start, end int) []byte {
+	if end > len(data) {
+		end = len(data)
+	}
+	if start > end {
+		start = end
+	}
+	return data[start:end]
+}
+
+func pointerString(arg *PointerArg) string {
+	if arg.Res == nil && arg.VmaSize == 0 {
+		return "nil"
+	}
+	if arg.VmaSize != 0 {
+		return fmt.Sprintf("0x%x/0x%x", arg.Address, arg.VmaSize)
+	}
+	return fmt.Sprintf("0x%x", arg.Address)
+}
+
+// matchCalls matches calls of the programs with the longest common subsequence of syscalls.
+// It returns indices of the matched calls of the other program, or -1 for unmatched calls.
+func matchCalls(p0, p1 *Prog) ([]int, []int) {
+	n0, n1 := len(p0.Calls), len(p1.Calls)
+	lcs := make([][]int, n0+1)
+	for i := range lcs {
+		lcs[i] = make([]int, n1+1)
+	}
+	for i := n0 - 1; i >= 0; i-- {
+		for j := n1 - 1; j >= 0; j-- {
+			if p0.Calls[i].Meta == p1.Calls[j].Meta {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = lcs[i+1][j]
+				if lcs[i][j] < lcs[i][j+1] {
+					lcs[i][j] = lcs[i][j+1]
+				}
+			}
+		}
+	}
+	match0, match1 := make([]int, n0), make([]int, n1)
+	for i := range match0 {
+		match0[i] = -1
+	}
+	for j := range match1 {
+		match1[j] = -1
+	}
+	for i, j := 0, 0; i < n0 && j < n1; {
+		switch {
+		case p0.Calls[i].Meta == p1.Calls[j].Meta:
+			match0[i], match1[j] = j, i
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			i++
+		default:
+			j++
+		}
+	}
+	return match0, match1
+}
+
+type resultLoc struct {
+	call int
+	path string
+}
+
+// resultLocations returns locations of all resources in the program.
+func resultLocations(p *Prog) map[*ResultArg]resultLoc {
+	locs := make(map[*ResultArg]resultLoc)
+	for i, c := range p.Calls {
+		for arg, path := range argPaths(c) {
+			if res, ok := arg.(*ResultArg); ok {
+				locs[res] = resultLoc{i, path}
+			}
+		}
+	}
+	return locs
+}
+
+func callTexts(p *Prog) []string {
+	return strings.Split(strings.TrimSuffix(string(p.Serialize()), "\n"), "\n")
+}
+
+// callSize returns the number of comparable entities in the call: the call itself and all arguments.
+func callSize(c *Call) int {
+	size := 1
+	ForeachArg(c, func(arg Arg, _ *ArgCtx) {
+		size++
+	})
+	return size
+}
@@ -0,0 +1,70 @@
+// Copyright 2026 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package prog
+
+import (
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	target, err := GetTarget("linux", "amd64")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p0, err := target.Deserialize([]byte(`
+r0 = open(&(0x7f0000000000)='./file0\x00', 0x0, 0x0)
+read(r0, &(0x7f0000000100)=""/10, 0xa)
+close(r0)
+`), Strict)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p1, err := target.Deserialize([]byte(`
+r0 = open(&(0x7f0000000000)='./file1\x00', 0x0, 0x0)
+r1 = dup(r0)
+read(r1, &(0x7f0000000100)=""/20, 0x14)
+`), Strict)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := Diff(p0, p1).String()
+	want := `similarity 0.370
+~ #0 -> #0: open
+	*file: data [6:7] 30 -> 31
++ #1: r1 = dup(r0)
+~ #1 -> #2: read
+	fd: resource #0.ret -> #1.ret
+	*buf: size 10 -> 20
+	count: value 0xa -> 0x14
+- #2: close(r0)
+`
+	if got != want {
+		t.Fatalf("bad diff:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestDiffRandom(t *testing.T) {
+	target, rs, iters := initTest(t)
+	ct := target.DefaultChoiceTable()
+	for i := 0; i < iters; i++ {
+		p := target.Generate(rs, 10, ct)
+		if diff := Diff(p, p.Clone()); len(diff.Calls) != 0 || diff.Similarity != 1 {
+			t.Fatalf("non-empty diff of identical programs:\n%v", diff)
+		}
+		p1 := p.Clone()
+		p1.Mutate(rs, 10, ct, nil, nil)
+		diff := Diff(p, p1)
+		if diff.Similarity < 0 || diff.Similarity > 1 {
+			t.Fatalf("bad similarity %v", diff.Similarity)
+		}
+		if len(diff.Calls) == 0 && diff.Similarity != 1 {
+			t.Fatalf("similarity %v of programs without differences", diff.Similarity)
+		}
+		for _, c := range diff.Calls {
+			if c.Kind == DiffChanged && len(c.Args) == 0 {
+				t.Fatalf("changed call without changed arguments:\n%v", diff)
+			}
+		}
+	}
+}
@@ -42,6 +42,14 @@ func (p *Prog) serialize(verbose bool) []byte {
 		vars:    make(map[*ResultArg]int),
 		verbose: verbose,
 	}
+	if holes := p.holeArgs(); len(holes) != 0 {
+		ctx.holes = make(map[Arg]*hole)
+		for _, h := range holes {
+			ctx.holes[h.arg] = h.hole
+		}
+		// Holes with default values must not be omitted.
+		ctx.verbose = true
+	}
 	for _, c := range p.Calls {
 		ctx.call(c)
 	}
@@ -54,6 +62,7 @@ type serializer struct {
 	vars    map[*ResultArg]int
 	varSeq  int
 	verbose bool
+	holes   map[Arg]*hole // holes of templates (see template.go)
 }
 
 func (ctx *serializer) printf(text string, args ...interface{}) {
@@ -118,6 +127,9 @@ func (ctx *serializer) arg(arg Arg) {
 		ctx.printf("nil")
 		return
 	}
+	if h := ctx.holes[arg]; h != nil {
+		h.serialize(ctx)
+	}
 	arg.serialize(ctx)
 }
 
@@ -266,6 +278,9 @@ func (target *Target) Deserialize(data []byte, mode DeserializeMode) (*Prog, err
 	if err := prog.sanitize(mode == NonStrict); err != nil {
 		return nil, err
 	}
+	if p.holes != nil {
+		prog.holes = p.holes
+	}
 	return prog, nil
 }
 
@@ -455,6 +470,8 @@ func (p *parser) parseArgImpl(typ Type, dir Dir) (Arg, error) {
 		p.Parse('T')
 		p.Parse('O')
 		return p.parseAuto(typ, dir)
+	case '?':
+		return p.parseHole(typ, dir)
 	default:
 		return nil, fmt.Errorf("failed to parse argument at '%c' (line #%v/%v: %v)",
 			p.Char(), p.l, p.i, p.s)
@@ -1087,6 +1104,7 @@ type parser struct {
 	strict  bool
 	vars    map[string]*ResultArg
 	autos   map[Arg]bool
+	holes   map[Arg]*hole
 	comment string
 
 	data []byte
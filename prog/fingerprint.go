@@ -0,0 +1,130 @@
+// Copyright 2026 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package prog
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/google/syzkaller/pkg/hash"
+)
+
+// Fingerprint returns a structural fingerprint of the program: programs that differ only in
+// data contents, pointer addresses, plain integer values, lengths and in order of calls that
+// don't depend on each other's resources have the same fingerprint.
+// The fingerprint captures the sequence of syscalls, the resource dataflow between calls,
+// flags and const values, union options and array sizes.
+func (p *Prog) Fingerprint() string {
+	return hash.String(p.canonicalForm())
+}
+
+// canonicalForm serializes the structure of the program that is captured by Fingerprint.
+// Independent calls are ordered by their own canonical form, so the form does not depend on
+// the order of such calls. Resources are referenced by the canonical index of the producing call.
+func (p *Prog) canonicalForm() []byte {
+	locs := resultLocations(p)
+	deps := make([]map[int]bool, len(p.Calls))
+	users := make([][]int, len(p.Calls))
+	for i, c := range p.Calls {
+		deps[i] = make(map[int]bool)
+		ForeachArg(c, func(arg Arg, _ *ArgCtx) {
+			if a, ok := arg.(*ResultArg); ok && a.Res != nil {
+				if producer := locs[a.Res].call; producer != i && !deps[i][producer] {
+					deps[i][producer] = true
+					users[producer] = append(users[producer], i)
+				}
+			}
+		})
+	}
+	canon := make([]int, len(p.Calls))
+	keys := make(map[int]string) // canonical forms of the calls that are ready to be emitted
+	for i := range p.Calls {
+		if len(deps[i]) == 0 {
+			keys[i] = canonicalCall(p.Calls[i], locs, canon)
+		}
+	}
+	buf := new(bytes.Buffer)
+	for n := 0; len(keys) != 0; n++ {
+		next := -1
+		for i, key := range keys {
+			if next == -1 || key < keys[next] || key == keys[next] && i < next {
+				next = i
+			}
+		}
+		canon[next] = n
+		buf.WriteString(keys[next])
+		buf.WriteByte('\n')
+		delete(keys, next)
+		for _, user := range users[next] {
+			if delete(deps[user], next); len(deps[user]) == 0 {
+				keys[user] = canonicalCall(p.Calls[user], locs, canon)
+			}
+		}
+	}
+	return buf.Bytes()
+}
+
+func canonicalCall(c *Call, locs map[*ResultArg]resultLoc, canon []int) string {
+	buf := new(bytes.Buffer)
+	buf.WriteString(c.Meta.Name)
+	buf.WriteByte('(')
+	for i, arg := range c.Args {
+		if i != 0 {
+			buf.WriteString(", ")
+		}
+		canonicalArg(buf, arg, locs, canon)
+	}
+	buf.WriteByte(')')
+	if c.Props != (CallProps{}) {
+		fmt.Fprintf(buf, " %+v", c.Props)
+	}
+	return buf.String()
+}
+
+func canonicalArg(buf *bytes.Buffer, arg Arg, locs map[*ResultArg]resultLoc, canon []int) {
+	switch a := arg.(type) {
+	case *ConstArg:
+		switch a.Type().(type) {
+		case *FlagsType, *ConstType:
+			fmt.Fprintf(buf, "0x%x", a.Val)
+		default:
+			buf.WriteByte('_')
+		}
+	case *ResultArg:
+		if a.Res != nil {
+			loc := locs[a.Res]
+			fmt.Fprintf(buf, "r%v.%v", canon[loc.call], loc.path)
+		} else {
+			fmt.Fprintf(buf, "0x%x", a.Val)
+		}
+	case *PointerArg:
+		switch {
+		case a.Res != nil:
+			buf.WriteByte('&')
+			canonicalArg(buf, a.Res, locs, canon)
+		case a.VmaSize != 0:
+			buf.WriteString("vma")
+		default:
+			buf.WriteString("nil")
+		}
+	case *DataArg:
+		buf.WriteString("data")
+	case *GroupArg:
+		begin, end := byte('{'), byte('}')
+		if _, ok := a.Type().(*ArrayType); ok {
+			begin, end = '[', ']'
+		}
+		buf.WriteByte(begin)
+		for i, inner := range a.Inner {
+			if i != 0 {
+				buf.WriteString(", ")
+			}
+			canonicalArg(buf, inner, locs, canon)
+		}
+		buf.WriteByte(end)
+	case *UnionArg:
+		fmt.Fprintf(buf, "@%v=", a.Type().(*UnionType).Fields[a.Index].Name)
+		canonicalArg(buf, a.Option, locs, canon)
+	}
+}
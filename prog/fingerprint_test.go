@@ -0,0 +1,106 @@
+// Copyright 2026 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package prog
+
+import (
+	"testing"
+)
+
+func TestFingerprint(t *testing.T) {
+	target, err := GetTarget("linux", "amd64")
+	if err != nil {
+		t.Fatal(err)
+	}
+	base := `
+r0 = open(&(0x7f0000000000)='./file0\x00', 0x0, 0x0)
+r1 = open(&(0x7f0000000100)='./file1\x00', 0x2, 0x0)
+read(r0, &(0x7f0000000200)=""/10, 0xa)
+close(r1)
+`
+	tests := []struct {
+		prog string
+		same bool
+	}{
+		{
+			// Different data, addresses, lengths and order of independent calls.
+			prog: `
+r0 = open(&(0x7f0000001000)='./bbb\x00', 0x2, 0x0)
+r1 = open(&(0x7f0000002000)='./aaa\x00', 0x0, 0x0)
+close(r0)
+read(r1, &(0x7f0000003000)=""/20, 0x14)
+`,
+			same: true,
+		},
+		{
+			// Different flags.
+			prog: `
+r0 = open(&(0x7f0000000000)='./file0\x00', 0x1, 0x0)
+r1 = open(&(0x7f0000000100)='./file1\x00', 0x2, 0x0)
+read(r0, &(0x7f0000000200)=""/10, 0xa)
+close(r1)
+`,
+			same: false,
+		},
+		{
+			// Different dataflow.
+			prog: `
+r0 = open(&(0x7f0000000000)='./file0\x00', 0x0, 0x0)
+r1 = open(&(0x7f0000000100)='./file1\x00', 0x2, 0x0)
+read(r1, &(0x7f0000000200)=""/10, 0xa)
+close(r0)
+`,
+			same: false,
+		},
+		{
+			// Dependent calls in a different order.
+			prog: `
+r0 = open(&(0x7f0000000000)='./file0\x00', 0x0, 0x0)
+r1 = open(&(0x7f0000000100)='./file1\x00', 0x2, 0x0)
+close(r1)
+read(r0, &(0x7f0000000200)=""/10, 0xa)
+`,
+			same: true,
+		},
+		{
+			// An extra call.
+			prog: `
+r0 = open(&(0x7f0000000000)='./file0\x00', 0x0, 0x0)
+r1 = open(&(0x7f0000000100)='./file1\x00', 0x2, 0x0)
+read(r0, &(0x7f0000000200)=""/10, 0xa)
+close(r1)
+close(r0)
+`,
+			same: false,
+		},
+	}
+	p0, err := target.Deserialize([]byte(base), Strict)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, test := range tests {
+		p1, err := target.Deserialize([]byte(test.prog), Strict)
+		if err != nil {
+			t.Fatalf("#%v: %v", i, err)
+		}
+		if same := p0.Fingerprint() == p1.Fingerprint(); same != test.same {
+			t.Errorf("#%v: same fingerprint %v, want %v\n%s\n%s", i, same, test.same,
+				p0.canonicalForm(), p1.canonicalForm())
+		}
+	}
+}
+
+func TestFingerprintStable(t *testing.T) {
+	target, rs, iters := initTest(t)
+	ct := target.DefaultChoiceTable()
+	for i := 0; i < iters; i++ {
+		p := target.Generate(rs, 10, ct)
+		p1, err := target.Deserialize(p.Serialize(), NonStrict)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if p.Fingerprint() != p1.Fingerprint() {
+			t.Fatalf("fingerprint changed after serialization:\n%s\n%s", p.canonicalForm(), p1.canonicalForm())
+		}
+	}
+}
@@ -66,8 +66,14 @@ func (m CompMap) String() string {
 // Mutates the program using the comparison operands stored in compMaps.
 // For each of the mutants executes the exec callback.
 func (p *Prog) MutateWithHints(callIndex int, comps CompMap, exec func(p *Prog)) {
+	p.mutateWithHints(callIndex, comps, func(p *Prog, _ Arg) { exec(p) })
+}
+
+// mutateWithHints is the same as MutateWithHints, but also passes the mutated argument to exec.
+func (p *Prog) mutateWithHints(callIndex int, comps CompMap, exec func(p *Prog, arg Arg)) {
 	p = p.Clone()
 	c := p.Calls[callIndex]
+	var cur Arg
 	execValidate := func() {
 		// Don't try to fix the candidate program.
 		// Assuming the original call was sanitized, we've got a bad call
@@ -76,9 +82,14 @@ func (p *Prog) MutateWithHints(callIndex int, comps CompMap, exec func(p *Prog))
 			return
 		}
 		p.debugValidate()
-		exec(p)
+		exec(p, cur)
 	}
+	inHoles := p.hintHoles(c)
 	ForeachArg(c, func(arg Arg, _ *ArgCtx) {
+		if inHoles != nil && !inHoles[arg] {
+			return
+		}
+		cur = arg
 		generateHints(comps, arg, execValidate)
 	})
 }
@@ -14,6 +14,10 @@ import (
 // whether it is equal to the original program or not. If it is equivalent then
 // the simplification attempt is committed and the process continues.
 func Minimize(p0 *Prog, callIndex0 int, crash bool, pred0 func(*Prog, int) bool) (*Prog, int) {
+	return minimize(p0, callIndex0, crash, false, pred0)
+}
+
+func minimize(p0 *Prog, callIndex0 int, crash, dd bool, pred0 func(*Prog, int) bool) (*Prog, int) {
 	pred := func(p *Prog, callIndex int) bool {
 		p.sanitizeFix()
 		p.debugValidate()
@@ -26,13 +30,23 @@ func Minimize(p0 *Prog, callIndex0 int, crash bool, pred0 func(*Prog, int) bool)
 		}
 		name0 = p0.Calls[callIndex0].Meta.Name
 	}
-
-	// Try to remove all calls except the last one one-by-one.
-	p0, callIndex0 = removeCalls(p0, callIndex0, crash, pred)
+	var triedPaths []map[string]bool
+	if dd {
+		// Try to remove groups of calls together with calls that use their resources.
+		p0, callIndex0 = removeCallsDD(p0, callIndex0, pred)
+	} else {
+		// Try to remove all calls except the last one one-by-one.
+		p0, callIndex0 = removeCalls(p0, callIndex0, crash, pred)
+	}
 
 	// Try to reset all call props to their default values.
 	p0 = resetCallProps(p0, callIndex0, pred)
 
+	if dd {
+		// Try to simplify groups of arguments across all calls at once.
+		p0, triedPaths = minimizeArgsDD(p0, callIndex0, crash, pred)
+	}
+
 	// Try to minimize individual calls.
 	for i := 0; i < len(p0.Calls); i++ {
 		if p0.Calls[i].Meta.Attrs.NoMinimize {
@@ -46,6 +60,9 @@ func Minimize(p0 *Prog, callIndex0 int, crash bool, pred0 func(*Prog, int) bool)
 			pred:       pred,
 			triedPaths: make(map[string]bool),
 		}
+		if triedPaths != nil {
+			ctx.triedPaths = triedPaths[i]
+		}
 	again:
 		ctx.p = p0.Clone()
 		ctx.call = ctx.p.Calls[i]
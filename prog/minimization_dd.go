@@ -0,0 +1,285 @@
+// Copyright 2026 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package prog
+
+import (
+	"fmt"
+)
+
+// MinimizeDD is a delta-debugging version of Minimize. It has the same semantics,
+// but instead of removing calls and simplifying arguments one-by-one it first tries to do it
+// for large groups of calls and arguments (across all calls) at once and then gradually
+// reduces size of the groups. Calls are removed together with all calls that use their resources.
+// The result is then refined with the per-argument minimization of Minimize, but simplifications
+// that were already rejected for individual arguments are not retried.
+// This needs fewer predicate invocations for long programs where most of the calls
+// and arguments don't matter, but may need a few more for short programs.
+func MinimizeDD(p0 *Prog, callIndex0 int, crash bool, pred0 func(*Prog, int) bool) (*Prog, int) {
+	return minimize(p0, callIndex0, crash, true, pred0)
+}
+
+// ddmin runs the delta debugging loop over n candidate simplifications.
+// try applies the candidates [begin, end) at once and returns the new number of candidates
+// if the simplified program is accepted. Applying candidates must not affect the preceding candidates.
+// Candidates are scanned in order in chunks: the chunk size is doubled after an accepted chunk
+// and halved after a rejected one. So long runs of irrelevant candidates are applied
+// in a logarithmic number of steps, while relevant candidates cost about as much
+// as with one-by-one minimization.
+func ddmin(n int, try func(begin, end int) (int, bool)) {
+	for begin, chunk := 0, 1; begin < n; {
+		end := begin + chunk
+		if end > n {
+			end = n
+		}
+		n1, ok := try(begin, end)
+		switch {
+		case ok && n1 < n:
+			// Accepted candidates normally disappear from the list and the next ones shift to begin.
+			n, chunk = n1, chunk*2
+		case ok:
+			// But this is not guaranteed (e.g. sanitization can restore some values).
+			n, begin = n1, end
+		case chunk > 1:
+			chunk /= 2
+		default:
+			begin = end
+		}
+	}
+}
+
+func removeCallsDD(p0 *Prog, callIndex0 int, pred func(*Prog, int) bool) (*Prog, int) {
+	candidates := func() []int {
+		var res []int
+		for i := range p0.Calls {
+			if i != callIndex0 {
+				res = append(res, i)
+			}
+		}
+		return res
+	}
+	// Removal of calls can make removal of the preceding calls possible,
+	// so repeat until nothing can be removed.
+	for removed := true; removed; {
+		removed = false
+		cands := candidates()
+		ddmin(len(cands), func(begin, end int) (int, bool) {
+			p, callIndex := removeCallSet(p0, callIndex0, dependentCalls(p0, cands[begin:end], callIndex0))
+			if !pred(p, callIndex) {
+				return 0, false
+			}
+			p0, callIndex0, removed = p, callIndex, true
+			cands = candidates()
+			return len(cands), true
+		})
+	}
+	// Calls with dependent calls were tried to be removed only together with the dependent calls.
+	// Removal of calls only shifts subsequent calls, so indices of the calls that are not yet visited
+	// stay valid when we iterate backwards.
+	var tried []bool
+	for i := range p0.Calls {
+		tried = append(tried, len(dependentCalls(p0, []int{i}, callIndex0)) == 1)
+	}
+	for i := len(p0.Calls) - 1; i >= 0; i-- {
+		if i == callIndex0 || tried[i] {
+			continue
+		}
+		p, callIndex := removeCallSet(p0, callIndex0, map[int]bool{i: true})
+		if pred(p, callIndex) {
+			p0, callIndex0 = p, callIndex
+		}
+	}
+	return p0, callIndex0
+}
+
+// dependentCalls returns the calls together with all calls that (transitively) use their resources.
+// Call keep is never included.
+func dependentCalls(p *Prog, calls []int, keep int) map[int]bool {
+	locs := resultLocations(p)
+	users := make([][]int, len(p.Calls))
+	for i, c := range p.Calls {
+		ForeachArg(c, func(arg Arg, _ *ArgCtx) {
+			if a, ok := arg.(*ResultArg); ok && a.Res != nil {
+				if producer := locs[a.Res].call; producer != i {
+					users[producer] = append(users[producer], i)
+				}
+			}
+		})
+	}
+	res := make(map[int]bool)
+	for queue := append([]int{}, calls...); len(queue) != 0; queue = queue[1:] {
+		idx := queue[0]
+		if idx == keep || res[idx] {
+			continue
+		}
+		res[idx] = true
+		queue = append(queue, users[idx]...)
+	}
+	return res
+}
+
+func removeCallSet(p0 *Prog, callIndex0 int, calls map[int]bool) (*Prog, int) {
+	p := p0.Clone()
+	for i := len(p.Calls) - 1; i >= 0; i-- {
+		if !calls[i] {
+			continue
+		}
+		p.RemoveCall(i)
+		if i < callIndex0 {
+			callIndex0--
+		}
+	}
+	return p, callIndex0
+}
+
+// ddArg identifies an argument that can be simplified by path used by minimizeArgsCtx.
+type ddArg struct {
+	call int
+	path string
+	// The triedPaths key that corresponds to the simplification in minimizeArgsCtx,
+	// empty if minimizeArgsCtx tries more than the single simplification.
+	tried string
+}
+
+// minimizeArgsDD simplifies groups of arguments in all calls. It returns the simplified program
+// and per-call tried paths for minimizeArgsCtx with simplifications that were rejected individually.
+func minimizeArgsDD(p0 *Prog, callIndex0 int, crash bool, pred func(*Prog, int) bool) (*Prog, []map[string]bool) {
+	cands := ddArgCandidates(p0, crash)
+	var failed []ddArg
+	ddmin(len(cands), func(begin, end int) (int, bool) {
+		selected := make(map[ddArg]bool)
+		calls := make(map[int]bool)
+		for _, cand := range cands[begin:end] {
+			selected[cand] = true
+			calls[cand.call] = true
+		}
+		p := p0.Clone()
+		for i, c := range p.Calls {
+			if !calls[i] {
+				continue
+			}
+			var args []Arg
+			forEachMinimizeArg(c, func(arg Arg, path string) {
+				if ok, tried := ddSimplifiable(arg, path, crash); ok && selected[ddArg{i, path, tried}] {
+					args = append(args, arg)
+				}
+			})
+			// Simplify inner arguments before the pointers that contain them.
+			for j := len(args) - 1; j >= 0; j-- {
+				simplifyArg(args[j])
+			}
+			p.Target.assignSizesCall(c)
+		}
+		if !pred(p, callIndex0) {
+			if end-begin == 1 {
+				failed = append(failed, cands[begin])
+			}
+			return 0, false
+		}
+		p0 = p
+		cands = ddArgCandidates(p0, crash)
+		return len(cands), true
+	})
+	triedPaths := make([]map[string]bool, len(p0.Calls))
+	for i := range triedPaths {
+		triedPaths[i] = make(map[string]bool)
+	}
+	for _, arg := range failed {
+		if arg.tried != "" {
+			triedPaths[arg.call][arg.tried] = true
+		}
+	}
+	return p0, triedPaths
+}
+
+func ddArgCandidates(p *Prog, crash bool) []ddArg {
+	var cands []ddArg
+	for i, c := range p.Calls {
+		if c.Meta.Attrs.NoMinimize {
+			continue
+		}
+		forEachMinimizeArg(c, func(arg Arg, path string) {
+			if ok, tried := ddSimplifiable(arg, path, crash); ok {
+				cands = append(cands, ddArg{i, path, tried})
+			}
+		})
+	}
+	return cands
+}
+
+// ddSimplifiable returns whether simplifyArg can simplify the argument,
+// and the corresponding triedPaths key of minimizeArgsCtx (see ddArg).
+func ddSimplifiable(arg Arg, path string, crash bool) (bool, string) {
+	switch a := arg.(type) {
+	case *ConstArg:
+		switch typ := a.Type().(type) {
+		case *IntType, *FlagsType:
+		case *ProcType:
+			if !typ.Optional() {
+				return false, ""
+			}
+		default:
+			return false, ""
+		}
+		return !crash && a.Val != a.Type().DefaultArg(a.Dir()).(*ConstArg).Val, path
+	case *ResultArg:
+		return !crash && a.Res != nil, path
+	case *PointerArg:
+		_, ok := a.Type().(*PtrType)
+		return ok && a.Res != nil, path + ">"
+	case *DataArg:
+		typ := a.Type().(*BufferType)
+		return a.Dir() != DirOut && (typ.Kind == BufferBlobRand || typ.Kind == BufferBlobRange) &&
+			uint64(len(a.Data())) > typ.RangeBegin, ""
+	}
+	return false, ""
+}
+
+// simplifyArg resets ints and resources to default values, replaces pointers with nil
+// and truncates blobs to the minimal length.
+func simplifyArg(arg Arg) {
+	switch a := arg.(type) {
+	case *ConstArg:
+		a.Val = a.Type().DefaultArg(a.Dir()).(*ConstArg).Val
+	case *ResultArg:
+		// The resource may already be reset if the producer argument was removed.
+		if a.Res != nil {
+			delete(a.Res.uses, a)
+			a.Res, a.Val = nil, a.Type().(*ResourceType).Default()
+		}
+	case *PointerArg:
+		removeArg(a.Res)
+		replaceArg(a, MakeSpecialPointerArg(a.Type(), a.Dir(), 0))
+	case *DataArg:
+		a.data = a.Data()[:a.Type().(*BufferType).RangeBegin]
+	}
+}
+
+// forEachMinimizeArg calls fn for all arguments of the call with the paths that minimizeArgsCtx uses.
+func forEachMinimizeArg(c *Call, fn func(arg Arg, path string)) {
+	for i, field := range c.Meta.Args {
+		walkMinimizeArg(c.Args[i], field.Name, "", fn)
+	}
+}
+
+func walkMinimizeArg(arg Arg, field, path string, fn func(arg Arg, path string)) {
+	path += fmt.Sprintf("-%v", field)
+	fn(arg, path)
+	switch typ := arg.Type().(type) {
+	case *StructType:
+		for i, inner := range arg.(*GroupArg).Inner {
+			walkMinimizeArg(inner, typ.Fields[i].Name, path, fn)
+		}
+	case *UnionType:
+		a := arg.(*UnionArg)
+		walkMinimizeArg(a.Option, typ.Fields[a.Index].Name, path, fn)
+	case *PtrType:
+		if a := arg.(*PointerArg); a.Res != nil {
+			walkMinimizeArg(a.Res, "", path, fn)
+		}
+	case *ArrayType:
+		for i, elem := range arg.(*GroupArg).Inner {
+			walkMinimizeArg(elem, "", fmt.Sprintf("%v-%v", path, i), fn)
+		}
+	}
+}
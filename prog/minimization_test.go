@@ -4,6 +4,7 @@
 package prog
 
 import (
+	"fmt"
 	"math/rand"
 	"testing"
 )
@@ -251,16 +252,23 @@ func TestMinimize(t *testing.T) {
 		if err != nil {
 			t.Fatalf("failed to deserialize original program #%v: %v", ti, err)
 		}
-		p1, ci := Minimize(p, test.callIndex, false, test.pred)
-		res := p1.Serialize()
-		if string(res) != test.result {
-			t.Fatalf("minimization produced wrong result #%v\norig:\n%v\nexpect:\n%v\ngot:\n%v\n",
-				ti, test.orig, test.result, string(res))
-		}
-		if ci != test.resultCallIndex {
-			t.Fatalf("minimization broke call index #%v: got %v, want %v",
-				ti, ci, test.resultCallIndex)
+		var runs [2]int
+		for mi, minimize := range []func(*Prog, int, bool, func(*Prog, int) bool) (*Prog, int){Minimize, MinimizeDD} {
+			p1, ci := minimize(p.Clone(), test.callIndex, false, func(p *Prog, callIndex int) bool {
+				runs[mi]++
+				return test.pred(p, callIndex)
+			})
+			res := p1.Serialize()
+			if string(res) != test.result {
+				t.Fatalf("minimization #%v produced wrong result #%v\norig:\n%v\nexpect:\n%v\ngot:\n%v\n",
+					mi, ti, test.orig, test.result, string(res))
+			}
+			if ci != test.resultCallIndex {
+				t.Fatalf("minimization #%v broke call index #%v: got %v, want %v",
+					mi, ti, ci, test.resultCallIndex)
+			}
 		}
+		t.Logf("test #%v: %v runs, %v delta debugging runs", ti, runs[0], runs[1])
 	}
 }
 
@@ -304,3 +312,102 @@ func TestMinimizeCallIndex(t *testing.T) {
 		}
 	}
 }
+
+func TestMinimizeDDRandom(t *testing.T) {
+	target, rs, iters := initTest(t)
+	iters /= 10 // Long test.
+	ct := target.DefaultChoiceTable()
+	r := rand.New(rs)
+	for i := 0; i < iters; i++ {
+		for _, crash := range []bool{false, true} {
+			p := target.Generate(rs, 5, ct)
+			copyP := p.Clone()
+			minP, _ := MinimizeDD(p, len(p.Calls)-1, crash, func(p1 *Prog, callIndex int) bool {
+				if r.Intn(2) == 0 {
+					return false
+				}
+				copyP = p1.Clone()
+				return true
+			})
+			got := string(minP.Serialize())
+			want := string(copyP.Serialize())
+			if got != want {
+				t.Fatalf("program:\n%s\ngot:\n%v\nwant:\n%s", string(p.Serialize()), got, want)
+			}
+		}
+	}
+}
+
+func TestMinimizeDDCallIndex(t *testing.T) {
+	target, rs, iters := initTest(t)
+	ct := target.DefaultChoiceTable()
+	r := rand.New(rs)
+	for i := 0; i < iters; i++ {
+		p := target.Generate(rs, 5, ct)
+		ci := r.Intn(len(p.Calls))
+		p1, ci1 := MinimizeDD(p, ci, r.Intn(2) == 0, func(p1 *Prog, callIndex int) bool {
+			return r.Intn(2) == 0
+		})
+		if ci1 < 0 || ci1 >= len(p1.Calls) || p.Calls[ci].Meta.Name != p1.Calls[ci1].Meta.Name {
+			t.Fatalf("bad call index after minimization")
+		}
+	}
+}
+
+func TestMinimizeDD(t *testing.T) {
+	target, rs, iters := initTest(t)
+	iters /= 10 // Long test.
+	ct := target.DefaultChoiceTable()
+	r := rand.New(rs)
+	var runs, runsDD int
+	for i := 0; i < iters; i++ {
+		p := target.Generate(rs, 50, ct)
+		ci := r.Intn(len(p.Calls))
+		crash := r.Intn(2) == 0
+		// The "bug" needs the target call and a few preceding calls with all their resources.
+		needed := map[string]int{minimizeCallKey(p.Calls[ci]): 1}
+		for _, c := range p.Calls[:ci] {
+			if r.Intn(10) == 0 {
+				needed[minimizeCallKey(c)]++
+			}
+		}
+		pred := func(count *int) func(*Prog, int) bool {
+			return func(p1 *Prog, callIndex int) bool {
+				*count++
+				seen := make(map[string]int)
+				for _, c := range p1.Calls[:callIndex+1] {
+					seen[minimizeCallKey(c)]++
+				}
+				for key, n := range needed {
+					if seen[key] < n {
+						return false
+					}
+				}
+				return minimizeCallKey(p1.Calls[callIndex]) == minimizeCallKey(p.Calls[ci])
+			}
+		}
+		var n, nDD int
+		p1, ci1 := Minimize(p.Clone(), ci, crash, pred(&n))
+		p2, ci2 := MinimizeDD(p.Clone(), ci, crash, pred(&nDD))
+		if !pred(new(int))(p1, ci1) || !pred(new(int))(p2, ci2) {
+			t.Fatalf("minimized program does not satisfy the predicate")
+		}
+		runs += n
+		runsDD += nDD
+	}
+	t.Logf("predicate runs: %v, with delta debugging: %v", runs, runsDD)
+	if runsDD >= runs {
+		t.Fatalf("delta debugging is not faster: %v runs vs %v runs", runsDD, runs)
+	}
+}
+
+// minimizeCallKey identifies the call by name and the number of resources it uses.
+func minimizeCallKey(c *Call) string {
+	resources := 0
+	ForeachArg(c, func(arg Arg, _ *ArgCtx) {
+		if a, ok := arg.(*ResultArg); ok && a.Res != nil {
+			resources++
+		}
+	})
+	return fmt.Sprintf("%v/%v", c.Meta.Name, resources)
+}
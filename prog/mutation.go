@@ -16,6 +16,61 @@ import (
 // Maximum length of generated binary blobs inserted into the program.
 const maxBlobLen = uint64(100 << 10)
 
+// MutationOp is a program-level mutation operator applied by Mutate.
+type MutationOp int
+
+const (
+	MutationSquashAny MutationOp = iota
+	MutationSplice
+	MutationInsertCall
+	MutationMutateArg
+	MutationRemoveCall
+	MutationOpCount
+)
+
+var mutationOpNames = [MutationOpCount]string{
+	MutationSquashAny:  "squash any",
+	MutationSplice:     "splice",
+	MutationInsertCall: "insert call",
+	MutationMutateArg:  "mutate arg",
+	MutationRemoveCall: "remove call",
+}
+
+func (op MutationOp) String() string {
+	return mutationOpNames[op]
+}
+
+// DefaultMutationWeights are weights of mutation operators (indexed by MutationOp)
+// that approximate the odds used by Mutate.
+var DefaultMutationWeights = []int{
+	MutationSquashAny:  200,
+	MutationSplice:     8,
+	MutationInsertCall: 511,
+	MutationMutateArg:  255,
+	MutationRemoveCall: 26,
+}
+
+// MutationScheduler chooses mutation operators for MutateWithScheduler.
+type MutationScheduler interface {
+	// Weights returns relative weights of mutation operators indexed by MutationOp.
+	// Operators are applied until one of them succeeds, so it's better to keep all weights non-zero.
+	Weights() []int
+}
+
+// Mutation describes mutations applied by MutateWithScheduler.
+type Mutation struct {
+	// Operators that were successfully applied to the program, in order.
+	Ops []MutationOp
+	// Calls inserted by MutationInsertCall along with the calls their choice was biased to.
+	Insertions []CallPair
+}
+
+// CallPair is a pair of syscall IDs: Next was chosen to be inserted into a program based on Prev.
+type CallPair struct {
+	Prev int
+	Next int
+}
+
 // Mutate program p.
 //
 // p:           The program to mutate.
@@ -25,6 +80,23 @@ const maxBlobLen = uint64(100 << 10)
 // noMutate:    Set of IDs of syscalls which should not be mutated.
 // corpus:      The entire corpus, including original program p.
 func (p *Prog) Mutate(rs rand.Source, ncalls int, ct *ChoiceTable, noMutate map[int]bool, corpus []*Prog) {
+	p.mutate(rs, ncalls, ct, noMutate, corpus, nil, nil)
+}
+
+// MutateWithScheduler is the same as Mutate, but mutation operators are chosen
+// according to the weights supplied by sched.
+// Returns the description of mutations that were applied to the program.
+func (p *Prog) MutateWithScheduler(rs rand.Source, ncalls int, ct *ChoiceTable, noMutate map[int]bool,
+	corpus []*Prog, sched MutationScheduler) Mutation {
+	weights := sched.Weights()
+	if len(weights) != int(MutationOpCount) {
+		panic(fmt.Sprintf("bad number of mutation weights: %v, want %v", len(weights), MutationOpCount))
+	}
+	return p.mutate(rs, ncalls, ct, noMutate, corpus, weights, nil)
+}
+
+func (p *Prog) mutate(rs rand.Source, ncalls int, ct *ChoiceTable, noMutate map[int]bool, corpus []*Prog,
+	weights []int, trace *Trace) Mutation {
 	r := newRand(p.Target, rs)
 	if ncalls < len(p.Calls) {
 		ncalls = len(p.Calls)
@@ -36,28 +108,89 @@ func (p *Prog) Mutate(rs rand.Source, ncalls int, ct *ChoiceTable, noMutate map[
 		ct:       ct,
 		noMutate: noMutate,
 		corpus:   corpus,
-	}
-	for stop, ok := false, false; !stop; stop = ok && len(p.Calls) != 0 && r.oneOf(3) {
-		switch {
-		case r.oneOf(5):
-			// Not all calls have anything squashable,
-			// so this has lower priority in reality.
+		trace:    trace,
+		template: p.IsTemplate(),
+	}
+	failed := 0
+	// Templates where all holes are in calls that must not be mutated are left intact.
+	noHoles := ctx.template && !p.IsMutableTemplate(noMutate)
+	for stop, ok := noHoles, false; !stop; stop = ok && len(p.Calls) != 0 && r.oneOf(3) {
+		// Don't loop forever if the scheduler insists on operators that are not applicable.
+		const maxFailed = 100
+		if failed == maxFailed {
+			weights = nil
+		}
+		// Calls of templates are fixed, only arguments in holes are mutated.
+		op := MutationMutateArg
+		if !ctx.template {
+			op = ctx.chooseOp(weights)
+		}
+		traced := 0
+		if trace != nil {
+			traced = len(trace.Ops)
+		}
+		switch op {
+		case MutationSquashAny:
 			ok = ctx.squashAny()
-		case r.nOutOf(1, 100):
+		case MutationSplice:
 			ok = ctx.splice()
-		case r.nOutOf(20, 31):
+		case MutationInsertCall:
 			ok = ctx.insertCall()
-		case r.nOutOf(10, 11):
+		case MutationMutateArg:
 			ok = ctx.mutateArg()
-		default:
+		case MutationRemoveCall:
 			ok = ctx.removeCall()
 		}
+		if ok {
+			ctx.mutation.Ops = append(ctx.mutation.Ops, op)
+		} else {
+			failed++
+		}
+		trace.recordProg(p, traced)
 	}
 	p.sanitizeFix()
 	p.debugValidate()
 	if got := len(p.Calls); got < 1 || got > ncalls {
 		panic(fmt.Sprintf("bad number of calls after mutation: %v, want [1, %v]", got, ncalls))
 	}
+	return ctx.mutation
+}
+
+// chooseOp chooses the next mutation operator according to weights,
+// or using the default odds if weights are nil.
+func (ctx *mutator) chooseOp(weights []int) MutationOp {
+	r := ctx.r
+	if weights == nil {
+		switch {
+		case r.oneOf(5):
+			// Not all calls have anything squashable,
+			// so this has lower priority in reality.
+			return MutationSquashAny
+		case r.nOutOf(1, 100):
+			return MutationSplice
+		case r.nOutOf(20, 31):
+			return MutationInsertCall
+		case r.nOutOf(10, 11):
+			return MutationMutateArg
+		default:
+			return MutationRemoveCall
+		}
+	}
+	total := 0
+	for _, w := range weights {
+		total += w
+	}
+	if total <= 0 {
+		return ctx.chooseOp(nil)
+	}
+	x := r.Intn(total)
+	for op, w := range weights {
+		if x < w {
+			return MutationOp(op)
+		}
+		x -= w
+	}
+	panic("bad mutation op choice")
 }
 
 // Internal state required for performing mutations -- currently this matches
@@ -69,6 +202,9 @@ type mutator struct {
 	ct       *ChoiceTable // ChoiceTable for syscalls.
 	noMutate map[int]bool // Set of IDs of syscalls which should not be mutated.
 	corpus   []*Prog      // The entire corpus, including original program p.
+	mutation Mutation     // Description of the applied mutations.
+	trace    *Trace       // Trace of the applied mutations, if tracing is enabled.
+	template bool         // Whether the program is a template, then only holes are mutated.
 }
 
 // This function selects a random other program p0 out of the corpus, and
@@ -82,6 +218,9 @@ func (ctx *mutator) splice() bool {
 	p0 := ctx.corpus[r.Intn(len(ctx.corpus))]
 	p0c := p0.Clone()
 	idx := r.Intn(len(p.Calls))
+	ctx.trace.record(func() TraceOp {
+		return TraceOp{Op: MutationSplice.String(), Call: idx, Spliced: progHash(p0)}
+	})
 	p.Calls = append(p.Calls[:idx], append(p0c.Calls, p.Calls[idx:]...)...)
 	for i := len(p.Calls) - 1; i >= ctx.ncalls; i-- {
 		p.RemoveCall(i)
@@ -133,6 +272,14 @@ func (ctx *mutator) squashAny() bool {
 		newArg := r.allocAddr(s, base.Type(), base.Dir(), base.Res.Size(), base.Res)
 		*base = *newArg
 	}
+	ctx.trace.record(func() TraceOp {
+		return TraceOp{
+			Op:      MutationSquashAny.String(),
+			Call:    p.callIndex(ptr.call),
+			Syscall: ptr.call.Meta.Name,
+			Arg:     argPath(ptr.call, ptr.arg),
+		}
+	})
 	return true
 }
 
@@ -149,7 +296,13 @@ func (ctx *mutator) insertCall() bool {
 		c = p.Calls[idx]
 	}
 	s := analyze(ctx.ct, ctx.corpus, p, c)
-	calls := r.generateCall(s, p, idx)
+	calls, bias := r.generateBiasedCall(s, p, idx)
+	if bias != -1 {
+		ctx.mutation.Insertions = append(ctx.mutation.Insertions, CallPair{bias, calls[len(calls)-1].Meta.ID})
+	}
+	ctx.trace.record(func() TraceOp {
+		return TraceOp{Op: MutationInsertCall.String(), Call: idx, Syscall: calls[len(calls)-1].Meta.Name}
+	})
 	p.insertBefore(c, calls)
 	for len(p.Calls) > ctx.ncalls {
 		p.RemoveCall(idx)
@@ -164,6 +317,9 @@ func (ctx *mutator) removeCall() bool {
 		return false
 	}
 	idx := r.Intn(len(p.Calls))
+	ctx.trace.record(func() TraceOp {
+		return TraceOp{Op: MutationRemoveCall.String(), Call: idx, Syscall: p.Calls[idx].Meta.Name}
+	})
 	p.RemoveCall(idx)
 	return true
 }
@@ -171,6 +327,9 @@ func (ctx *mutator) removeCall() bool {
 // Mutate an argument of a random call.
 func (ctx *mutator) mutateArg() bool {
 	p, r := ctx.p, ctx.r
+	if ctx.template {
+		return ctx.mutateHole(p.mutableHoles(ctx.noMutate))
+	}
 	if len(p.Calls) == 0 {
 		return false
 	}
@@ -193,6 +352,11 @@ func (ctx *mutator) mutateArg() bool {
 		}
 		s := analyze(ctx.ct, ctx.corpus, p, c)
 		arg, argCtx := ma.chooseArg(r.Rand)
+		var path string
+		if ctx.trace != nil {
+			// The arg can be replaced during mutation, so take the path beforehand.
+			path = argPath(c, arg)
+		}
 		calls, ok1 := p.Target.mutateArg(r, s, arg, argCtx, &updateSizes)
 		if !ok1 {
 			ok = false
@@ -211,6 +375,9 @@ func (ctx *mutator) mutateArg() bool {
 		if updateSizes {
 			p.Target.assignSizesCall(c)
 		}
+		ctx.trace.record(func() TraceOp {
+			return TraceOp{Op: MutationMutateArg.String(), Call: idx, Syscall: c.Meta.Name, Arg: path}
+		})
 	}
 	return true
 }
@@ -255,6 +255,56 @@ func TestMutateCorpus(t *testing.T) {
 	}
 }
 
+type testMutationScheduler []int
+
+func (s testMutationScheduler) Weights() []int {
+	return s
+}
+
+func TestMutateWithScheduler(t *testing.T) {
+	target, rs, iters := initTest(t)
+	ct := target.DefaultChoiceTable()
+	var corpus []*Prog
+	for i := 0; i < 100; i++ {
+		corpus = append(corpus, target.Generate(rs, 10, ct))
+	}
+	for op := MutationOp(0); op < MutationOpCount; op++ {
+		weights := make(testMutationScheduler, MutationOpCount)
+		weights[op] = 1
+		total, chosen, insertions := 0, 0, 0
+		for i := 0; i < iters/10+1; i++ {
+			// Leave enough room for splice and insert call.
+			p := target.Generate(rs, 5, ct)
+			mutation := p.MutateWithScheduler(rs, 100, ct, nil, corpus, weights)
+			inserts := 0
+			for _, op1 := range mutation.Ops {
+				if op1 == MutationInsertCall {
+					inserts++
+				}
+			}
+			// Calls inserted at the beginning of the program have no bias.
+			if len(mutation.Insertions) > inserts {
+				t.Fatalf("%v insertions for %v insert call operators", len(mutation.Insertions), inserts)
+			}
+			insertions += len(mutation.Insertions)
+			for _, op1 := range mutation.Ops {
+				total++
+				if op1 == op {
+					chosen++
+				}
+			}
+		}
+		// Other operators are used only if the requested one is not applicable many times in a row
+		// (e.g. squash any is not applicable to programs without complex pointers).
+		if chosen*4 < total {
+			t.Errorf("%v: applied %v times out of %v", op, chosen, total)
+		}
+		if op == MutationInsertCall && insertions == 0 {
+			t.Errorf("no call insertions recorded")
+		}
+	}
+}
+
 func TestMutateTable(t *testing.T) {
 	tests := [][2]string{
 		// Insert a call.
@@ -5,15 +5,17 @@ package prog
 
 import (
 	"bytes"
+	"encoding/json"
 	"strconv"
 )
 
 // LogEntry describes one program in execution log.
 type LogEntry struct {
 	P     *Prog
-	Proc  int // index of parallel proc
-	Start int // start offset in log
-	End   int // end offset in log
+	Proc  int    // index of parallel proc
+	Start int    // start offset in log
+	End   int    // end offset in log
+	Trace *Trace // how the program was derived, if the fuzzer recorded traces
 }
 
 func (target *Target) ParseLog(data []byte) []*LogEntry {
@@ -48,6 +50,7 @@ func (target *Target) ParseLog(data []byte) []*LogEntry {
 				faultCall = parsedFaultCall
 				faultNth, _ = extractInt(line, "fault-nth:")
 			}
+			ent.Trace = extractTrace(line)
 			cur = nil
 			continue
 		}
@@ -74,6 +77,21 @@ func (target *Target) ParseLog(data []byte) []*LogEntry {
 	return entries
 }
 
+// extractTrace parses the "[trace {...}]" suffix of an "executing program" line.
+func extractTrace(line []byte) *Trace {
+	const prefix = "[trace "
+	start := bytes.Index(line, []byte(prefix))
+	end := bytes.LastIndexByte(line, ']')
+	if start == -1 || end < start {
+		return nil
+	}
+	trace := new(Trace)
+	if err := json.Unmarshal(line[start+len(prefix):end], trace); err != nil {
+		return nil
+	}
+	return trace
+}
+
 func extractInt(line []byte, prefix string) (int, bool) {
 	pos := bytes.Index(line, []byte(prefix))
 	if pos == -1 {
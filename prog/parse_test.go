@@ -138,3 +138,33 @@ getpid()
 		t.Fatalf("bad program: %s, want %s", got, want)
 	}
 }
+
+func TestParseTrace(t *testing.T) {
+	t.Parallel()
+	target, err := GetTarget("linux", "amd64")
+	if err != nil {
+		t.Fatal(err)
+	}
+	const execLog = `2015/12/21 12:18:05 executing program 1 [trace {"kind":"generate","seed":42,"ncalls":2,` +
+		`"child":"abc","ops":[{"op":"generate","call":0,"syscall":"gettid"},{"op":"generate","call":1,"syscall":"getpid"}]}]:
+gettid()
+getpid()
+2015/12/21 12:18:05 executing program 2:
+getpid()
+`
+	entries := target.ParseLog([]byte(execLog))
+	if len(entries) != 2 {
+		t.Fatalf("got %v programs, want 2", len(entries))
+	}
+	if entries[0].Proc != 1 || entries[1].Proc != 2 {
+		t.Fatalf("bad procs")
+	}
+	trace := entries[0].Trace
+	if trace == nil || trace.Kind != TraceGenerate || trace.Seed != 42 || len(trace.Ops) != 2 ||
+		trace.Ops[1].Syscall != "getpid" {
+		t.Fatalf("bad trace: %+v", trace)
+	}
+	if entries[1].Trace != nil {
+		t.Fatalf("unexpected trace: %+v", entries[1].Trace)
+	}
+}
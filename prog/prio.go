@@ -179,6 +179,85 @@ func normalizePrio(prios [][]int32) {
 	}
 }
 
+// CallPriors are externally supplied call-to-call priorities (e.g. estimated from semantic relations
+// between syscalls) that are blended into priorities calculated by CalculatePriorities.
+type CallPriors struct {
+	// Priors normalized to [prioLow..prioHigh], nil for calls without priors.
+	prios  [][]int32
+	factor float64
+}
+
+// MakeCallPriors creates call priors from a map of call name pairs to non-negative weights:
+// weights[X][Y] is the relative weight of adding call Y to a program that contains call X.
+// Weights are normalized per X, pairs missing for a present X get the lowest priority.
+// Factor in [0..1] is the share of the priors in the blended priorities.
+func (target *Target) MakeCallPriors(weights map[string]map[string]float64, factor float64) (*CallPriors, error) {
+	if factor < 0 || factor > 1 {
+		return nil, fmt.Errorf("call priors factor %v is out of [0, 1] range", factor)
+	}
+	priors := &CallPriors{
+		prios:  make([][]int32, len(target.Syscalls)),
+		factor: factor,
+	}
+	for name0, row := range weights {
+		c0 := target.SyscallMap[name0]
+		if c0 == nil {
+			return nil, fmt.Errorf("unknown call %v in call priors", name0)
+		}
+		max := 0.0
+		for name1, w := range row {
+			if target.SyscallMap[name1] == nil {
+				return nil, fmt.Errorf("unknown call %v in call priors", name1)
+			}
+			if w < 0 {
+				return nil, fmt.Errorf("negative call prior %v for %v -> %v", w, name0, name1)
+			}
+			if max < w {
+				max = w
+			}
+		}
+		prios := make([]int32, len(target.Syscalls))
+		for i := range prios {
+			prios[i] = prioLow
+		}
+		if max != 0 {
+			for name1, w := range row {
+				prios[target.SyscallMap[name1].ID] += int32(w * (prioHigh - prioLow) / max)
+			}
+		}
+		priors.prios[c0.ID] = prios
+	}
+	return priors, nil
+}
+
+// Prior returns the normalized prior priority of call next to bias,
+// or false if there are no priors for bias.
+func (priors *CallPriors) Prior(bias, call int) (int32, bool) {
+	if priors == nil || priors.prios[bias] == nil {
+		return 0, false
+	}
+	return priors.prios[bias][call], true
+}
+
+// Blend mixes the priors into priorities returned by CalculatePriorities.
+func (priors *CallPriors) Blend(prios [][]int32) {
+	if priors == nil {
+		return
+	}
+	for i, row := range priors.prios {
+		if row == nil {
+			continue
+		}
+		for j, prior := range row {
+			prios[i][j] = int32(float64(prios[i][j])*(1-priors.factor) + float64(prior)*priors.factor)
+			if prios[i][j] < 1 {
+				// Zero priority would make the call impossible to choose.
+				prios[i][j] = 1
+			}
+		}
+	}
+}
+
 // ChooseTable allows to do a weighted choice of a syscall for a given syscall
 // based on call-to-call priorities and a set of enabled and generatable syscalls.
 type ChoiceTable struct {
@@ -189,6 +268,13 @@ type ChoiceTable struct {
 }
 
 func (target *Target) BuildChoiceTable(corpus []*Prog, enabled map[*Syscall]bool) *ChoiceTable {
+	return target.BuildChoiceTableWithPriors(corpus, enabled)
+}
+
+// BuildChoiceTableWithPriors is the same as BuildChoiceTable,
+// but additionally blends the given call priors (nil priors are ignored) into the priorities in order.
+func (target *Target) BuildChoiceTableWithPriors(corpus []*Prog, enabled map[*Syscall]bool,
+	priors ...*CallPriors) *ChoiceTable {
 	if enabled == nil {
 		enabled = make(map[*Syscall]bool)
 		for _, c := range target.Syscalls {
@@ -223,6 +309,9 @@ func (target *Target) BuildChoiceTable(corpus []*Prog, enabled map[*Syscall]bool
 		}
 	}
 	prios := target.CalculatePriorities(corpus)
+	for _, p := range priors {
+		p.Blend(prios)
+	}
 	run := make([][]int32, len(target.Syscalls))
 	// ChoiceTable.runs[][] contains cumulated sum of weighted priority numbers.
 	// This helps in quick binary search with biases when generating programs.
@@ -240,7 +329,12 @@ func (target *Target) BuildChoiceTable(corpus []*Prog, enabled map[*Syscall]bool
 			run[i][j] = sum
 		}
 	}
-	return &ChoiceTable{target, run, generatableCalls, noGenerateCalls}
+	return &ChoiceTable{
+		target:          target,
+		runs:            run,
+		calls:           generatableCalls,
+		noGenerateCalls: noGenerateCalls,
+	}
 }
 
 func (ct *ChoiceTable) Enabled(call int) bool {
@@ -251,6 +345,19 @@ func (ct *ChoiceTable) Generatable(call int) bool {
 	return ct.runs[call] != nil
 }
 
+// Weight returns the weight of choosing call when generating a call next to bias.
+// The weight is 0 if either of the calls is not generatable.
+func (ct *ChoiceTable) Weight(bias, call int) int32 {
+	run := ct.runs[bias]
+	if run == nil || !ct.Generatable(call) {
+		return 0
+	}
+	if call == 0 {
+		return run[0]
+	}
+	return run[call] - run[call-1]
+}
+
 func (ct *ChoiceTable) choose(r *rand.Rand, bias int) int {
 	if bias < 0 {
 		bias = ct.calls[r.Intn(len(ct.calls))].ID
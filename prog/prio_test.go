@@ -94,3 +94,72 @@ func TestPrioDeterminism(t *testing.T) {
 		}
 	}
 }
+
+func TestChoiceTableWeight(t *testing.T) {
+	target, _, _ := initTest(t)
+	ct := target.DefaultChoiceTable()
+	for bias := range target.Syscalls {
+		if !ct.Generatable(bias) {
+			for call := range target.Syscalls {
+				if w := ct.Weight(bias, call); w != 0 {
+					t.Fatalf("non-generatable %v has weight %v", target.Syscalls[bias].Name, w)
+				}
+			}
+			continue
+		}
+		var sum int32
+		for call := range target.Syscalls {
+			w := ct.Weight(bias, call)
+			if w < 0 {
+				t.Fatalf("negative weight %v for %v->%v", w,
+					target.Syscalls[bias].Name, target.Syscalls[call].Name)
+			}
+			sum += w
+		}
+		if run := ct.runs[bias]; sum != run[len(run)-1] {
+			t.Fatalf("weights of %v sum to %v, want %v", target.Syscalls[bias].Name, sum, run[len(run)-1])
+		}
+	}
+}
+
+func TestCallPriors(t *testing.T) {
+	target, _, _ := initTest(t)
+	ct := target.DefaultChoiceTable()
+	var calls []*Syscall
+	for _, call := range target.Syscalls {
+		if ct.Generatable(call.ID) {
+			calls = append(calls, call)
+		}
+	}
+	c0, c1, c2 := calls[0], calls[1], calls[2]
+	weights := map[string]map[string]float64{c0.Name: {c1.Name: 3}}
+	priors, err := target.MakeCallPriors(weights, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ct = target.BuildChoiceTableWithPriors(nil, nil, priors)
+	if w := ct.Weight(c0.ID, c1.ID); w != prioHigh {
+		t.Errorf("weight of %v->%v is %v, want %v", c0.Name, c1.Name, w, prioHigh)
+	}
+	if w := ct.Weight(c0.ID, c2.ID); w != prioLow {
+		t.Errorf("weight of %v->%v is %v, want %v", c0.Name, c2.Name, w, prioLow)
+	}
+	if _, ok := priors.Prior(c1.ID, c0.ID); ok {
+		t.Errorf("%v has priors", c1.Name)
+	}
+	if ct.Weight(c1.ID, c0.ID) != target.DefaultChoiceTable().Weight(c1.ID, c0.ID) {
+		t.Errorf("weights of %v has changed", c1.Name)
+	}
+	for _, bad := range []map[string]map[string]float64{
+		{"foo": {c1.Name: 1}},
+		{c0.Name: {"foo": 1}},
+		{c0.Name: {c1.Name: -1}},
+	} {
+		if _, err := target.MakeCallPriors(bad, 0.5); err == nil {
+			t.Errorf("no error for %v", bad)
+		}
+	}
+	if _, err := target.MakeCallPriors(weights, 2); err == nil {
+		t.Errorf("no error for factor 2")
+	}
+}
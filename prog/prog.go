@@ -12,6 +12,9 @@ type Prog struct {
 	Target   *Target
 	Calls    []*Call
 	Comments []string
+
+	// Holes of a program template (see template.go).
+	holes map[Arg]*hole
 }
 
 // These properties are parsed and serialized according to the tag and the type
@@ -559,6 +559,13 @@ func (r *randGen) nOutOf(n, outOf int) bool {
 }
 
 func (r *randGen) generateCall(s *state, p *Prog, insertionPoint int) []*Call {
+	calls, _ := r.generateBiasedCall(s, p, insertionPoint)
+	return calls
+}
+
+// generateBiasedCall is the same as generateCall, but also returns ID of the call
+// that was used as the bias for the choice of the new call (-1 if there was no bias).
+func (r *randGen) generateBiasedCall(s *state, p *Prog, insertionPoint int) ([]*Call, int) {
 	biasCall := -1
 	if insertionPoint > 0 {
 		// Choosing the base call is based on the insertion point of the new calls sequence.
@@ -570,7 +577,7 @@ func (r *randGen) generateCall(s *state, p *Prog, insertionPoint int) []*Call {
 	}
 	idx := s.ct.choose(r.Rand, biasCall)
 	meta := r.target.Syscalls[idx]
-	return r.generateParticularCall(s, meta)
+	return r.generateParticularCall(s, meta), biasCall
 }
 
 func (r *randGen) generateParticularCall(s *state, meta *Syscall) (calls []*Call) {
@@ -323,10 +323,16 @@ func (g *Gen) MutateArg(arg0 Arg) (calls []*Call) {
 	return calls
 }
 
+// Builder constructs programs call by call, see builder.go for the high-level interface.
 type Builder struct {
 	target *Target
 	ma     *memAlloc
 	p      *Prog
+	// State of the high-level interface.
+	call   *CallBuilder          // call that is being built
+	vars   map[string]*ResultArg // named resources
+	consts map[string]uint64
+	err    error // first error, reported by Finalize
 }
 
 func MakeProgGen(target *Target) *Builder {
@@ -355,6 +361,10 @@ func (pg *Builder) AllocateVMA(npages uint64) uint64 {
 }
 
 func (pg *Builder) Finalize() (*Prog, error) {
+	pg.flush()
+	if pg.err != nil {
+		return nil, pg.err
+	}
 	if err := pg.p.validate(); err != nil {
 		return nil, err
 	}
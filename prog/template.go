@@ -0,0 +1,318 @@
+// Copyright 2026 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package prog
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+)
+
+// Program templates are programs with holes: arguments that are specified as ? in the program text, e.g.:
+//
+//	r0 = openat$kvm(0xffffffffffffff9c, &(0x7f0000000000)='/dev/kvm\x00', 0x0, 0x0)
+//	ioctl$KVM_CREATE_VM(r0, 0xae01, ?int[0:2])
+//	mmap(&(0x7f0000000000/0x1000)=nil, 0x1000, 0x3, 0x32, ?, 0x0)
+//
+// Deserialize turns holes into default arguments that are marked as holes,
+// GenerateFromTemplate fills holes with random values, and mutation of a template program
+// mutates only arguments inside of holes, while the call skeleton and the rest of arguments
+// stay fixed (see fixedArgs). Integer holes can be restricted to a range of values with ?int[begin:end].
+// Holes survive Clone and Serialize, so programs derived from a template are templates as well.
+// Serialize prints holes with their current values, e.g. ?int[0:2]=0x1, and Deserialize
+// restores both the holes and the values. Holes are meaningful only inside of the fuzzer
+// that mutates the template, programs are stripped of holes with WithoutHoles before they are
+// sent anywhere else (the manager, the corpus, execution logs), and are handled as normal programs there.
+
+// hole describes restrictions on values of a hole.
+type hole struct {
+	hasRange   bool
+	rangeBegin uint64
+	rangeEnd   uint64
+}
+
+// holeArg is a hole in a program.
+type holeArg struct {
+	call *Call
+	arg  Arg
+	ctx  ArgCtx
+	hole *hole
+}
+
+// IsTemplate returns true if the program contains holes.
+func (p *Prog) IsTemplate() bool {
+	return len(p.holeArgs()) != 0
+}
+
+// IsMutableTemplate returns true if the program contains holes that can be mutated
+// with the given set of IDs of syscalls that should not be mutated.
+func (p *Prog) IsMutableTemplate(noMutate map[int]bool) bool {
+	return len(p.mutableHoles(noMutate)) != 0
+}
+
+// WithoutHoles returns a clone of the template without holes, or the program itself if it's not a template.
+func (p *Prog) WithoutHoles() *Prog {
+	if len(p.holes) == 0 {
+		return p
+	}
+	p1 := &Prog{
+		Target: p.Target,
+		Calls:  cloneCalls(p.Calls, make(map[*ResultArg]*ResultArg)),
+	}
+	p1.debugValidate()
+	return p1
+}
+
+// GenerateFromTemplate returns a new program with holes of the template filled with random values.
+// The returned program is a template with the same holes, so mutations of it explore only the holes.
+func (target *Target) GenerateFromTemplate(rs rand.Source, tmpl *Prog, ct *ChoiceTable) *Prog {
+	if tmpl.Target != target {
+		panic("template of a different target")
+	}
+	p := tmpl.Clone()
+	r := newRand(target, rs)
+	for _, h := range p.holeArgs() {
+		p.fillHole(r, analyze(ct, nil, p, h.call), h)
+	}
+	p.sanitizeFix()
+	p.debugValidate()
+	return p
+}
+
+// holeArgs returns holes that are present in the program.
+// The holes map can contain stale arguments of removed calls, so it's not used directly.
+func (p *Prog) holeArgs() []holeArg {
+	if len(p.holes) == 0 {
+		return nil
+	}
+	var holes []holeArg
+	for _, c := range p.Calls {
+		ForeachArg(c, func(arg Arg, ctx *ArgCtx) {
+			if h := p.holes[arg]; h != nil {
+				holes = append(holes, holeArg{c, arg, *ctx, h})
+				ctx.Stop = true
+			}
+		})
+	}
+	return holes
+}
+
+// fillHole replaces the hole argument with a randomly generated value in place,
+// so that the hole stays marked.
+func (p *Prog) fillHole(r *randGen, s *state, h holeArg) {
+	var baseSize uint64
+	if h.ctx.Base != nil {
+		baseSize = h.ctx.Base.Res.Size()
+	}
+	newArg, calls := r.generateArg(s, h.arg.Type(), h.arg.Dir())
+	if _, ok := h.arg.(*ResultArg); !ok {
+		removeArg(h.arg)
+	}
+	if a, ok := h.arg.(*GroupArg); ok {
+		// Arrays can change size, so replaceArg can't be used.
+		a.Inner = newArg.(*GroupArg).Inner
+	} else {
+		replaceArg(h.arg, newArg)
+	}
+	h.hole.restrict(h.arg)
+	if base := h.ctx.Base; base != nil && baseSize < base.Res.Size() {
+		replaceArg(base, r.allocAddr(s, base.Type(), base.Dir(), base.Res.Size(), base.Res))
+	}
+	p.insertBefore(h.call, calls)
+	p.Target.assignSizesCall(h.call)
+}
+
+// restrict brings the hole argument value into the range of the hole.
+func (h *hole) restrict(arg Arg) {
+	a, ok := arg.(*ConstArg)
+	if !ok || !h.hasRange || a.Val >= h.rangeBegin && a.Val <= h.rangeEnd {
+		return
+	}
+	if n := h.rangeEnd - h.rangeBegin + 1; n != 0 {
+		a.Val = h.rangeBegin + a.Val%n
+	}
+}
+
+// mutateHole either fills a random hole anew, or mutates an argument inside of it.
+// Calls that are created to produce resources for the hole are inserted before the call,
+// but they are dropped if the program would get more than ncalls calls.
+func (ctx *mutator) mutateHole(holes []holeArg) bool {
+	p, r := ctx.p, ctx.r
+	if len(holes) == 0 {
+		return false
+	}
+	h := holes[r.Intn(len(holes))]
+	s := analyze(ctx.ct, ctx.corpus, p, h.call)
+	ncalls := len(p.Calls)
+	fixed := p.fixedArgs(ctx.noMutate)
+	inHole := make(map[Arg]bool)
+	ForeachSubArg(h.arg, func(arg Arg, _ *ArgCtx) {
+		inHole[arg] = !fixed[arg]
+	})
+	ma := &mutationArgs{target: p.Target}
+	ForeachArg(h.call, func(arg Arg, argCtx *ArgCtx) {
+		if inHole[arg] {
+			ma.collectArg(arg, argCtx)
+		}
+	})
+	var path string
+	if ctx.trace != nil {
+		path = argPath(h.call, h.arg)
+	}
+	if len(ma.args) == 0 || r.oneOf(10) {
+		p.fillHole(r, s, h)
+	} else {
+		arg, argCtx := ma.chooseArg(r.Rand)
+		updateSizes := true
+		calls, ok := p.Target.mutateArg(r, s, arg, argCtx, &updateSizes)
+		if !ok {
+			return false
+		}
+		h.hole.restrict(h.arg)
+		p.insertBefore(h.call, calls)
+		if updateSizes {
+			p.Target.assignSizesCall(h.call)
+		}
+	}
+	for idx := p.callIndex(h.call) - 1; len(p.Calls) > ctx.ncalls && len(p.Calls) > ncalls; idx-- {
+		p.RemoveCall(idx)
+	}
+	ctx.trace.record(func() TraceOp {
+		return TraceOp{Op: MutationMutateArg.String(), Call: p.callIndex(h.call), Syscall: h.call.Meta.Name, Arg: path}
+	})
+	return true
+}
+
+// fixedArgs returns arguments of the template that must not be mutated: all arguments outside of holes
+// and all arguments of noMutate calls, i.e. noMutate applied to templates at argument granularity.
+func (p *Prog) fixedArgs(noMutate map[int]bool) map[Arg]bool {
+	fixed := make(map[Arg]bool)
+	for _, c := range p.Calls {
+		ForeachArg(c, func(arg Arg, ctx *ArgCtx) {
+			if p.holes[arg] != nil && !noMutate[c.Meta.ID] {
+				ctx.Stop = true
+				return
+			}
+			fixed[arg] = true
+		})
+	}
+	return fixed
+}
+
+// mutableHoles returns holes that are not fixed.
+func (p *Prog) mutableHoles(noMutate map[int]bool) []holeArg {
+	holes := p.holeArgs()
+	if len(holes) == 0 {
+		return nil
+	}
+	fixed := p.fixedArgs(noMutate)
+	var res []holeArg
+	for _, h := range holes {
+		if !fixed[h.arg] {
+			res = append(res, h)
+		}
+	}
+	return res
+}
+
+// cloneHoles marks holes in p1, which must be a clone of p.
+func cloneHoles(p, p1 *Prog) {
+	p1.holes = make(map[Arg]*hole)
+	for i, c := range p.Calls {
+		var args []Arg
+		ForeachArg(c, func(arg Arg, _ *ArgCtx) {
+			args = append(args, arg)
+		})
+		j := 0
+		ForeachArg(p1.Calls[i], func(arg Arg, _ *ArgCtx) {
+			if h := p.holes[args[j]]; h != nil {
+				p1.holes[arg] = h
+			}
+			j++
+		})
+	}
+}
+
+func (p *parser) parseHole(typ Type, dir Dir) (Arg, error) {
+	p.Parse('?')
+	h := new(hole)
+	if !p.EOF() && p.Char() == 'i' {
+		if kind := p.Ident(); kind != "int" {
+			return nil, fmt.Errorf("unknown hole kind %v", kind)
+		}
+		if _, ok := typ.(*IntType); !ok {
+			return nil, fmt.Errorf("int hole for %v argument", typ)
+		}
+		p.Parse('[')
+		begin := p.Ident()
+		p.Parse(':')
+		end := p.Ident()
+		p.Parse(']')
+		var err error
+		if h.rangeBegin, err = parseHoleBound(begin); err != nil {
+			return nil, err
+		}
+		if h.rangeEnd, err = parseHoleBound(end); err != nil {
+			return nil, err
+		}
+		if h.rangeBegin > h.rangeEnd {
+			return nil, fmt.Errorf("bad int hole range [%v:%v]", begin, end)
+		}
+		h.hasRange = true
+	}
+	switch typ.(type) {
+	case *ConstType, *LenType, *CsumType:
+		return nil, fmt.Errorf("hole for %v argument that is computed automatically", typ)
+	}
+	arg := typ.DefaultArg(dir)
+	if !p.EOF() && p.Char() == '=' {
+		// Serialized template with the current value of the hole.
+		p.Parse('=')
+		var err error
+		if arg, err = p.parseArg(typ, dir); err != nil {
+			return nil, err
+		}
+	}
+	if p.holes == nil {
+		p.holes = make(map[Arg]*hole)
+	}
+	p.holes[arg] = h
+	return arg, nil
+}
+
+func (h *hole) serialize(ctx *serializer) {
+	ctx.printf("?")
+	if h.hasRange {
+		ctx.printf("int[0x%x:0x%x]", h.rangeBegin, h.rangeEnd)
+	}
+	ctx.printf("=")
+}
+
+func parseHoleBound(s string) (uint64, error) {
+	v, err := strconv.ParseUint(s, 0, 64)
+	if err != nil {
+		return 0, fmt.Errorf("wrong int hole bound '%v': %w", s, err)
+	}
+	return v, nil
+}
+
+// hintHoles returns the set of arguments of the call that are inside of holes
+// and can be mutated with hints, or nil if the program is not a template.
+// Integer holes with a range are not mutated with hints since hints would break the range.
+func (p *Prog) hintHoles(c *Call) map[Arg]bool {
+	holes := p.holeArgs()
+	if len(holes) == 0 {
+		return nil
+	}
+	res := make(map[Arg]bool)
+	for _, h := range holes {
+		if h.call != c || h.hole.hasRange {
+			continue
+		}
+		ForeachSubArg(h.arg, func(arg Arg, _ *ArgCtx) {
+			res[arg] = true
+		})
+	}
+	return res
+}
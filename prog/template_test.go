@@ -0,0 +1,188 @@
+// Copyright 2026 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package prog
+
+import (
+	"bytes"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestTemplateParse(t *testing.T) {
+	target, err := GetTarget("test", "64")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		tmpl string
+		err  string
+	}{
+		{
+			tmpl: "test$int(?, ?int[0:16], 0x0, ?int[0x10:0x10], 0x0)\n",
+		},
+		{
+			tmpl: "test$blob0(?)\ntest$struct(&(0x7f0000000000)={?, ?})\n",
+		},
+		{
+			tmpl: "test$int(?int[2:1], 0x0, 0x0, 0x0, 0x0)\n",
+			err:  "bad int hole range [2:1]",
+		},
+		{
+			tmpl: "test$int(?int[0:foo], 0x0, 0x0, 0x0, 0x0)\n",
+			err:  "wrong int hole bound 'foo'",
+		},
+		{
+			tmpl: "test$int(?intptr, 0x0, 0x0, 0x0, 0x0)\n",
+			err:  "unknown hole kind intptr",
+		},
+		{
+			tmpl: "test$blob0(?int[0:1])\n",
+			err:  "int hole for",
+		},
+	}
+	for i, test := range tests {
+		p, err := target.Deserialize([]byte(test.tmpl), NonStrict)
+		if test.err != "" {
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Fatalf("#%v: got error %v, want %q", i, err, test.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("#%v: failed to parse template: %v", i, err)
+		}
+		if !p.IsTemplate() || !p.Clone().IsTemplate() {
+			t.Fatalf("#%v: parsed program is not a template", i)
+		}
+		data := p.Serialize()
+		p1, err := target.Deserialize(data, Strict)
+		if err != nil {
+			t.Fatalf("#%v: failed to parse serialized template: %v\n%s", i, err, data)
+		}
+		if !p1.IsTemplate() || len(p1.holeArgs()) != len(p.holeArgs()) {
+			t.Fatalf("#%v: serialized template lost holes:\n%s", i, data)
+		}
+		if data1 := p1.Serialize(); !bytes.Equal(data, data1) {
+			t.Fatalf("#%v: serialized template changed:\n%s\nvs:\n%s", i, data, data1)
+		}
+	}
+}
+
+func TestTemplateSerialize(t *testing.T) {
+	target, rs, iters := initRandomTargetTest(t, "test", "64")
+	p0, err := target.Deserialize([]byte("test$int(?int[3:10], 0x1, ?, 0x3, 0x4)\ntest$blob0(?)\n"), NonStrict)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ct := target.DefaultChoiceTable()
+	for i := 0; i < iters; i++ {
+		p := target.GenerateFromTemplate(rs, p0, ct)
+		p.Mutate(rs, 10, ct, nil, nil)
+		data := p.Serialize()
+		p1, err := target.Deserialize(data, Strict)
+		if err != nil {
+			t.Fatalf("failed to parse serialized template: %v\n%s", err, data)
+		}
+		if !p1.IsTemplate() || !bytes.Equal(data, p1.Serialize()) {
+			t.Fatalf("template does not survive serialization:\n%s\nvs:\n%s", data, p1.Serialize())
+		}
+		if v := p1.Calls[len(p1.Calls)-2].Args[0].(*ConstArg).Val; v < 3 || v > 10 {
+			t.Fatalf("int hole value %v is out of range:\n%s", v, data)
+		}
+	}
+}
+
+func TestTemplateNoMutate(t *testing.T) {
+	target, rs, iters := initRandomTargetTest(t, "test", "64")
+	p0, err := target.Deserialize([]byte("test$int(?, 0x1, 0x2, 0x3, 0x4)\ntest$blob0(?)\n"), NonStrict)
+	if err != nil {
+		t.Fatal(err)
+	}
+	intCall, blobCall := target.SyscallMap["test$int"], target.SyscallMap["test$blob0"]
+	if !p0.IsMutableTemplate(map[int]bool{intCall.ID: true}) ||
+		p0.IsMutableTemplate(map[int]bool{intCall.ID: true, blobCall.ID: true}) {
+		t.Fatalf("bad mutable holes")
+	}
+	ct := target.DefaultChoiceTable()
+	noMutate := map[int]bool{intCall.ID: true}
+	for i := 0; i < iters; i++ {
+		p := target.GenerateFromTemplate(rs, p0, ct)
+		val := p.Calls[0].Args[0].(*ConstArg).Val
+		p.Mutate(rs, 10, ct, noMutate, nil)
+		if p.Calls[0].Meta != intCall || p.Calls[0].Args[0].(*ConstArg).Val != val {
+			t.Fatalf("mutation changed a hole of noMutate call:\n%s", p.Serialize())
+		}
+	}
+}
+
+func TestTemplateMinimize(t *testing.T) {
+	target, err := GetTarget("test", "64")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p0, err := target.Deserialize([]byte("r0 = test$res0()\ntest$int(?, 0x1, 0x2, 0x3, 0x4)\ntest$res1(r0)\n"), NonStrict)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p0.Calls[1].Args[0].(*ConstArg).Val = 42
+	p := p0.WithoutHoles()
+	if p.IsTemplate() || !p0.IsTemplate() {
+		t.Fatalf("holes are not stripped from a clone of the template")
+	}
+	if data := p.Serialize(); !bytes.Contains(data, []byte("\ntest$int(0x2a, 0x1, 0x2, 0x3, 0x4)\n")) {
+		t.Fatalf("program without holes is serialized as:\n%s", data)
+	}
+	// Programs stripped of holes are minimized as normal programs.
+	p1, _ := Minimize(p, 1, false, func(p *Prog, call int) bool {
+		return true
+	})
+	if len(p1.Calls) != 1 || p1.Calls[0].Meta.Name != "test$int" {
+		t.Fatalf("program is not minimized:\n%s", p1.Serialize())
+	}
+}
+
+func TestTemplateMutate(t *testing.T) {
+	target, rs, iters := initRandomTargetTest(t, "test", "64")
+	const tmpl = `
+r0 = test$res0()
+test$int(?int[3:10], 0x1, ?, 0x3, 0x4)
+test$blob0(?)
+test$res1(r0)
+`
+	p0, err := target.Deserialize([]byte(tmpl), NonStrict)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ct := target.DefaultChoiceTable()
+	r := rand.New(rs)
+	progs := make(map[string]bool)
+	for i := 0; i < iters; i++ {
+		p := target.GenerateFromTemplate(rs, p0, ct)
+		for j := r.Intn(10); j >= 0; j-- {
+			p.Mutate(rs, 10, ct, nil, nil)
+			if !p.IsTemplate() {
+				t.Fatalf("mutated program is not a template:\n%s", p.Serialize())
+			}
+		}
+		if len(p.Calls) != 4 || p.Calls[0].Meta.Name != "test$res0" || p.Calls[1].Meta.Name != "test$int" ||
+			p.Calls[2].Meta.Name != "test$blob0" || p.Calls[3].Meta.Name != "test$res1" {
+			t.Fatalf("mutation changed calls:\n%s", p.Serialize())
+		}
+		args := p.Calls[1].Args
+		if v := args[0].(*ConstArg).Val; v < 3 || v > 10 {
+			t.Fatalf("int hole value %v is out of range:\n%s", v, p.Serialize())
+		}
+		if args[1].(*ConstArg).Val != 1 || args[3].(*ConstArg).Val != 3 || args[4].(*ConstArg).Val != 4 {
+			t.Fatalf("mutation changed fixed arguments:\n%s", p.Serialize())
+		}
+		if res := p.Calls[3].Args[0].(*ResultArg); res.Res != p.Calls[0].Ret {
+			t.Fatalf("mutation changed fixed resource:\n%s", p.Serialize())
+		}
+		progs[string(p.Serialize())] = true
+	}
+	if len(progs) < iters/2 {
+		t.Fatalf("holes are not mutated: %v distinct programs in %v iterations", len(progs), iters)
+	}
+}
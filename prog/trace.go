@@ -0,0 +1,365 @@
+// Copyright 2026 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package prog
+
+import (
+	"fmt"
+	"math/rand"
+
+	"github.com/google/syzkaller/pkg/hash"
+	"github.com/google/syzkaller/pkg/image"
+)
+
+// Trace describes how a program was derived: generated from scratch, mutated from a parent program,
+// or produced from a parent program with a comparison hint.
+// Traces are self-contained: operations that depend on the choice table or the corpus
+// (generation, splicing, insertion of calls, mutation of arguments) record the resulting program,
+// and call removals and hints are recorded precisely enough to be applied directly,
+// so Replay needs only the parent program.
+// Holes of templates are not part of traces, programs are recorded without them.
+type Trace struct {
+	Kind    string    `json:"kind"`
+	Seed    int64     `json:"seed,omitempty"`    // informational, the seed of the random source
+	NCalls  int       `json:"ncalls,omitempty"`  // informational
+	Weights []int     `json:"weights,omitempty"` // mutation operator weights, if a scheduler was used
+	Parent  string    `json:"parent,omitempty"`  // hash of the parent program
+	Child   string    `json:"child"`             // hash of the resulting program
+	Ops     []TraceOp `json:"ops"`
+	// Minimization of the resulting program, if it was minimized afterwards.
+	Minimization *TraceMinimization `json:"minimization,omitempty"`
+}
+
+// TraceMinimization describes minimization of a program with Minimize (crash=false).
+// Minimization is deterministic given the answers of the predicate, so they are enough to replay it.
+type TraceMinimization struct {
+	Call    int    `json:"call"`    // the call index passed to Minimize
+	Results []bool `json:"results"` // answers of the predicate in the order of the queries
+	Child   string `json:"child"`   // hash of the minimized program
+}
+
+const (
+	TraceGenerate = "generate"
+	TraceMutate   = "mutate"
+	TraceHints    = "hints"
+)
+
+// TraceOp is a single operation applied to a program.
+type TraceOp struct {
+	Op string `json:"op"` // "generate", "hint" or name of the MutationOp
+	// Index of the affected call at the time of the operation, and its name.
+	Call    int    `json:"call"`
+	Syscall string `json:"syscall,omitempty"`
+	// Path of the affected argument in the call, e.g. "*addr.sin_port" (see argPaths).
+	Arg string `json:"arg,omitempty"`
+	// Hints: new value of an integer argument, or new bytes at offset of a data argument
+	// (for compressed data arguments the offset refers to the decompressed data).
+	Value  uint64 `json:"value,omitempty"`
+	Offset int    `json:"offset,omitempty"`
+	Data   []byte `json:"data,omitempty"`
+	// Splice: hash of the corpus program that was spliced in.
+	Spliced string `json:"spliced,omitempty"`
+	// The program after the operation, recorded for operations that can't be applied directly.
+	// Operations that are followed by other operations of the same mutation step (e.g. several arguments
+	// of a call mutated in a row) record only the program after the last of them.
+	Prog string `json:"prog,omitempty"`
+}
+
+// record appends the operation to the trace, op is called only if tracing is enabled.
+func (trace *Trace) record(op func() TraceOp) {
+	if trace != nil {
+		trace.Ops = append(trace.Ops, op())
+	}
+}
+
+// recordProg records p as the result of the last operation, if operations after the first one
+// were recorded and the last of them can't be applied directly.
+func (trace *Trace) recordProg(p *Prog, first int) {
+	if trace == nil || len(trace.Ops) <= first {
+		return
+	}
+	if op := &trace.Ops[len(trace.Ops)-1]; op.Op != MutationRemoveCall.String() {
+		op.Prog = serializeTraced(p)
+	}
+}
+
+func serializeTraced(p *Prog) string {
+	return string(p.WithoutHoles().Serialize())
+}
+
+func progHash(p *Prog) string {
+	return hash.String([]byte(serializeTraced(p)))
+}
+
+// GenerateTraced is the same as Generate, but uses a random source seeded with seed
+// and returns the trace of the generation.
+func (target *Target) GenerateTraced(seed int64, ncalls int, ct *ChoiceTable) (*Prog, *Trace) {
+	p := target.Generate(rand.NewSource(seed), ncalls, ct)
+	trace := &Trace{
+		Kind:   TraceGenerate,
+		Seed:   seed,
+		NCalls: ncalls,
+		Child:  progHash(p),
+	}
+	for i, c := range p.Calls {
+		trace.Ops = append(trace.Ops, TraceOp{Op: TraceGenerate, Call: i, Syscall: c.Meta.Name})
+	}
+	trace.recordProg(p, 0)
+	return p, trace
+}
+
+// MutateTraced is the same as MutateWithScheduler (sched may be nil), but uses a random source
+// seeded with seed and additionally returns the trace of the mutation.
+func (p *Prog) MutateTraced(seed int64, ncalls int, ct *ChoiceTable, noMutate map[int]bool,
+	corpus []*Prog, sched MutationScheduler) (Mutation, *Trace) {
+	trace := &Trace{
+		Kind:   TraceMutate,
+		Seed:   seed,
+		NCalls: ncalls,
+		Parent: progHash(p),
+	}
+	if sched != nil {
+		trace.Weights = append([]int{}, sched.Weights()...)
+		if len(trace.Weights) != int(MutationOpCount) {
+			panic(fmt.Sprintf("bad number of mutation weights: %v, want %v", len(trace.Weights), MutationOpCount))
+		}
+	}
+	mutation := p.mutate(rand.NewSource(seed), ncalls, ct, noMutate, corpus, trace.Weights, trace)
+	if len(trace.Ops) != 0 {
+		// Mutation fixes up the program after the last operation.
+		trace.Ops[len(trace.Ops)-1].Prog = serializeTraced(p)
+	}
+	trace.Child = progHash(p)
+	return mutation, trace
+}
+
+// MutateWithHintsTraced is the same as MutateWithHints, but also passes the trace of each mutant to exec.
+func (p *Prog) MutateWithHintsTraced(callIndex int, comps CompMap, exec func(p *Prog, trace *Trace)) {
+	parent := progHash(p)
+	origArgs := argsByPath(p.Calls[callIndex])
+	var paths map[Arg]string
+	p.mutateWithHints(callIndex, comps, func(p1 *Prog, arg Arg) {
+		c := p1.Calls[callIndex]
+		if paths == nil {
+			// Hints change only values of arguments, so the paths stay the same.
+			paths = argPaths(c)
+		}
+		op := TraceOp{
+			Op:      "hint",
+			Call:    callIndex,
+			Syscall: c.Meta.Name,
+			Arg:     paths[arg],
+		}
+		switch a := arg.(type) {
+		case *ConstArg:
+			op.Value = a.Val
+		case *DataArg:
+			orig, data := origArgs[op.Arg].(*DataArg).Data(), a.Data()
+			if a.Type().(*BufferType).Kind == BufferCompressed {
+				var dtor, dtor1 func()
+				orig, dtor = image.MustDecompress(orig)
+				data, dtor1 = image.MustDecompress(data)
+				defer dtor()
+				defer dtor1()
+			}
+			op.Offset, op.Data = diffData(orig, data)
+		}
+		exec(p1, &Trace{
+			Kind:   TraceHints,
+			Parent: parent,
+			Child:  progHash(p1),
+			Ops:    []TraceOp{op},
+		})
+	})
+}
+
+// MinimizeTraced is the same as Minimize with crash=false, but additionally returns a copy
+// of the trace of p0 extended with the minimization, so that it describes the minimized program.
+func MinimizeTraced(p0 *Prog, callIndex0 int, trace *Trace, pred func(*Prog, int) bool) (*Prog, int, *Trace) {
+	minimization := &TraceMinimization{Call: callIndex0}
+	p, callIndex := Minimize(p0, callIndex0, false, func(p1 *Prog, callIndex1 int) bool {
+		res := pred(p1, callIndex1)
+		minimization.Results = append(minimization.Results, res)
+		return res
+	})
+	minimization.Child = progHash(p)
+	trace1 := *trace
+	trace1.Minimization = minimization
+	return p, callIndex, &trace1
+}
+
+// diffData returns the offset and contents of the range of data that differs from orig
+// (data is assumed to be of the same size).
+func diffData(orig, data []byte) (int, []byte) {
+	start, end := 0, len(data)
+	for start < end && orig[start] == data[start] {
+		start++
+	}
+	for end > start && orig[end-1] == data[end-1] {
+		end--
+	}
+	return start, append([]byte{}, data[start:end]...)
+}
+
+// Replay reproduces the program described by the trace.
+// Parent is required for mutation and hint traces.
+// If the replayed program diverges from the trace, the error describes the first difference,
+// the replayed program is returned in this case as well.
+func (target *Target) Replay(trace *Trace, parent *Prog) (*Prog, error) {
+	var p *Prog
+	switch trace.Kind {
+	case TraceGenerate:
+	case TraceMutate, TraceHints:
+		if parent == nil {
+			return nil, fmt.Errorf("%v trace requires the parent program", trace.Kind)
+		}
+		if h := progHash(parent); h != trace.Parent {
+			return nil, fmt.Errorf("parent program hash %v does not match the trace parent %v", h, trace.Parent)
+		}
+		p = parent.WithoutHoles().Clone()
+	default:
+		return nil, fmt.Errorf("unknown trace kind %q", trace.Kind)
+	}
+	for i, op := range trace.Ops {
+		var err error
+		switch {
+		case trace.Kind == TraceHints:
+			err = p.applyHint(op)
+		case op.Prog != "":
+			p, err = target.Deserialize([]byte(op.Prog), NonStrict)
+		case op.Op == MutationRemoveCall.String():
+			err = p.applyRemoveCall(op)
+		}
+		if err != nil {
+			return p, fmt.Errorf("operation #%v: %w", i, err)
+		}
+	}
+	if p == nil {
+		return nil, fmt.Errorf("the trace does not record the generated program")
+	}
+	if h := progHash(p); h != trace.Child {
+		return p, fmt.Errorf("replayed program hash %v does not match the trace child %v", h, trace.Child)
+	}
+	if trace.Minimization != nil {
+		return replayMinimization(p, trace.Minimization)
+	}
+	return p, nil
+}
+
+func replayMinimization(p *Prog, minimization *TraceMinimization) (*Prog, error) {
+	if minimization.Call < -1 || minimization.Call >= len(p.Calls) {
+		return p, fmt.Errorf("bad minimization call index %v", minimization.Call)
+	}
+	queries := 0
+	p, _ = Minimize(p, minimization.Call, false, func(*Prog, int) bool {
+		queries++
+		return queries <= len(minimization.Results) && minimization.Results[queries-1]
+	})
+	if queries != len(minimization.Results) {
+		return p, fmt.Errorf("minimization diverged: %v queries, the trace has %v", queries, len(minimization.Results))
+	}
+	if h := progHash(p); h != minimization.Child {
+		return p, fmt.Errorf("minimized program hash %v does not match the trace minimized child %v", h, minimization.Child)
+	}
+	return p, nil
+}
+
+func (p *Prog) applyRemoveCall(op TraceOp) error {
+	if p == nil || op.Call < 0 || op.Call >= len(p.Calls) || p.Calls[op.Call].Meta.Name != op.Syscall {
+		return fmt.Errorf("bad remove operation %+v", op)
+	}
+	p.RemoveCall(op.Call)
+	return nil
+}
+
+func (p *Prog) applyHint(op TraceOp) error {
+	if op.Op != "hint" || op.Call < 0 || op.Call >= len(p.Calls) || p.Calls[op.Call].Meta.Name != op.Syscall {
+		return fmt.Errorf("bad hint operation %+v", op)
+	}
+	c := p.Calls[op.Call]
+	switch arg := argsByPath(c)[op.Arg].(type) {
+	case *ConstArg:
+		arg.Val = op.Value
+	case *DataArg:
+		data := arg.Data()
+		compressed := arg.Type().(*BufferType).Kind == BufferCompressed
+		if compressed {
+			var dtor func()
+			data, dtor = image.MustDecompress(data)
+			defer dtor()
+		}
+		if op.Offset+len(op.Data) > len(data) {
+			return fmt.Errorf("hint data out of bounds: %v+%v, data size %v", op.Offset, len(op.Data), len(data))
+		}
+		data = append([]byte{}, data...)
+		copy(data[op.Offset:], op.Data)
+		if compressed {
+			data = image.Compress(data)
+		}
+		arg.SetData(data)
+	default:
+		return fmt.Errorf("no integer or data argument %q in %v", op.Arg, c.Meta.Name)
+	}
+	if err := p.Target.sanitize(c, false); err != nil {
+		return err
+	}
+	return nil
+}
+
+// argPaths returns human-readable paths of all arguments of the call:
+// syscall argument names and struct and union field names separated with dots, array indices
+// (and indices of unnamed fields) in brackets, pointer targets are denoted with a "*" prefix,
+// e.g. "*addr.sin_port" or "**msg.vec[1].len".
+func argPaths(c *Call) map[Arg]string {
+	paths := make(map[Arg]string)
+	var walk func(arg Arg, path string)
+	walk = func(arg Arg, path string) {
+		paths[arg] = path
+		switch a := arg.(type) {
+		case *GroupArg:
+			typ, isStruct := a.Type().(*StructType)
+			for i, inner := range a.Inner {
+				if isStruct && typ.Fields[i].Name != "" {
+					walk(inner, path+"."+typ.Fields[i].Name)
+				} else {
+					walk(inner, fmt.Sprintf("%v[%v]", path, i))
+				}
+			}
+		case *PointerArg:
+			if a.Res != nil {
+				walk(a.Res, "*"+path)
+			}
+		case *UnionArg:
+			walk(a.Option, path+"."+a.Type().(*UnionType).Fields[a.Index].Name)
+		}
+	}
+	if c.Ret != nil {
+		walk(c.Ret, "ret")
+	}
+	for i, arg := range c.Args {
+		walk(arg, c.Meta.Args[i].Name)
+	}
+	return paths
+}
+
+func argPath(c *Call, arg Arg) string {
+	return argPaths(c)[arg]
+}
+
+func argsByPath(c *Call) map[string]Arg {
+	args := make(map[string]Arg)
+	for arg, path := range argPaths(c) {
+		args[path] = arg
+	}
+	return args
+}
+
+// callIndex returns index of the call in the program.
+func (p *Prog) callIndex(c *Call) int {
+	for i, c1 := range p.Calls {
+		if c1 == c {
+			return i
+		}
+	}
+	panic("call is not in the program")
+}
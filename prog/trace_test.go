@@ -0,0 +1,173 @@
+// Copyright 2026 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package prog
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/rand"
+	"testing"
+)
+
+func TestTraceReplay(t *testing.T) {
+	target, rs, iters := initTest(t)
+	ct := target.DefaultChoiceTable()
+	r := rand.New(rs)
+	var corpus []*Prog
+	for i := 0; i < 20; i++ {
+		corpus = append(corpus, target.Generate(rs, 10, ct))
+	}
+	replay := func(p *Prog, trace *Trace, parent *Prog) {
+		// Traces are stored as JSON, so check that they survive serialization.
+		data, err := json.Marshal(trace)
+		if err != nil {
+			t.Fatal(err)
+		}
+		trace = new(Trace)
+		if err := json.Unmarshal(data, trace); err != nil {
+			t.Fatal(err)
+		}
+		// Replay does not need the choice table and the corpus used during recording.
+		p1, err := target.Replay(trace, parent)
+		if err != nil {
+			t.Fatalf("replay failed: %v\ntrace: %s", err, data)
+		}
+		if got, want := p1.Serialize(), p.Serialize(); !bytes.Equal(got, want) {
+			t.Fatalf("replayed program differs:\n%s\nwant:\n%s", got, want)
+		}
+	}
+	for i := 0; i < iters/10+1; i++ {
+		p, trace := target.GenerateTraced(r.Int63(), 10, ct)
+		if len(trace.Ops) != len(p.Calls) {
+			t.Fatalf("generation trace has %v ops for %v calls", len(trace.Ops), len(p.Calls))
+		}
+		replay(p, trace, nil)
+		var sched MutationScheduler
+		if i%2 == 0 {
+			sched = testMutationScheduler(DefaultMutationWeights)
+		}
+		noMutate := map[int]bool{p.Calls[0].Meta.ID: true}
+		p1 := p.Clone()
+		mutation, trace := p1.MutateTraced(r.Int63(), 10, ct, noMutate, corpus, sched)
+		if len(mutation.Ops) == 0 || len(trace.Ops) < len(mutation.Ops) {
+			t.Fatalf("mutation trace has %v ops for %v mutations", len(trace.Ops), len(mutation.Ops))
+		}
+		replay(p1, trace, p)
+		if _, err := target.Replay(trace, corpus[0]); err == nil {
+			t.Fatalf("no error for a wrong parent")
+		}
+	}
+}
+
+func TestTraceTemplate(t *testing.T) {
+	target, rs, iters := initRandomTargetTest(t, "test", "64")
+	p0, err := target.Deserialize([]byte("test$int(?, 0x1, 0x2, 0x3, 0x4)\ntest$blob0(?)\n"), NonStrict)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ct := target.DefaultChoiceTable()
+	r := rand.New(rs)
+	for i := 0; i < iters/10+1; i++ {
+		p := target.GenerateFromTemplate(rs, p0, ct)
+		p1 := p.Clone()
+		_, trace := p1.MutateTraced(r.Int63(), 10, ct, nil, nil, nil)
+		// Templates leave the fuzzer without holes, so that's what traces are replayed from.
+		p2, err := target.Replay(trace, p.WithoutHoles())
+		if err != nil {
+			t.Fatalf("replay failed: %v\ntrace: %+v", err, trace)
+		}
+		if got, want := p2.Serialize(), p1.WithoutHoles().Serialize(); !bytes.Equal(got, want) {
+			t.Fatalf("replayed program differs:\n%s\nwant:\n%s", got, want)
+		}
+	}
+}
+
+func TestTraceMinimize(t *testing.T) {
+	target, rs, iters := initTest(t)
+	ct := target.DefaultChoiceTable()
+	r := rand.New(rs)
+	for i := 0; i < iters/10+1; i++ {
+		p, trace := target.GenerateTraced(r.Int63(), 10, ct)
+		// Accept random simplifications, this is what replay cannot reproduce without the trace.
+		seed := r.Int63()
+		pred := rand.New(rand.NewSource(seed))
+		p1, _, trace1 := MinimizeTraced(p, len(p.Calls)-1, trace, func(*Prog, int) bool {
+			return pred.Intn(2) == 0
+		})
+		if trace.Minimization != nil {
+			t.Fatalf("the original trace is changed")
+		}
+		data, err := json.Marshal(trace1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		trace1 = new(Trace)
+		if err := json.Unmarshal(data, trace1); err != nil {
+			t.Fatal(err)
+		}
+		p2, err := target.Replay(trace1, nil)
+		if err != nil {
+			t.Fatalf("replay failed: %v\ntrace: %s", err, data)
+		}
+		if got, want := p2.Serialize(), p1.Serialize(); !bytes.Equal(got, want) {
+			t.Fatalf("replayed program differs:\n%s\nwant:\n%s", got, want)
+		}
+		if results := trace1.Minimization.Results; len(results) != 0 {
+			trace1.Minimization.Results = results[:len(results)-1]
+			if _, err := target.Replay(trace1, nil); err == nil {
+				t.Fatalf("no error for wrong minimization results")
+			}
+		}
+	}
+}
+
+func TestTraceHints(t *testing.T) {
+	target, rs, iters := initTest(t)
+	ct := target.DefaultChoiceTable()
+	mutants := 0
+	for i := 0; i < iters/10+1; i++ {
+		p := target.Generate(rs, 5, ct)
+		for callIndex, c := range p.Calls {
+			comps := make(CompMap)
+			ForeachArg(c, func(arg Arg, _ *ArgCtx) {
+				// A few comparisons are enough, every comparison can produce lots of mutants.
+				if a, ok := arg.(*ConstArg); ok && len(comps) < 3 {
+					comps.AddComp(a.Val, a.Val+1)
+				}
+			})
+			callMutants := 0
+			p.MutateWithHintsTraced(callIndex, comps, func(p1 *Prog, trace *Trace) {
+				// Data arguments can produce lots of mutants, checking some of them is enough.
+				if callMutants++; callMutants > 50 {
+					return
+				}
+				mutants++
+				p2, err := target.Replay(trace, p)
+				if err != nil {
+					t.Fatalf("replay failed: %v\ntrace: %+v", err, trace)
+				}
+				if got, want := p2.Serialize(), p1.Serialize(); !bytes.Equal(got, want) {
+					t.Fatalf("replayed program differs:\n%s\nwant:\n%s", got, want)
+				}
+			})
+		}
+	}
+	if mutants == 0 {
+		t.Fatalf("no hint mutants")
+	}
+}
+
+func TestArgPaths(t *testing.T) {
+	target, rs, iters := initTest(t)
+	ct := target.DefaultChoiceTable()
+	for i := 0; i < iters/10+1; i++ {
+		p := target.Generate(rs, 10, ct)
+		for _, c := range p.Calls {
+			paths := argPaths(c)
+			if byPath := argsByPath(c); len(byPath) != len(paths) {
+				t.Fatalf("%v: paths are not unique: %v", c.Meta.Name, paths)
+			}
+		}
+	}
+}
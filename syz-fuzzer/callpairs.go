@@ -0,0 +1,108 @@
+// Copyright 2026 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"sync"
+
+	"github.com/google/syzkaller/pkg/log"
+	"github.com/google/syzkaller/pkg/rpctype"
+	"github.com/google/syzkaller/prog"
+)
+
+// callPairLearner accumulates outcomes of call insertions done by the insert call mutation:
+// which call was inserted, which call was used as the bias, and whether the mutant gave a new input.
+// The manager aggregates the outcomes from all fuzzers and turns them into call priors.
+type callPairLearner struct {
+	mu    sync.Mutex
+	pairs map[prog.CallPair]*rpctype.CallPairStats
+}
+
+func newCallPairLearner() *callPairLearner {
+	return &callPairLearner{
+		pairs: make(map[prog.CallPair]*rpctype.CallPairStats),
+	}
+}
+
+func (l *callPairLearner) inserted(pairs []prog.CallPair) {
+	if len(pairs) == 0 {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, pair := range pairs {
+		l.get(pair).Inserts++
+	}
+}
+
+func (l *callPairLearner) newInput(pairs []prog.CallPair) {
+	if len(pairs) == 0 {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, pair := range pairs {
+		l.get(pair).NewInputs++
+	}
+}
+
+func (l *callPairLearner) get(pair prog.CallPair) *rpctype.CallPairStats {
+	stats := l.pairs[pair]
+	if stats == nil {
+		stats = &rpctype.CallPairStats{Prev: pair.Prev, Next: pair.Next}
+		l.pairs[pair] = stats
+	}
+	return stats
+}
+
+// collect returns outcomes accumulated since the previous call.
+func (l *callPairLearner) collect() []rpctype.CallPairStats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	res := make([]rpctype.CallPairStats, 0, len(l.pairs))
+	for _, stats := range l.pairs {
+		res = append(res, *stats)
+	}
+	l.pairs = make(map[prog.CallPair]*rpctype.CallPairStats)
+	return res
+}
+
+// makePriors returns the call priors blended into the base choice table.
+func (fuzzer *Fuzzer) makePriors() []*prog.CallPriors {
+	var priors []*prog.CallPriors
+	if fuzzer.cfgPriors != nil {
+		priors = append(priors, fuzzer.cfgPriors)
+	}
+	if fuzzer.learnedWeights != nil {
+		learned, err := fuzzer.target.MakeCallPriors(fuzzer.learnedWeights, fuzzer.learnedFactor)
+		if err != nil {
+			log.SyzFatalf("failed to create learned call priors: %v", err)
+		}
+		priors = append(priors, learned)
+	}
+	return priors
+}
+
+// updateLearnedPriors rebuilds the base choice table with the learned priors received from the manager
+// (nil means that the priors have not changed). Focus and slow call choice tables pick up
+// the new priors when they are rebuilt next time.
+func (fuzzer *Fuzzer) updateLearnedPriors(weights map[string]map[string]float64) {
+	if weights == nil {
+		return
+	}
+	fuzzer.learnedWeights = weights
+	if fuzzer.choiceTable == nil {
+		// The priors are applied once the fuzzer builds its choice table.
+		return
+	}
+	priors := fuzzer.makePriors()
+	fuzzer.corpusMu.RLock()
+	corpus := fuzzer.corpus
+	fuzzer.corpusMu.RUnlock()
+	ct := fuzzer.target.BuildChoiceTableWithPriors(corpus, fuzzer.enabledCalls(), priors...)
+	fuzzer.ctMu.Lock()
+	fuzzer.priors, fuzzer.choiceTable = priors, ct
+	fuzzer.ctMu.Unlock()
+	log.Logf(0, "updated learned call priors for %v calls", len(weights))
+}
@@ -0,0 +1,96 @@
+// Copyright 2026 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"math/bits"
+	"sync"
+	"time"
+
+	"github.com/google/syzkaller/pkg/ipc"
+	"github.com/google/syzkaller/pkg/rpctype"
+	"github.com/google/syzkaller/prog"
+	"github.com/google/syzkaller/sys/targets"
+)
+
+// callResultCollector accumulates per-syscall results of executed calls: the number of executions,
+// the number of successful executions, the histogram of errnos of failed executions,
+// the histogram of durations and the number of calls that hit the syscall timeout.
+// The manager aggregates the results from all fuzzers and shows them on the /syscalls page.
+type callResultCollector struct {
+	mu       sync.Mutex
+	timeouts targets.Timeouts
+	calls    map[int]*rpctype.CallResultStats
+}
+
+func newCallResultCollector(timeouts targets.Timeouts) *callResultCollector {
+	return &callResultCollector{
+		timeouts: timeouts,
+		calls:    make(map[int]*rpctype.CallResultStats),
+	}
+}
+
+func (c *callResultCollector) record(p *prog.Prog, info *ipc.ProgInfo) {
+	if info == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i, inf := range info.Calls {
+		// Calls that were not started say nothing about the call,
+		// and injected faults make calls fail regardless of the arguments.
+		if i >= len(p.Calls) || inf.Flags&ipc.CallExecuted == 0 || inf.Flags&ipc.CallFaultInjected != 0 {
+			continue
+		}
+		meta := p.Calls[i].Meta
+		stats := c.calls[meta.ID]
+		if stats == nil {
+			stats = &rpctype.CallResultStats{ID: meta.ID}
+			c.calls[meta.ID] = stats
+		}
+		stats.Execs++
+		bucket := durationBucket(inf.Duration)
+		for len(stats.Durations) <= bucket {
+			stats.Durations = append(stats.Durations, 0)
+		}
+		stats.Durations[bucket]++
+		if callTimedOut(meta, &inf, c.timeouts) {
+			stats.Timeouts++
+		}
+		if inf.Errno == 0 {
+			stats.Success++
+			continue
+		}
+		if stats.Errnos == nil {
+			stats.Errnos = make(map[int]uint32)
+		}
+		stats.Errnos[inf.Errno]++
+	}
+}
+
+// collect returns results accumulated since the previous call.
+func (c *callResultCollector) collect() []rpctype.CallResultStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	res := make([]rpctype.CallResultStats, 0, len(c.calls))
+	for _, stats := range c.calls {
+		res = append(res, *stats)
+	}
+	c.calls = make(map[int]*rpctype.CallResultStats)
+	return res
+}
+
+// durationBucket returns the index of the duration in rpctype.CallResultStats.Durations.
+func durationBucket(d time.Duration) int {
+	return bits.Len64(uint64(d / time.Millisecond))
+}
+
+// callTimeout returns the time after which the executor considers the call blocked.
+func callTimeout(meta *prog.Syscall, timeouts targets.Timeouts) time.Duration {
+	return timeouts.Syscall + time.Duration(meta.Attrs.Timeout)*time.Millisecond*timeouts.Scale
+}
+
+func callTimedOut(meta *prog.Syscall, inf *ipc.CallInfo, timeouts targets.Timeouts) bool {
+	return inf.Flags&ipc.CallFinished == 0 || inf.Duration >= callTimeout(meta, timeouts)
+}
@@ -0,0 +1,137 @@
+// Copyright 2026 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"math"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+
+	"github.com/google/syzkaller/pkg/log"
+	"github.com/google/syzkaller/pkg/rpctype"
+	"github.com/google/syzkaller/prog"
+)
+
+// directedState guides fuzzing towards target PCs chosen in the manager config (see mgrconfig.Config.Directed).
+// The manager sends call graph distances to the targets for coverage PCs, the distance of a corpus program
+// is the minimal distance of its coverage. With strength S, S share of corpus programs chosen for mutation
+// are chosen among programs within the max distance with probability proportional to 2^-distance,
+// and such programs get up to 1+S times more smash executions (the closer, the more).
+type directedState struct {
+	distances   map[uint32]uint32 // nil if directed fuzzing is disabled, immutable
+	strength    float64
+	maxDistance int
+
+	mu     sync.Mutex
+	progs  []*prog.Prog
+	dist   map[*prog.Prog]int
+	energy energyTree
+	// Stats since the last poll of the manager.
+	statChosen    uint64
+	statNewInputs uint64
+	statReached   uint64
+}
+
+func (d *directedState) init(req *rpctype.Directed) {
+	if req == nil || len(req.Distances) == 0 {
+		return
+	}
+	d.distances = req.Distances
+	d.strength = req.Strength
+	d.maxDistance = req.MaxDistance
+	d.dist = make(map[*prog.Prog]int)
+	log.Logf(0, "directed fuzzing: %v PCs within distance %v, strength %v",
+		len(req.Distances), req.MaxDistance, req.Strength)
+}
+
+// distance returns the minimal distance of the coverage to the targets, or -1 if it's beyond the max distance
+// (or directed fuzzing is disabled).
+func (d *directedState) distance(cov []uint32) int {
+	best := -1
+	for _, pc := range cov {
+		if dist, ok := d.distances[pc]; ok && (best == -1 || int(dist) < best) {
+			best = int(dist)
+		}
+	}
+	return best
+}
+
+// added is called for new corpus programs with their distance to the targets.
+// The distance is computed by the fuzzer that found the program, since coverage
+// of programs found by other fuzzers is not available.
+func (d *directedState) added(p *prog.Prog, dist int) {
+	if d.distances == nil || dist == -1 {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.dist[p] = dist
+	d.progs = append(d.progs, p)
+	d.energy.append(math.Exp2(-float64(dist)))
+}
+
+// reset is called when the corpus is dropped.
+func (d *directedState) reset() {
+	if d.distances == nil {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.progs = nil
+	d.dist = make(map[*prog.Prog]int)
+	d.energy.reset(nil)
+}
+
+// newInput is called for new inputs found by this fuzzer with their distance to the targets.
+func (d *directedState) newInput(dist int) {
+	if d.distances == nil {
+		return
+	}
+	switch dist {
+	case -1:
+	case 0:
+		atomic.AddUint64(&d.statReached, 1)
+		fallthrough
+	default:
+		atomic.AddUint64(&d.statNewInputs, 1)
+	}
+}
+
+// choose returns a corpus program close to the targets with probability of the directed strength,
+// otherwise (or if there are no such programs) it returns nil.
+func (d *directedState) choose(r *rand.Rand) *prog.Prog {
+	if d.distances == nil || r.Float64() >= d.strength {
+		return nil
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if len(d.progs) == 0 {
+		return nil
+	}
+	atomic.AddUint64(&d.statChosen, 1)
+	return d.progs[d.energy.choose(r.Float64()*d.energy.total())]
+}
+
+// smashBudget adjusts the number of smash mutations for the input p.
+func (d *directedState) smashBudget(p *prog.Prog, iters int) int {
+	if d.distances == nil {
+		return iters
+	}
+	d.mu.Lock()
+	dist, ok := d.dist[p]
+	d.mu.Unlock()
+	if !ok {
+		return iters
+	}
+	closeness := 1 - float64(dist)/float64(d.maxDistance+1)
+	return int(float64(iters) * (1 + d.strength*closeness))
+}
+
+// collectStats adds stats accumulated since the previous call to stats.
+func (d *directedState) collectStats(stats map[string]uint64) {
+	stats["directed chosen"] += atomic.SwapUint64(&d.statChosen, 0)
+	stats["directed new inputs"] += atomic.SwapUint64(&d.statNewInputs, 0)
+	stats["directed reached"] += atomic.SwapUint64(&d.statReached, 0)
+}
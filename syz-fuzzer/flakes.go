@@ -0,0 +1,111 @@
+// Copyright 2026 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"sync"
+
+	"github.com/google/syzkaller/pkg/rpctype"
+	"github.com/google/syzkaller/prog"
+)
+
+// flakeTracker measures how flaky new signal of triaged inputs is per syscall and adapts
+// the number of triage runs to it: inputs for calls whose signal is often not reproduced
+// get more runs to filter out unstable signal, inputs for stable calls get fewer runs.
+// Flakiness of a call is the share of its triaged inputs with some unstable new signal,
+// it's blended with flakiness learned by the manager from all fuzzers.
+// The stats are also sent to the manager and shown on the /syscalls page.
+type flakeTracker struct {
+	mu    sync.Mutex
+	prior map[int]float64                 // flakiness per syscall ID received from the manager
+	total map[int]*rpctype.CallFlakeStats // since the fuzzer start
+	delta map[int]*rpctype.CallFlakeStats // since the previous poll
+}
+
+const (
+	triageRunsDefault = 3
+	triageRunsMin     = 2
+	triageRunsMax     = 6
+	// The number of triaged inputs after which local flakiness of a call is used without the manager prior.
+	flakeMinSamples = 10
+)
+
+func newFlakeTracker(prior map[int]float64) *flakeTracker {
+	return &flakeTracker{
+		prior: prior,
+		total: make(map[int]*rpctype.CallFlakeStats),
+		delta: make(map[int]*rpctype.CallFlakeStats),
+	}
+}
+
+// record is called for every triaged input: newSignal is the amount of new signal of the input,
+// stable is the amount of it that was reproduced by all triage runs.
+func (ft *flakeTracker) record(p *prog.Prog, call, newSignal, stable int) {
+	if call == -1 || newSignal == 0 {
+		return
+	}
+	id := p.Calls[call].Meta.ID
+	ft.mu.Lock()
+	defer ft.mu.Unlock()
+	for _, stats := range []map[int]*rpctype.CallFlakeStats{ft.total, ft.delta} {
+		s := stats[id]
+		if s == nil {
+			s = &rpctype.CallFlakeStats{ID: id}
+			stats[id] = s
+		}
+		s.Triaged++
+		if stable < newSignal {
+			s.Flaky++
+		}
+		s.Signal += uint32(newSignal)
+		s.Unstable += uint32(newSignal - stable)
+	}
+}
+
+// flakiness returns the share of flaky triaged inputs for the syscall, or false if it's not known yet.
+func (ft *flakeTracker) flakiness(id int) (float64, bool) {
+	ft.mu.Lock()
+	defer ft.mu.Unlock()
+	var triaged, flaky float64
+	if s := ft.total[id]; s != nil {
+		triaged, flaky = float64(s.Triaged), float64(s.Flaky)
+	}
+	if prior, ok := ft.prior[id]; ok {
+		// The prior counts as flakeMinSamples inputs.
+		return (flaky + prior*flakeMinSamples) / (triaged + flakeMinSamples), true
+	}
+	if triaged < flakeMinSamples {
+		return 0, false
+	}
+	return flaky / triaged, true
+}
+
+// triageRuns returns the number of executions to use to find stable signal of the call of the program.
+func (ft *flakeTracker) triageRuns(p *prog.Prog, call int) int {
+	if call == -1 {
+		return triageRunsDefault
+	}
+	flakiness, ok := ft.flakiness(p.Calls[call].Meta.ID)
+	if !ok {
+		return triageRunsDefault
+	}
+	// Stable calls get triageRunsMin runs, every 1/8 of flaky inputs adds a run.
+	runs := triageRunsMin + int(flakiness*8+0.5)
+	if runs > triageRunsMax {
+		runs = triageRunsMax
+	}
+	return runs
+}
+
+// collect returns stats accumulated since the previous call.
+func (ft *flakeTracker) collect() []rpctype.CallFlakeStats {
+	ft.mu.Lock()
+	defer ft.mu.Unlock()
+	res := make([]rpctype.CallFlakeStats, 0, len(ft.delta))
+	for _, stats := range ft.delta {
+		res = append(res, *stats)
+	}
+	ft.delta = make(map[int]*rpctype.CallFlakeStats)
+	return res
+}
@@ -0,0 +1,199 @@
+// Copyright 2026 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"math/rand"
+	"reflect"
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	"github.com/google/syzkaller/pkg/log"
+	"github.com/google/syzkaller/pkg/rpctype"
+	"github.com/google/syzkaller/prog"
+)
+
+// focusState biases fuzzing towards a set of syscalls chosen by the manager (see mgrconfig.Config.Focus).
+// With strength S, S share of corpus programs chosen for mutation contain the focus calls,
+// the focus calls and calls related to them get S share of the call-to-call priorities
+// used to insert and generate calls, and inputs with the focus calls get 1+S times more smash
+// executions, while other inputs get 1-S/2 times less smash executions and hints.
+type focusState struct {
+	mu       sync.RWMutex
+	req      *rpctype.Focus
+	calls    map[int]bool // nil if there is no focus
+	strength float64
+	ct       *prog.ChoiceTable // choice table biased towards the focus calls
+	progs    []*prog.Prog      // corpus programs with the focus calls
+	// Stats since the last poll of the manager.
+	statChosen    uint64
+	statNewInputs uint64
+	statSmashed   uint64
+}
+
+const (
+	// The number of calls related to each focus call that are favored along with the focus call.
+	focusRelatedCalls = 10
+	// Weight of the related calls in the focus priors relative to the focus calls.
+	focusRelatedWeight = 0.5
+)
+
+// updateFocus switches to the new focus received from the manager (nil means no focus).
+func (fuzzer *Fuzzer) updateFocus(req *rpctype.Focus) {
+	f := &fuzzer.focus
+	f.mu.RLock()
+	same := reflect.DeepEqual(f.req, req)
+	f.mu.RUnlock()
+	if same || fuzzer.choiceTable == nil {
+		// The focus is applied once the fuzzer has built its choice table.
+		return
+	}
+	calls := make(map[int]bool)
+	if req != nil {
+		for _, id := range req.Calls {
+			if fuzzer.choiceTable.Generatable(id) {
+				calls[id] = true
+			}
+		}
+	}
+	if len(calls) == 0 {
+		if req != nil {
+			log.Logf(0, "none of %v focus calls are enabled", len(req.Calls))
+		} else {
+			log.Logf(0, "focus is cleared")
+		}
+		f.mu.Lock()
+		f.req, f.calls, f.strength, f.ct, f.progs = req, nil, 0, nil, nil
+		f.mu.Unlock()
+		return
+	}
+	fuzzer.corpusMu.RLock()
+	corpus := fuzzer.corpus
+	fuzzer.corpusMu.RUnlock()
+	priors, err := fuzzer.target.MakeCallPriors(fuzzer.focusWeights(calls), req.Strength)
+	if err != nil {
+		log.SyzFatalf("failed to create focus priors: %v", err)
+	}
+	ct := fuzzer.target.BuildChoiceTableWithPriors(corpus, fuzzer.enabledCalls(), append(fuzzer.priors, priors)...)
+	var progs []*prog.Prog
+	for _, p := range corpus {
+		if hasFocusCalls(p, calls) {
+			progs = append(progs, p)
+		}
+	}
+	log.Logf(0, "focus on %v calls with strength %v, %v corpus programs", len(calls), req.Strength, len(progs))
+	f.mu.Lock()
+	f.req, f.calls, f.strength, f.ct, f.progs = req, calls, req.Strength, ct, progs
+	f.mu.Unlock()
+}
+
+// focusWeights returns weights for prog.MakeCallPriors that favor the focus calls
+// and calls related to them in the base choice table after any call.
+func (fuzzer *Fuzzer) focusWeights(calls map[int]bool) map[string]map[string]float64 {
+	ct := fuzzer.choiceTable
+	syscalls := fuzzer.target.Syscalls
+	row := make(map[string]float64)
+	for id := range calls {
+		var related []int
+		for _, c := range syscalls {
+			if !calls[c.ID] && ct.Weight(id, c.ID) != 0 {
+				related = append(related, c.ID)
+			}
+		}
+		sort.Slice(related, func(i, j int) bool {
+			return ct.Weight(id, related[i]) > ct.Weight(id, related[j])
+		})
+		if len(related) > focusRelatedCalls {
+			related = related[:focusRelatedCalls]
+		}
+		for _, id1 := range related {
+			row[syscalls[id1].Name] = focusRelatedWeight
+		}
+	}
+	for id := range calls {
+		row[syscalls[id].Name] = 1
+	}
+	weights := make(map[string]map[string]float64)
+	for _, c := range syscalls {
+		if ct.Generatable(c.ID) {
+			weights[c.Name] = row
+		}
+	}
+	return weights
+}
+
+func hasFocusCalls(p *prog.Prog, calls map[int]bool) bool {
+	for _, c := range p.Calls {
+		if calls[c.Meta.ID] {
+			return true
+		}
+	}
+	return false
+}
+
+// choiceTable returns the choice table biased towards the focus calls, or nil if there is no focus.
+func (f *focusState) choiceTable() *prog.ChoiceTable {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.ct
+}
+
+// choose returns a corpus program with the focus calls with probability of the focus strength,
+// otherwise (or if there is no focus) it returns nil.
+func (f *focusState) choose(r *rand.Rand) *prog.Prog {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	if len(f.progs) == 0 || r.Float64() >= f.strength {
+		return nil
+	}
+	atomic.AddUint64(&f.statChosen, 1)
+	return f.progs[r.Intn(len(f.progs))]
+}
+
+// added is called for new corpus programs.
+func (f *focusState) added(p *prog.Prog) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.calls != nil && hasFocusCalls(p, f.calls) {
+		f.progs = append(f.progs, p)
+	}
+}
+
+// reset is called when the corpus is dropped.
+func (f *focusState) reset() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.progs = nil
+}
+
+// newInput is called for new inputs found by this fuzzer.
+func (f *focusState) newInput(p *prog.Prog) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	if f.calls != nil && hasFocusCalls(p, f.calls) {
+		atomic.AddUint64(&f.statNewInputs, 1)
+	}
+}
+
+// smashBudget adjusts the number of smash mutations for the input p and says whether to use hints for it.
+func (f *focusState) smashBudget(p *prog.Prog, iters int, r *rand.Rand) (int, bool) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	if f.calls == nil {
+		return iters, true
+	}
+	if hasFocusCalls(p, f.calls) {
+		atomic.AddUint64(&f.statSmashed, 1)
+		return int(float64(iters) * (1 + f.strength)), true
+	}
+	return int(float64(iters) * (1 - f.strength/2)), r.Float64() >= f.strength/2
+}
+
+// collectStats adds stats accumulated since the previous call to stats.
+func (f *focusState) collectStats(stats map[string]uint64) {
+	stats["focus chosen"] += atomic.SwapUint64(&f.statChosen, 0)
+	stats["focus new inputs"] += atomic.SwapUint64(&f.statNewInputs, 0)
+	stats["focus smashed"] += atomic.SwapUint64(&f.statSmashed, 0)
+}
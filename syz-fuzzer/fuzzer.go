@@ -10,7 +10,7 @@ import (
 	"os"
 	"runtime"
 	"runtime/debug"
-	"sort"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -33,14 +33,32 @@ import (
 type Fuzzer struct {
 	name        string
 	outputType  OutputType
+	recentProgs int // size of Proc.recent
 	config      *ipc.Config
 	execOpts    *ipc.ExecOpts
 	procs       []*Proc
 	gate        *ipc.Gate
 	workQueue   *WorkQueue
 	needPoll    chan struct{}
+	// choiceTable and priors are replaced only by the poll goroutine under ctMu
+	// (see updateLearnedPriors), so the poll goroutine can read them without the mutex,
+	// other goroutines must use baseChoiceTable.
+	ctMu        sync.RWMutex
 	choiceTable *prog.ChoiceTable
-	noMutate    map[int]bool
+	priors      []*prog.CallPriors // priors blended into choiceTable
+	cfgPriors   *prog.CallPriors   // external call priors from the manager config
+	// Learned call priors received from the manager and their share in the choice table.
+	learnedWeights map[string]map[string]float64
+	learnedFactor  float64
+	focus          focusState
+	directed       directedState
+	seeds          seedStats
+	noMutate       map[int]bool
+	templates      []*prog.Prog
+	callPairs      *callPairLearner
+	callResults    *callResultCollector
+	slowCalls      *slowCallTracker
+	flakes         *flakeTracker
 	// The stats field cannot unfortunately be just an uint64 array, because it
 	// results in "unaligned 64-bit atomic operation" errors on 32-bit platforms.
 	stats             []uint64
@@ -48,16 +66,22 @@ type Fuzzer struct {
 	target            *prog.Target
 	triagedCandidates uint32
 	timeouts          targets.Timeouts
+	// Candidates that have been triaged since the last poll (see candidateTriage).
+	doneCandidatesMu sync.Mutex
+	doneCandidates   []string
+	// Heap memory in use reported to the manager, sampled by the poll goroutine (see sampleHeapInUse).
+	heapInUse     uint64
+	heapInUseTime time.Time
 
 	faultInjectionEnabled    bool
 	comparisonTracingEnabled bool
 	fetchRawCover            bool
+	traceMutations           bool
 
 	corpusMu     sync.RWMutex
 	corpus       []*prog.Prog
 	corpusHashes map[hash.Sig]struct{}
-	corpusPrios  []int64
-	sumPrios     int64
+	schedule     *powerSchedule
 
 	signalMu     sync.RWMutex
 	corpusSignal signal.Signal // signal of inputs in corpus
@@ -69,9 +93,10 @@ type Fuzzer struct {
 }
 
 type FuzzerSnapshot struct {
-	corpus      []*prog.Prog
-	corpusPrios []int64
-	sumPrios    int64
+	corpus   []*prog.Prog
+	schedule *powerSchedule
+	focus    *focusState
+	directed *directedState
 }
 
 type Stat int
@@ -87,6 +112,9 @@ const (
 	StatSeed
 	StatCollide
 	StatBufferTooSmall
+	StatTemplate
+	StatExtSeed
+	StatExtSeedBurst
 	StatCount
 )
 
@@ -101,6 +129,14 @@ var statNames = [StatCount]string{
 	StatSeed:           "exec seeds",
 	StatCollide:        "exec collide",
 	StatBufferTooSmall: "buffer too small",
+	StatTemplate:       "exec template",
+	StatExtSeed:        "exec ext seed",
+	StatExtSeedBurst:   "exec ext seed burst",
+}
+
+// origin returns the name of the input origin for inputs produced by executions of this kind.
+func (stat Stat) origin() string {
+	return strings.TrimPrefix(statNames[stat], "exec ")
 }
 
 type OutputType int
@@ -156,6 +192,11 @@ func main() {
 		flagTest     = flag.Bool("test", false, "enable image testing mode")      // used by syz-ci
 		flagRunTest  = flag.Bool("runtest", false, "enable program testing mode") // used by pkg/runtest
 		flagRawCover = flag.Bool("raw_cover", false, "fetch raw coverage")
+		flagRecent   = flag.Int("recent_progs", 16,
+			"number of recently executed programs per proc dumped on executor failure (0 to disable)")
+		flagStandalone = flag.Bool("standalone", false, "run without syz-manager (see standalone.go)")
+		flagCorpus     = flag.String("corpus", "corpus.db", "corpus database for the standalone mode")
+		flagSeeds      = flag.String("seeds", "", "directory with seed programs for the standalone mode")
 	)
 	defer tool.Init()()
 	outputType := parseOutputType(*flagOutput)
@@ -196,6 +237,9 @@ func main() {
 		testImage(*flagManager, checkArgs)
 		return
 	}
+	if *flagStandalone {
+		*flagManager = startStandaloneManager(target, *flagCorpus, *flagSeeds)
+	}
 
 	machineInfo, modules := collectMachineInfos(target)
 
@@ -261,11 +305,16 @@ func main() {
 		return
 	}
 
+	schedule, err := newPowerSchedule(r.PowerSchedule)
+	if err != nil {
+		log.SyzFatalf("%v", err)
+	}
 	needPoll := make(chan struct{}, 1)
 	needPoll <- struct{}{}
 	fuzzer := &Fuzzer{
 		name:                     *flagName,
 		outputType:               outputType,
+		recentProgs:              *flagRecent,
 		config:                   config,
 		execOpts:                 execOpts,
 		workQueue:                newWorkQueue(*flagProcs, needPoll),
@@ -276,11 +325,27 @@ func main() {
 		faultInjectionEnabled:    r.CheckResult.Features[host.FeatureFault].Enabled,
 		comparisonTracingEnabled: r.CheckResult.Features[host.FeatureComparisons].Enabled,
 		corpusHashes:             make(map[hash.Sig]struct{}),
+		schedule:                 schedule,
 		checkResult:              r.CheckResult,
 		fetchRawCover:            *flagRawCover,
+		traceMutations:           r.TraceMutations,
 		noMutate:                 r.NoMutateCalls,
+		callPairs:                newCallPairLearner(),
+		callResults:              newCallResultCollector(timeouts),
+		slowCalls:                newSlowCallTracker(timeouts),
+		flakes:                   newFlakeTracker(r.CallFlakiness),
+		learnedWeights:           r.LearnedCallPriors,
+		learnedFactor:            r.LearnedCallPriorsFactor,
 		stats:                    make([]uint64, StatCount),
 	}
+	for _, data := range r.Templates {
+		p, err := target.Deserialize(data, prog.NonStrict)
+		if err != nil {
+			log.SyzFatalf("failed to parse template: %v", err)
+		}
+		fuzzer.templates = append(fuzzer.templates, p)
+	}
+	fuzzer.directed.init(r.Directed)
 	gateCallback := fuzzer.useBugFrames(r, *flagProcs)
 	fuzzer.gate = ipc.NewGate(2**flagProcs, gateCallback)
 
@@ -290,11 +355,14 @@ func main() {
 		log.Logf(0, "fetching corpus: %v, signal %v/%v (executing program)",
 			len(fuzzer.corpus), len(fuzzer.corpusSignal), len(fuzzer.maxSignal))
 	}
-	calls := make(map[*prog.Syscall]bool)
-	for _, id := range r.CheckResult.EnabledCalls[sandbox] {
-		calls[target.Syscalls[id]] = true
+	if r.CallPriors != nil {
+		fuzzer.cfgPriors, err = target.MakeCallPriors(r.CallPriors, r.CallPriorsFactor)
+		if err != nil {
+			log.SyzFatalf("failed to create call priors: %v", err)
+		}
 	}
-	fuzzer.choiceTable = target.BuildChoiceTable(fuzzer.corpus, calls)
+	fuzzer.priors = fuzzer.makePriors()
+	fuzzer.choiceTable = target.BuildChoiceTableWithPriors(fuzzer.corpus, fuzzer.enabledCalls(), fuzzer.priors...)
 
 	if r.CoverFilterBitmap != nil {
 		fuzzer.execOpts.Flags |= ipc.FlagEnableCoverageFilter
@@ -401,12 +469,18 @@ func (fuzzer *Fuzzer) pollLoop() {
 			for _, proc := range fuzzer.procs {
 				stats["exec total"] += atomic.SwapUint64(&proc.env.StatExecs, 0)
 				stats["executor restarts"] += atomic.SwapUint64(&proc.env.StatRestarts, 0)
+				proc.scheduler.collectStats(stats)
 			}
+			fuzzer.schedule.collectStats(stats)
+			fuzzer.focus.collectStats(stats)
+			fuzzer.directed.collectStats(stats)
+			fuzzer.seeds.collectStats(stats)
 			for stat := Stat(0); stat < StatCount; stat++ {
 				v := atomic.SwapUint64(&fuzzer.stats[stat], 0)
 				stats[statNames[stat]] = v
 				execTotal += v
 			}
+			fuzzer.updateSlowCalls()
 			if !fuzzer.poll(needCandidates, stats) {
 				lastPoll = time.Now()
 			}
@@ -416,10 +490,15 @@ func (fuzzer *Fuzzer) pollLoop() {
 
 func (fuzzer *Fuzzer) poll(needCandidates bool, stats map[string]uint64) bool {
 	a := &rpctype.PollArgs{
-		Name:           fuzzer.name,
-		NeedCandidates: needCandidates,
-		MaxSignal:      fuzzer.grabNewSignal().Serialize(),
-		Stats:          stats,
+		Name:              fuzzer.name,
+		NeedCandidates:    needCandidates,
+		MaxSignal:         fuzzer.grabNewSignal().Serialize(),
+		Stats:             stats,
+		CallPairs:         fuzzer.callPairs.collect(),
+		CallResults:       fuzzer.callResults.collect(),
+		CallFlakes:        fuzzer.flakes.collect(),
+		Memory:            fuzzer.sampleHeapInUse(),
+		TriagedCandidates: fuzzer.grabDoneCandidates(),
 	}
 	r := &rpctype.PollRes{}
 	if err := fuzzer.manager.Call("Manager.Poll", a, r); err != nil {
@@ -429,6 +508,11 @@ func (fuzzer *Fuzzer) poll(needCandidates bool, stats map[string]uint64) bool {
 	log.Logf(1, "poll: candidates=%v inputs=%v signal=%v",
 		len(r.Candidates), len(r.NewInputs), maxSignal.Len())
 	fuzzer.addMaxSignal(maxSignal)
+	fuzzer.updateLearnedPriors(r.LearnedCallPriors)
+	fuzzer.updateFocus(r.Focus)
+	if r.ResetCorpus {
+		fuzzer.resetCorpus()
+	}
 	for _, inp := range r.NewInputs {
 		fuzzer.addInputFromAnotherFuzzer(inp)
 	}
@@ -458,7 +542,7 @@ func (fuzzer *Fuzzer) addInputFromAnotherFuzzer(inp rpctype.Input) {
 	}
 	sig := hash.Hash(inp.Prog)
 	sign := inp.Signal.Deserialize()
-	fuzzer.addInputToCorpus(p, sign, sig)
+	fuzzer.addInputToCorpus(p, sign, sig, inp.DirectedDistance)
 }
 
 func (fuzzer *Fuzzer) addCandidateInput(candidate rpctype.Candidate) {
@@ -473,12 +557,46 @@ func (fuzzer *Fuzzer) addCandidateInput(candidate rpctype.Candidate) {
 	if candidate.Smashed {
 		flags |= ProgSmashed
 	}
+	origin := StatCandidate.origin()
+	if candidate.Source != "" {
+		origin = candidate.Source
+	}
+	triage := &candidateTriage{sig: hash.String(candidate.Prog), refs: 1}
+	if candidate.Seed {
+		fuzzer.workQueue.enqueue(&WorkSeed{
+			p:         p,
+			flags:     flags,
+			origin:    origin,
+			candidate: triage,
+		})
+		return
+	}
 	fuzzer.workQueue.enqueue(&WorkCandidate{
-		p:     p,
-		flags: flags,
+		p:         p,
+		flags:     flags,
+		origin:    origin,
+		candidate: triage,
 	})
 }
 
+// candidateDone is called when a work item derived from the candidate is done.
+func (fuzzer *Fuzzer) candidateDone(triage *candidateTriage) {
+	if triage == nil || atomic.AddInt32(&triage.refs, -1) != 0 {
+		return
+	}
+	fuzzer.doneCandidatesMu.Lock()
+	fuzzer.doneCandidates = append(fuzzer.doneCandidates, triage.sig)
+	fuzzer.doneCandidatesMu.Unlock()
+}
+
+func (fuzzer *Fuzzer) grabDoneCandidates() []string {
+	fuzzer.doneCandidatesMu.Lock()
+	defer fuzzer.doneCandidatesMu.Unlock()
+	done := fuzzer.doneCandidates
+	fuzzer.doneCandidates = nil
+	return done
+}
+
 func (fuzzer *Fuzzer) deserializeInput(inp []byte) *prog.Prog {
 	p, err := fuzzer.target.Deserialize(inp, prog.NonStrict)
 	if err != nil {
@@ -496,8 +614,9 @@ func (fuzzer *Fuzzer) deserializeInput(inp []byte) *prog.Prog {
 }
 
 func (fuzzer *Fuzzer) checkDisabledCalls(p *prog.Prog) {
+	ct := fuzzer.baseChoiceTable()
 	for _, call := range p.Calls {
-		if !fuzzer.choiceTable.Enabled(call.Meta.ID) {
+		if !ct.Enabled(call.Meta.ID) {
 			fmt.Printf("executing disabled syscall %v [%v]\n", call.Meta.Name, call.Meta.ID)
 			sandbox := ipc.FlagsToSandbox(fuzzer.config.Flags)
 			fmt.Printf("check result for sandbox=%v:\n", sandbox)
@@ -507,7 +626,7 @@ func (fuzzer *Fuzzer) checkDisabledCalls(p *prog.Prog) {
 			}
 			fmt.Printf("choice table:\n")
 			for i, meta := range fuzzer.target.Syscalls {
-				fmt.Printf("  #%v: %v [%v]: enabled=%v\n", i, meta.Name, meta.ID, fuzzer.choiceTable.Enabled(meta.ID))
+				fmt.Printf("  #%v: %v [%v]: enabled=%v\n", i, meta.Name, meta.ID, ct.Enabled(meta.ID))
 			}
 			panic("disabled syscall")
 		}
@@ -515,24 +634,52 @@ func (fuzzer *Fuzzer) checkDisabledCalls(p *prog.Prog) {
 }
 
 func (fuzzer *FuzzerSnapshot) chooseProgram(r *rand.Rand) *prog.Prog {
-	randVal := r.Int63n(fuzzer.sumPrios + 1)
-	idx := sort.Search(len(fuzzer.corpusPrios), func(i int) bool {
-		return fuzzer.corpusPrios[i] >= randVal
-	})
-	return fuzzer.corpus[idx]
+	if p := fuzzer.focus.choose(r); p != nil {
+		return p
+	}
+	if p := fuzzer.directed.choose(r); p != nil {
+		return p
+	}
+	return fuzzer.schedule.choose(r)
+}
+
+// enabledCalls returns a new set of syscalls enabled in the fuzzer.
+func (fuzzer *Fuzzer) enabledCalls() map[*prog.Syscall]bool {
+	calls := make(map[*prog.Syscall]bool)
+	for _, id := range fuzzer.checkResult.EnabledCalls[ipc.FlagsToSandbox(fuzzer.config.Flags)] {
+		calls[fuzzer.target.Syscalls[id]] = true
+	}
+	return calls
 }
 
-func (fuzzer *Fuzzer) addInputToCorpus(p *prog.Prog, sign signal.Signal, sig hash.Sig) {
+// currentChoiceTable returns the choice table to generate and mutate programs with.
+// The focus choice table takes precedence over the slow calls choice table.
+func (fuzzer *Fuzzer) currentChoiceTable() *prog.ChoiceTable {
+	if ct := fuzzer.focus.choiceTable(); ct != nil {
+		return ct
+	}
+	if ct := fuzzer.slowCalls.choiceTable(); ct != nil {
+		return ct
+	}
+	return fuzzer.baseChoiceTable()
+}
+
+// baseChoiceTable returns the choice table with the config and learned priors.
+func (fuzzer *Fuzzer) baseChoiceTable() *prog.ChoiceTable {
+	fuzzer.ctMu.RLock()
+	defer fuzzer.ctMu.RUnlock()
+	return fuzzer.choiceTable
+}
+
+// addInputToCorpus adds the program to the corpus, dist is its directed distance (see directedState.distance).
+func (fuzzer *Fuzzer) addInputToCorpus(p *prog.Prog, sign signal.Signal, sig hash.Sig, dist int) {
 	fuzzer.corpusMu.Lock()
 	if _, ok := fuzzer.corpusHashes[sig]; !ok {
 		fuzzer.corpus = append(fuzzer.corpus, p)
 		fuzzer.corpusHashes[sig] = struct{}{}
-		prio := int64(len(sign))
-		if sign.Empty() {
-			prio = 1
-		}
-		fuzzer.sumPrios += prio
-		fuzzer.corpusPrios = append(fuzzer.corpusPrios, fuzzer.sumPrios)
+		fuzzer.schedule.add(p, sign)
+		fuzzer.focus.added(p)
+		fuzzer.directed.added(p, dist)
 	}
 	fuzzer.corpusMu.Unlock()
 
@@ -544,10 +691,50 @@ func (fuzzer *Fuzzer) addInputToCorpus(p *prog.Prog, sign signal.Signal, sig has
 	}
 }
 
+// resetCorpus drops the corpus when the manager switches the fuzzer to another corpus shard.
+// Max signal is kept, so programs of the previous shard are not triaged again.
+func (fuzzer *Fuzzer) resetCorpus() {
+	// Snapshots keep using the old schedule along with the old corpus.
+	schedule, err := newPowerSchedule(fuzzer.schedule.name)
+	if err != nil {
+		log.SyzFatalf("%v", err)
+	}
+	fuzzer.corpusMu.Lock()
+	log.Logf(0, "switching corpus shard, dropping %v corpus programs", len(fuzzer.corpus))
+	fuzzer.corpus = nil
+	fuzzer.corpusHashes = make(map[hash.Sig]struct{})
+	fuzzer.schedule = schedule
+	fuzzer.focus.reset()
+	fuzzer.directed.reset()
+	fuzzer.corpusMu.Unlock()
+
+	fuzzer.signalMu.Lock()
+	fuzzer.corpusSignal = nil
+	fuzzer.signalMu.Unlock()
+}
+
+func (fuzzer *Fuzzer) currentSchedule() *powerSchedule {
+	fuzzer.corpusMu.RLock()
+	defer fuzzer.corpusMu.RUnlock()
+	return fuzzer.schedule
+}
+
+// sampleHeapInUse returns heap memory in use, it's sampled at most once per heapSamplePeriod
+// because runtime.ReadMemStats stops the world.
+func (fuzzer *Fuzzer) sampleHeapInUse() uint64 {
+	const heapSamplePeriod = time.Minute
+	if now := time.Now(); now.Sub(fuzzer.heapInUseTime) >= heapSamplePeriod {
+		var stats runtime.MemStats
+		runtime.ReadMemStats(&stats)
+		fuzzer.heapInUse, fuzzer.heapInUseTime = stats.HeapInuse, now
+	}
+	return fuzzer.heapInUse
+}
+
 func (fuzzer *Fuzzer) snapshot() FuzzerSnapshot {
 	fuzzer.corpusMu.RLock()
 	defer fuzzer.corpusMu.RUnlock()
-	return FuzzerSnapshot{fuzzer.corpus, fuzzer.corpusPrios, fuzzer.sumPrios}
+	return FuzzerSnapshot{fuzzer.corpus, fuzzer.schedule, &fuzzer.focus, &fuzzer.directed}
 }
 
 func (fuzzer *Fuzzer) addMaxSignal(sign signal.Signal) {
@@ -4,11 +4,19 @@
 package main
 
 import (
+	"fmt"
 	"math"
 	"math/rand"
+	"path/filepath"
+	"reflect"
+	"sort"
 	"testing"
+	"time"
 
 	"github.com/google/syzkaller/pkg/hash"
+	"github.com/google/syzkaller/pkg/ipc"
+	"github.com/google/syzkaller/pkg/osutil"
+	"github.com/google/syzkaller/pkg/rpctype"
 	"github.com/google/syzkaller/pkg/signal"
 	"github.com/google/syzkaller/prog"
 	"github.com/google/syzkaller/sys/targets"
@@ -24,7 +32,7 @@ func TestChooseProgram(t *testing.T) {
 	rs := rand.NewSource(0)
 	r := rand.New(rs)
 	target := getTarget(t, targets.TestOS, targets.TestArch64)
-	fuzzer := &Fuzzer{corpusHashes: make(map[hash.Sig]struct{})}
+	fuzzer := &Fuzzer{corpusHashes: make(map[hash.Sig]struct{}), schedule: newTestSchedule(t, "")}
 
 	const (
 		maxIters   = 1000
@@ -39,7 +47,7 @@ func TestChooseProgram(t *testing.T) {
 			sizeSig = 0
 		}
 		inp := generateInput(target, rs, 10, sizeSig)
-		fuzzer.addInputToCorpus(inp.p, inp.sign, inp.sig)
+		fuzzer.addInputToCorpus(inp.p, inp.sign, inp.sig, -1)
 		priorities[inp.p] = int64(len(inp.sign))
 	}
 	snapshot := fuzzer.snapshot()
@@ -48,7 +56,7 @@ func TestChooseProgram(t *testing.T) {
 		counters[snapshot.chooseProgram(r)]++
 	}
 	for p, prio := range priorities {
-		prob := float64(prio) / float64(fuzzer.sumPrios)
+		prob := float64(prio) / fuzzer.schedule.energy.total()
 		diff := math.Abs(prob*maxIters - float64(counters[p]))
 		if diff > eps*maxIters {
 			t.Fatalf("the difference (%f) is higher than %f%%", diff, eps*100)
@@ -58,7 +66,7 @@ func TestChooseProgram(t *testing.T) {
 
 func TestAddInputConcurrency(t *testing.T) {
 	target := getTarget(t, targets.TestOS, targets.TestArch64)
-	fuzzer := &Fuzzer{corpusHashes: make(map[hash.Sig]struct{})}
+	fuzzer := &Fuzzer{corpusHashes: make(map[hash.Sig]struct{}), schedule: newTestSchedule(t, "")}
 
 	const (
 		routines = 10
@@ -71,7 +79,7 @@ func TestAddInputConcurrency(t *testing.T) {
 			r := rand.New(rs)
 			for it := 0; it < iters; it++ {
 				inp := generateInput(target, rs, 10, it)
-				fuzzer.addInputToCorpus(inp.p, inp.sign, inp.sig)
+				fuzzer.addInputToCorpus(inp.p, inp.sign, inp.sig, -1)
 				snapshot := fuzzer.snapshot()
 				snapshot.chooseProgram(r).Clone()
 			}
@@ -79,6 +87,659 @@ func TestAddInputConcurrency(t *testing.T) {
 	}
 }
 
+func TestPowerSchedules(t *testing.T) {
+	target := getTarget(t, targets.TestOS, targets.TestArch64)
+	rs := rand.NewSource(0)
+	r := rand.New(rs)
+	sign := func(elems ...uint32) signal.Signal {
+		return signal.FromRaw(elems, 0)
+	}
+	tests := []struct {
+		schedule string
+		signals  []signal.Signal
+		newInput []int
+		// Index of the program that must have the highest energy.
+		best int
+	}{
+		{
+			schedule: "signal",
+			signals:  []signal.Signal{sign(1, 2), sign(1, 2, 3, 4), nil},
+			best:     1,
+		},
+		{
+			// Signal 1 and 2 is covered by all programs, so it's not rare.
+			schedule: "rare",
+			signals: []signal.Signal{sign(1, 2), sign(1, 2), sign(1, 2, 5, 6), sign(1, 2),
+				sign(1, 2, 3), sign(1, 2, 3)},
+			best: 2,
+		},
+		{
+			schedule: "recent",
+			signals:  []signal.Signal{sign(1, 2), sign(1, 2), sign(1, 2)},
+			best:     2,
+		},
+		{
+			schedule: "productive",
+			signals:  []signal.Signal{sign(1, 2, 3, 4), sign(1, 2), sign(1, 2, 3)},
+			newInput: []int{1, 2, 1, 1, 1},
+			best:     1,
+		},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.schedule, func(t *testing.T) {
+			s := newTestSchedule(t, test.schedule)
+			var progs []*prog.Prog
+			for _, sign := range test.signals {
+				p := target.Generate(rs, 1, target.DefaultChoiceTable())
+				progs = append(progs, p)
+				s.add(p, sign)
+			}
+			for _, idx := range test.newInput {
+				s.newInput(progs[idx])
+			}
+			best := 0
+			for i, v := range s.energy.vals {
+				if v > s.energy.vals[best] {
+					best = i
+				}
+			}
+			if best != test.best {
+				t.Fatalf("program #%v has the highest energy, want #%v (energies %v)",
+					best, test.best, s.energy.vals)
+			}
+			const iters = 10000
+			counts := make(map[*prog.Prog]int)
+			for i := 0; i < iters; i++ {
+				counts[s.choose(r)]++
+			}
+			for i, p := range progs {
+				want := s.energy.vals[i] / s.energy.total() * iters
+				if got := float64(counts[p]); math.Abs(got-want) > 0.05*iters {
+					t.Fatalf("program #%v was chosen %v times, want %v", i, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestEnergyTree(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+	var tree energyTree
+	var vals []float64
+	for i := 0; i < 1000; i++ {
+		if len(vals) == 0 || r.Intn(2) == 0 {
+			v := float64(r.Intn(100))
+			vals = append(vals, v)
+			tree.append(v)
+		} else {
+			idx := r.Intn(len(vals))
+			vals[idx] = float64(r.Intn(100))
+			tree.set(idx, vals[idx])
+		}
+		sum := 0.0
+		for _, v := range vals {
+			sum += v
+		}
+		if total := tree.total(); total != sum {
+			t.Fatalf("total %v, want %v", total, sum)
+		}
+		if sum == 0 {
+			continue
+		}
+		x := r.Float64() * sum
+		idx := tree.choose(x)
+		prefix := 0.0
+		for _, v := range vals[:idx] {
+			prefix += v
+		}
+		if prefix > x || prefix+vals[idx] <= x {
+			t.Fatalf("chose #%v with prefix sum %v/%v for %v", idx, prefix, prefix+vals[idx], x)
+		}
+	}
+}
+
+func TestResetCorpus(t *testing.T) {
+	target := getTarget(t, targets.TestOS, targets.TestArch64)
+	rs := rand.NewSource(0)
+	r := rand.New(rs)
+	fuzzer := &Fuzzer{corpusHashes: make(map[hash.Sig]struct{}), schedule: newTestSchedule(t, "rare")}
+	var inputs []InputTest
+	for i := 0; i < 10; i++ {
+		inp := generateInput(target, rs, 10, i)
+		fuzzer.addInputToCorpus(inp.p, inp.sign, inp.sig, -1)
+		inputs = append(inputs, inp)
+	}
+	old := fuzzer.snapshot()
+	fuzzer.resetCorpus()
+	if len(fuzzer.corpus) != 0 || len(fuzzer.corpusSignal) != 0 || fuzzer.schedule.name != "rare" {
+		t.Fatalf("corpus is not reset")
+	}
+	// Snapshots taken before the reset keep working.
+	for i := 0; i < 100; i++ {
+		if old.chooseProgram(r) == nil {
+			t.Fatalf("old snapshot returned no program")
+		}
+	}
+	// Programs of the previous corpus can be added again.
+	fuzzer.addInputToCorpus(inputs[0].p, inputs[0].sign, inputs[0].sig, -1)
+	if snapshot := fuzzer.snapshot(); len(snapshot.corpus) != 1 || snapshot.chooseProgram(r) != inputs[0].p {
+		t.Fatalf("bad corpus after reset")
+	}
+}
+
+func TestFocus(t *testing.T) {
+	target := getTarget(t, targets.TestOS, targets.TestArch64)
+	rs := rand.NewSource(0)
+	r := rand.New(rs)
+	fuzzer := newTestFuzzer(t, target)
+	focusCall := target.SyscallMap["test$res0"]
+	for i := 0; i < 100; i++ {
+		inp := generateInput(target, rs, 10, i)
+		fuzzer.addInputToCorpus(inp.p, inp.sign, inp.sig, -1)
+	}
+	const iters = 10000
+	countFocus := func() (int, int) {
+		snapshot := fuzzer.snapshot()
+		chosen, generated := 0, 0
+		ct := fuzzer.currentChoiceTable()
+		for i := 0; i < iters; i++ {
+			if hasFocusCalls(snapshot.chooseProgram(r), map[int]bool{focusCall.ID: true}) {
+				chosen++
+			}
+		}
+		for i := 0; i < iters/10; i++ {
+			p := target.Generate(rs, 10, ct)
+			if hasFocusCalls(p, map[int]bool{focusCall.ID: true}) {
+				generated++
+			}
+		}
+		return chosen, generated
+	}
+	chosen0, generated0 := countFocus()
+	fuzzer.updateFocus(&rpctype.Focus{Calls: []int{focusCall.ID}, Strength: 0.8})
+	if len(fuzzer.focus.progs) == 0 {
+		t.Fatalf("no corpus programs with the focus call")
+	}
+	chosen1, generated1 := countFocus()
+	if chosen1 < iters*8/10 || chosen1 <= chosen0 {
+		t.Fatalf("programs with the focus call were chosen %v/%v times, without focus %v/%v",
+			chosen1, iters, chosen0, iters)
+	}
+	if generated1 <= generated0*2 {
+		t.Fatalf("generated %v programs with the focus call, without focus %v", generated1, generated0)
+	}
+	fuzzer.updateFocus(nil)
+	if fuzzer.currentChoiceTable() != fuzzer.choiceTable || fuzzer.focus.choose(r) != nil {
+		t.Fatalf("focus is not cleared")
+	}
+}
+
+func TestDirected(t *testing.T) {
+	target := getTarget(t, targets.TestOS, targets.TestArch64)
+	rs := rand.NewSource(0)
+	r := rand.New(rs)
+	fuzzer := &Fuzzer{corpusHashes: make(map[hash.Sig]struct{}), schedule: newTestSchedule(t, "")}
+	fuzzer.directed.init(&rpctype.Directed{
+		Distances:   map[uint32]uint32{10: 0, 20: 1, 30: 3},
+		Strength:    0.8,
+		MaxDistance: 3,
+	})
+	covers := [][]uint32{{1, 2}, {20, 30}, {5, 10}, {30}}
+	var progs []*prog.Prog
+	for i := 0; i < 100; i++ {
+		inp := generateInput(target, rs, 10, i)
+		fuzzer.addInputToCorpus(inp.p, inp.sign, inp.sig, fuzzer.directed.distance(covers[i%len(covers)]))
+		progs = append(progs, inp.p)
+	}
+	if len(fuzzer.directed.progs) != 75 {
+		t.Fatalf("got %v directed programs, want 75", len(fuzzer.directed.progs))
+	}
+	const iters = 10000
+	counts := make(map[int]int)
+	snapshot := fuzzer.snapshot()
+	for i := 0; i < iters; i++ {
+		p := snapshot.chooseProgram(r)
+		dist, ok := fuzzer.directed.dist[p]
+		if !ok {
+			dist = -1
+		}
+		counts[dist]++
+	}
+	// Distance 0 has energy 1, distance 1 - 1/2, distance 3 - 1/8.
+	if counts[0] < counts[1] || counts[1] < counts[3] || counts[-1] > iters*3/10 {
+		t.Fatalf("bad distribution of chosen programs by distance: %v", counts)
+	}
+	if got := fuzzer.directed.smashBudget(progs[2], 100); got != 180 {
+		t.Fatalf("smash budget for distance 0: %v, want 180", got)
+	}
+	if got := fuzzer.directed.smashBudget(progs[0], 100); got != 100 {
+		t.Fatalf("smash budget for a distant program: %v, want 100", got)
+	}
+	fuzzer.directed.newInput(fuzzer.directed.distance([]uint32{10}))
+	fuzzer.directed.newInput(fuzzer.directed.distance([]uint32{30}))
+	fuzzer.directed.newInput(fuzzer.directed.distance([]uint32{1}))
+	stats := make(map[string]uint64)
+	fuzzer.directed.collectStats(stats)
+	if stats["directed new inputs"] != 2 || stats["directed reached"] != 1 ||
+		stats["directed chosen"] == 0 {
+		t.Fatalf("bad stats: %v", stats)
+	}
+	var disabled directedState
+	disabled.init(nil)
+	disabled.added(progs[2], disabled.distance(covers[2]))
+	if disabled.choose(r) != nil || disabled.smashBudget(progs[2], 100) != 100 {
+		t.Fatalf("disabled directed state affects fuzzing")
+	}
+}
+
+func TestDirectedInputFromAnotherFuzzer(t *testing.T) {
+	target := getTarget(t, targets.TestOS, targets.TestArch64)
+	rs := rand.NewSource(0)
+	fuzzer := &Fuzzer{
+		target:       target,
+		corpusHashes: make(map[hash.Sig]struct{}),
+		schedule:     newTestSchedule(t, ""),
+	}
+	fuzzer.directed.init(&rpctype.Directed{
+		Distances:   map[uint32]uint32{10: 0, 20: 1},
+		Strength:    1,
+		MaxDistance: 1,
+	})
+	// Inputs from other fuzzers come without coverage, but with the distance computed by their fuzzer.
+	for i, dist := range []int{1, -1} {
+		p := generateInput(target, rs, 10, i).p
+		fuzzer.addInputFromAnotherFuzzer(rpctype.Input{
+			Prog:             p.Serialize(),
+			Signal:           signal.FromRaw([]uint32{uint32(i)}, 0).Serialize(),
+			DirectedDistance: dist,
+		})
+	}
+	if len(fuzzer.corpus) != 2 || len(fuzzer.directed.progs) != 1 ||
+		fuzzer.directed.dist[fuzzer.directed.progs[0]] != 1 || fuzzer.directed.progs[0] != fuzzer.corpus[0] {
+		t.Fatalf("bad directed programs: %v of %v corpus programs", len(fuzzer.directed.progs), len(fuzzer.corpus))
+	}
+	snapshot := fuzzer.snapshot()
+	if p := snapshot.chooseProgram(rand.New(rs)); p != fuzzer.corpus[0] {
+		t.Fatalf("the directed program is not chosen")
+	}
+}
+
+func TestCallResults(t *testing.T) {
+	target := getTarget(t, targets.TestOS, targets.TestArch64)
+	p, err := target.Deserialize([]byte("test$res0()\ntest$res0()\ntest$res0()\ntest$res0()\n"), prog.NonStrict)
+	if err != nil {
+		t.Fatal(err)
+	}
+	timeouts := targets.Get(targets.TestOS, targets.TestArch64).Timeouts(1)
+	collector := newCallResultCollector(timeouts)
+	collector.record(p, nil)
+	const finished = ipc.CallExecuted | ipc.CallFinished
+	collector.record(p, &ipc.ProgInfo{Calls: []ipc.CallInfo{
+		{Flags: finished},
+		{Flags: finished, Errno: 22, Duration: 5 * time.Millisecond},
+		{Flags: finished | ipc.CallFaultInjected, Errno: 12},
+		{},
+	}})
+	collector.record(p, &ipc.ProgInfo{Calls: []ipc.CallInfo{
+		{Flags: finished, Errno: 22, Duration: timeouts.Syscall},
+		{Flags: ipc.CallExecuted, Errno: 999, Duration: time.Millisecond},
+	}})
+	res := collector.collect()
+	want := []rpctype.CallResultStats{{
+		ID:        p.Calls[0].Meta.ID,
+		Execs:     4,
+		Success:   1,
+		Errnos:    map[int]uint32{22: 2, 999: 1},
+		Durations: make([]uint32, durationBucket(timeouts.Syscall)+1),
+		Timeouts:  2,
+	}}
+	want[0].Durations[0] = 1
+	want[0].Durations[1] = 1
+	want[0].Durations[3] = 1
+	want[0].Durations[durationBucket(timeouts.Syscall)]++
+	if !reflect.DeepEqual(res, want) {
+		t.Fatalf("got %+v, want %+v", res, want)
+	}
+	if res := collector.collect(); len(res) != 0 {
+		t.Fatalf("results are not reset: %+v", res)
+	}
+}
+
+func TestSlowCalls(t *testing.T) {
+	target := getTarget(t, targets.TestOS, targets.TestArch64)
+	timeouts := targets.Get(targets.TestOS, targets.TestArch64).Timeouts(1)
+	fuzzer := newTestFuzzer(t, target)
+	p, err := target.Deserialize([]byte("test$res0()\ntest$res1(0x0)\n"), prog.NonStrict)
+	if err != nil {
+		t.Fatal(err)
+	}
+	slowCall, fastCall := p.Calls[0].Meta, p.Calls[1].Meta
+	const finished = ipc.CallExecuted | ipc.CallFinished
+	info := &ipc.ProgInfo{Calls: []ipc.CallInfo{
+		{Flags: finished, Duration: timeouts.Syscall * 3 / 4},
+		{Flags: finished, Duration: time.Millisecond},
+	}}
+	for i := 0; i < slowCallQuietExecs; i++ {
+		fuzzer.slowCalls.record(p, info)
+	}
+	fuzzer.updateSlowCalls()
+	ct := fuzzer.currentChoiceTable()
+	if ct == fuzzer.choiceTable || !fuzzer.slowCalls.slow[slowCall.ID] || fuzzer.slowCalls.slow[fastCall.ID] {
+		t.Fatalf("slow call is not deprioritized: %v", fuzzer.slowCalls.slow)
+	}
+	if ct.Weight(fastCall.ID, slowCall.ID) >= fuzzer.choiceTable.Weight(fastCall.ID, slowCall.ID) {
+		t.Fatalf("slow call weight is not decreased: %v -> %v",
+			fuzzer.choiceTable.Weight(fastCall.ID, slowCall.ID), ct.Weight(fastCall.ID, slowCall.ID))
+	}
+	// New signal brings the call back.
+	fuzzer.slowCalls.newInput(p, 0)
+	fuzzer.updateSlowCalls()
+	if fuzzer.currentChoiceTable() != ct {
+		t.Fatalf("choice table is rebuilt before the update period")
+	}
+	fuzzer.slowCalls.lastSwap = time.Time{}
+	fuzzer.updateSlowCalls()
+	if fuzzer.currentChoiceTable() != fuzzer.choiceTable {
+		t.Fatalf("slow call is still deprioritized after new input")
+	}
+	// The call is deprioritized again after a quiet period, but only while it is slow.
+	stats := fuzzer.slowCalls.calls[slowCall.ID]
+	for i := 0; i < slowCallQuietExecs; i++ {
+		fuzzer.slowCalls.record(p, info)
+	}
+	if !stats.deprioritized() {
+		t.Fatalf("slow call is not deprioritized after a quiet period: %+v", *stats)
+	}
+	info.Calls[0].Duration = time.Millisecond
+	for i := 0; i < slowCallWindow; i++ {
+		fuzzer.slowCalls.record(p, info)
+	}
+	if stats.deprioritized() {
+		t.Fatalf("call is still deprioritized after it became fast: %+v", *stats)
+	}
+}
+
+func TestLearnedPriors(t *testing.T) {
+	target := getTarget(t, targets.TestOS, targets.TestArch64)
+	fuzzer := newTestFuzzer(t, target)
+	fuzzer.learnedFactor = 0.5
+	enabled := fuzzer.checkResult.EnabledCalls["none"]
+	ct0 := fuzzer.currentChoiceTable()
+	// Learn a pair with the lowest static priority.
+	prev := target.SyscallMap["test$res0"]
+	next := prev
+	for _, id := range enabled {
+		if ct0.Generatable(id) && ct0.Weight(prev.ID, id) < ct0.Weight(prev.ID, next.ID) {
+			next = target.Syscalls[id]
+		}
+	}
+	fuzzer.updateLearnedPriors(nil)
+	if fuzzer.currentChoiceTable() != ct0 {
+		t.Fatalf("choice table is rebuilt without new priors")
+	}
+	fuzzer.updateLearnedPriors(map[string]map[string]float64{prev.Name: {next.Name: 1}})
+	ct1 := fuzzer.currentChoiceTable()
+	if ct1 == ct0 || len(fuzzer.priors) != 1 {
+		t.Fatalf("choice table is not rebuilt with new priors")
+	}
+	if ct1.Weight(prev.ID, next.ID) <= ct0.Weight(prev.ID, next.ID) {
+		t.Fatalf("learned pair weight is not increased: %v -> %v",
+			ct0.Weight(prev.ID, next.ID), ct1.Weight(prev.ID, next.ID))
+	}
+}
+
+func TestStandaloneManager(t *testing.T) {
+	target := getTarget(t, targets.TestOS, targets.TestArch64)
+	dir := t.TempDir()
+	seedDir := filepath.Join(dir, "seeds")
+	corpusFile := filepath.Join(dir, "corpus.db")
+	osutil.MkdirAll(seedDir)
+	for i, seed := range []string{"test$res0()\n", "foo$bar()\n"} {
+		if err := osutil.WriteFile(filepath.Join(seedDir, fmt.Sprint(i)), []byte(seed)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	manager, err := rpctype.NewRPCClient(startStandaloneManager(target, corpusFile, seedDir), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer manager.Close()
+	r := &rpctype.PollRes{}
+	if err := manager.Call("Manager.Poll", &rpctype.PollArgs{NeedCandidates: true}, r); err != nil {
+		t.Fatal(err)
+	}
+	// The broken seed is skipped.
+	if len(r.Candidates) != 1 || string(r.Candidates[0].Prog) != "test$res0()\n" {
+		t.Fatalf("got candidates %+v", r.Candidates)
+	}
+	inp := rpctype.Input{Call: "test$res0", Prog: []byte("test$res0()\n")}
+	if err := manager.Call("Manager.NewInput", &rpctype.NewInputArgs{Input: inp}, nil); err != nil {
+		t.Fatal(err)
+	}
+	// New inputs are handed out as candidates after restart.
+	manager1, err := rpctype.NewRPCClient(startStandaloneManager(target, corpusFile, ""), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer manager1.Close()
+	r = &rpctype.PollRes{}
+	if err := manager1.Call("Manager.Poll", &rpctype.PollArgs{NeedCandidates: true}, r); err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Candidates) != 1 || !r.Candidates[0].Minimized || string(r.Candidates[0].Prog) != string(inp.Prog) {
+		t.Fatalf("got candidates %+v", r.Candidates)
+	}
+}
+
+func TestFlakeTracker(t *testing.T) {
+	target := getTarget(t, targets.TestOS, targets.TestArch64)
+	p, err := target.Deserialize([]byte("test$res0()\ntest$res1(0x0)\n"), prog.NonStrict)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stableID, flakyID := p.Calls[0].Meta.ID, p.Calls[1].Meta.ID
+	ft := newFlakeTracker(nil)
+	if runs := ft.triageRuns(p, 0); runs != triageRunsDefault {
+		t.Fatalf("got %v runs for an unknown call, want %v", runs, triageRunsDefault)
+	}
+	for i := 0; i < flakeMinSamples; i++ {
+		ft.record(p, 0, 10, 10)
+		ft.record(p, 1, 10, 10-i%2*5)
+	}
+	ft.record(p, -1, 10, 0)
+	if runs := ft.triageRuns(p, 0); runs != triageRunsMin {
+		t.Fatalf("got %v runs for a stable call, want %v", runs, triageRunsMin)
+	}
+	if runs := ft.triageRuns(p, 1); runs != triageRunsMin+4 {
+		t.Fatalf("got %v runs for a flaky call, want %v", runs, triageRunsMin+4)
+	}
+	got := ft.collect()
+	sort.Slice(got, func(i, j int) bool { return got[i].ID < got[j].ID })
+	want := []rpctype.CallFlakeStats{
+		{ID: stableID, Triaged: 10, Signal: 100},
+		{ID: flakyID, Triaged: 10, Flaky: 5, Signal: 100, Unstable: 25},
+	}
+	if stableID > flakyID {
+		want[0], want[1] = want[1], want[0]
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got stats %+v, want %+v", got, want)
+	}
+	if got := ft.collect(); len(got) != 0 {
+		t.Fatalf("stats are not reset: %+v", got)
+	}
+	// The manager prior is used before the fuzzer collects its own stats.
+	ft = newFlakeTracker(map[int]float64{flakyID: 1})
+	if runs := ft.triageRuns(p, 1); runs != triageRunsMax {
+		t.Fatalf("got %v runs for a call flaky according to the manager, want %v", runs, triageRunsMax)
+	}
+	for i := 0; i < 3*flakeMinSamples; i++ {
+		ft.record(p, 1, 10, 10)
+	}
+	if runs := ft.triageRuns(p, 1); runs != triageRunsMin+2 {
+		t.Fatalf("got %v runs after stable local triage, want %v", runs, triageRunsMin+2)
+	}
+}
+
+func TestTriageSignal(t *testing.T) {
+	target := getTarget(t, targets.TestOS, targets.TestArch64)
+	p, err := target.Deserialize([]byte("test$res0()\n"), prog.NonStrict)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := p.Calls[0].Meta.ID
+	item := &WorkTriage{p: p, call: 0, info: ipc.CallInfo{Signal: []uint32{1, 2}}}
+	newSignal := signal.FromRaw(item.info.Signal, 0)
+	success := &ipc.ProgInfo{Calls: []ipc.CallInfo{{Signal: []uint32{1, 2, 3}, Cover: []uint32{10}}}}
+	runs := func(infos ...*ipc.ProgInfo) func() *ipc.ProgInfo {
+		return func() *ipc.ProgInfo {
+			info := infos[0]
+			infos = infos[1:]
+			return info
+		}
+	}
+	ft := newFlakeTracker(nil)
+	// All runs failed, the input is dropped and counts as flaky.
+	if _, _, _, ok := triageSignal(item, newSignal, 3, false, ft, runs(nil, nil, nil)); ok {
+		t.Fatalf("input is not dropped after all runs failed")
+	}
+	if got := ft.collect(); len(got) != 1 || got[0] != (rpctype.CallFlakeStats{
+		ID: id, Triaged: 1, Flaky: 1, Signal: 2, Unstable: 2}) {
+		t.Fatalf("bad stats of the failed triage: %+v", got)
+	}
+	// Up to runs/2+1 failed runs are tolerated, but the input counts as flaky.
+	sign, cov, _, ok := triageSignal(item, newSignal, 3, false, ft, runs(nil, success, nil))
+	if !ok || sign.Len() != 2 || len(cov) != 1 {
+		t.Fatalf("input is dropped after two failed runs of three: signal %v, cover %v", sign.Len(), len(cov))
+	}
+	// More failed runs are not.
+	if _, _, _, ok := triageSignal(item, newSignal, 5, false, ft, runs(success, nil, nil, nil, nil)); ok {
+		t.Fatalf("input is not dropped after most runs failed")
+	}
+	// Inputs reproduced by all runs are stable.
+	if _, _, _, ok := triageSignal(item, newSignal, 2, false, ft, runs(success, success)); !ok {
+		t.Fatalf("input is dropped after successful runs")
+	}
+	got := ft.collect()
+	if len(got) != 1 || got[0] != (rpctype.CallFlakeStats{ID: id, Triaged: 3, Flaky: 2, Signal: 6, Unstable: 4}) {
+		t.Fatalf("bad triage stats: %+v", got)
+	}
+}
+
+func TestMutantCredit(t *testing.T) {
+	// Several calls of a mutant can give new inputs, but the mutant is credited once.
+	m := &mutant{}
+	if !m.credit() {
+		t.Fatalf("the mutant is not credited")
+	}
+	if m.credit() {
+		t.Fatalf("the mutant is credited twice")
+	}
+	var generated *mutant
+	if generated.credit() {
+		t.Fatalf("a program that was not mutated is credited")
+	}
+}
+
+func TestSeedRunVariants(t *testing.T) {
+	opts := &ipc.ExecOpts{Flags: ipc.FlagCollectSignal | ipc.FlagThreaded}
+	runs := seedRunVariants(opts)
+	if len(runs) != seedExecutions || runs[0].opts != opts || runs[0].collide ||
+		runs[1].opts.Flags != ipc.FlagCollectSignal || runs[1].collide ||
+		runs[2].opts != opts || !runs[2].collide {
+		t.Fatalf("bad threaded seed runs: %+v", runs)
+	}
+	if opts.Flags != ipc.FlagCollectSignal|ipc.FlagThreaded {
+		t.Fatalf("default options are changed: %v", opts.Flags)
+	}
+	opts = &ipc.ExecOpts{Flags: ipc.FlagCollectSignal}
+	runs = seedRunVariants(opts)
+	if len(runs) != seedExecutions {
+		t.Fatalf("got %v non-threaded seed runs, want %v", len(runs), seedExecutions)
+	}
+	for _, run := range runs {
+		if run.opts != opts || run.collide {
+			t.Fatalf("bad non-threaded seed runs: %+v", runs)
+		}
+	}
+}
+
+func TestSeedCandidates(t *testing.T) {
+	target := getTarget(t, targets.TestOS, targets.TestArch64)
+	needCandidates := make(chan struct{}, 1)
+	fuzzer := &Fuzzer{target: target, workQueue: newWorkQueue(1, needCandidates)}
+	fuzzer.addCandidateInput(rpctype.Candidate{Prog: []byte("test$res0()\n"), Seed: true, Source: "enrich"})
+	fuzzer.addCandidateInput(rpctype.Candidate{Prog: []byte("test$res1(0x0)\n"), Minimized: true})
+	fuzzer.workQueue.enqueue(&WorkTriage{})
+	// Seeds are handled after candidates, but before triage of new inputs.
+	if _, ok := fuzzer.workQueue.dequeue().(*WorkCandidate); !ok {
+		t.Fatalf("candidate is not dequeued first")
+	}
+	seed, ok := fuzzer.workQueue.dequeue().(*WorkSeed)
+	if !ok {
+		t.Fatalf("seed is not dequeued second")
+	}
+	if string(seed.p.Serialize()) != "test$res0()\n" || seed.flags != ProgCandidate || seed.origin != "enrich" {
+		t.Fatalf("bad seed: %+v", seed)
+	}
+	select {
+	case <-needCandidates:
+	default:
+		t.Fatalf("no candidates are requested after the last seed")
+	}
+	if _, ok := fuzzer.workQueue.dequeue().(*WorkTriage); !ok {
+		t.Fatalf("triage is not dequeued last")
+	}
+}
+
+func TestSeedStats(t *testing.T) {
+	s := &seedStats{seeds: 4, newSignal: 1, bursts: 3, burstNewSignal: 2}
+	stats := map[string]uint64{"ext seeds": 1}
+	s.collectStats(stats)
+	want := map[string]uint64{
+		"ext seeds":                  5,
+		"ext seeds new signal":       1,
+		"ext seed bursts":            3,
+		"ext seed bursts new signal": 2,
+	}
+	if !reflect.DeepEqual(stats, want) {
+		t.Fatalf("got stats %v, want %v", stats, want)
+	}
+	if *s != (seedStats{}) {
+		t.Fatalf("stats are not reset: %+v", *s)
+	}
+}
+
+func newTestSchedule(t *testing.T, name string) *powerSchedule {
+	s, err := newPowerSchedule(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+// newTestFuzzer returns a fuzzer for the test target with all generatable syscalls enabled
+// and an empty corpus.
+func newTestFuzzer(t *testing.T, target *prog.Target) *Fuzzer {
+	var enabled []int
+	for _, c := range target.Syscalls {
+		if !c.Attrs.Disabled && !c.Attrs.NoGenerate {
+			enabled = append(enabled, c.ID)
+		}
+	}
+	fuzzer := &Fuzzer{
+		target:       target,
+		config:       &ipc.Config{},
+		checkResult:  &rpctype.CheckArgs{EnabledCalls: map[string][]int{"none": enabled}},
+		corpusHashes: make(map[hash.Sig]struct{}),
+		schedule:     newTestSchedule(t, ""),
+		slowCalls:    newSlowCallTracker(targets.Get(target.OS, target.Arch).Timeouts(1)),
+	}
+	fuzzer.choiceTable = target.BuildChoiceTable(nil, fuzzer.enabledCalls())
+	return fuzzer
+}
+
 func generateInput(target *prog.Target, rs rand.Source, ncalls, sizeSig int) (inp InputTest) {
 	inp.p = target.Generate(rs, ncalls, target.DefaultChoiceTable())
 	var raw []uint32
@@ -0,0 +1,281 @@
+// Copyright 2026 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+
+	"github.com/google/syzkaller/pkg/signal"
+	"github.com/google/syzkaller/prog"
+)
+
+// powerSchedule chooses corpus programs for mutation with probability proportional to their energy.
+// Energies are assigned and updated by the schedule policy (see powerPolicies),
+// all updates are incremental and cost O(log(corpus size)) per changed energy.
+// Energies change only when new inputs are added, so choose (called for every mutation)
+// takes only the read lock.
+type powerSchedule struct {
+	// Totals since the last poll of the manager, statMutations is updated atomically
+	// (it goes first to be 64-bit aligned on 32-bit platforms).
+	statMutations uint64
+	statNewInputs uint64
+
+	mu     sync.RWMutex
+	name   string
+	policy powerPolicy
+	progs  []*prog.Prog
+	index  map[*prog.Prog]int
+	energy energyTree
+}
+
+// powerPolicy assigns energies to corpus programs.
+type powerPolicy interface {
+	// added is called when the program idx with the signal is added to the corpus,
+	// it must append energy of the new program to s.energy.
+	added(s *powerSchedule, idx int, sign signal.Signal)
+	// newInput is called when a mutant of the program idx was added to the corpus.
+	newInput(s *powerSchedule, idx int)
+}
+
+// powerPolicies are the supported power schedules, see mgrconfig.Config.PowerSchedule.
+var powerPolicies = map[string]func() powerPolicy{
+	"signal":     func() powerPolicy { return signalPolicy{} },
+	"rare":       func() powerPolicy { return &rarePolicy{elems: make(map[uint32][]int)} },
+	"recent":     func() powerPolicy { return &recentPolicy{} },
+	"productive": func() powerPolicy { return &productivePolicy{} },
+}
+
+func newPowerSchedule(name string) (*powerSchedule, error) {
+	if name == "" {
+		name = "signal"
+	}
+	policy := powerPolicies[name]
+	if policy == nil {
+		return nil, fmt.Errorf("unknown power schedule %q", name)
+	}
+	return &powerSchedule{
+		name:   name,
+		policy: policy(),
+		index:  make(map[*prog.Prog]int),
+	}, nil
+}
+
+func (s *powerSchedule) add(p *prog.Prog, sign signal.Signal) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.index[p] = len(s.progs)
+	s.progs = append(s.progs, p)
+	s.policy.added(s, len(s.progs)-1, sign)
+}
+
+func (s *powerSchedule) choose(r *rand.Rand) *prog.Prog {
+	atomic.AddUint64(&s.statMutations, 1)
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.progs[s.energy.choose(r.Float64()*s.energy.total())]
+}
+
+// newInput records that a mutant of the corpus program p was added to the corpus.
+func (s *powerSchedule) newInput(p *prog.Prog) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.statNewInputs++
+	if idx, ok := s.index[p]; ok {
+		s.policy.newInput(s, idx)
+	}
+}
+
+// collectStats adds stats accumulated since the previous call to stats.
+func (s *powerSchedule) collectStats(stats map[string]uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stats["schedule mutations"] += atomic.SwapUint64(&s.statMutations, 0)
+	stats["schedule new inputs"] += s.statNewInputs
+	s.statNewInputs = 0
+}
+
+// signalEnergy is the base energy of a program: the size of its signal.
+func signalEnergy(sign signal.Signal) float64 {
+	if sign.Empty() {
+		return 1
+	}
+	return float64(sign.Len())
+}
+
+// signalPolicy favors programs with larger signal, energies never change.
+type signalPolicy struct{}
+
+func (signalPolicy) added(s *powerSchedule, idx int, sign signal.Signal) {
+	s.energy.append(signalEnergy(sign))
+}
+
+func (signalPolicy) newInput(s *powerSchedule, idx int) {}
+
+// rarePolicy favors programs that cover rare signal: energy of a program is 1 + the number of its
+// signal elements that are covered by at most rareThreshold corpus programs.
+// An element stops being rare only once, so the total number of energy updates
+// is bounded by rareThreshold per element.
+type rarePolicy struct {
+	// Programs that cover the signal element, nil if the element is not rare anymore.
+	elems map[uint32][]int
+	rare  []int
+}
+
+const rareThreshold = 4
+
+func (pol *rarePolicy) added(s *powerSchedule, idx int, sign signal.Signal) {
+	pol.rare = append(pol.rare, 0)
+	s.energy.append(1)
+	for e := range sign {
+		progs, ok := pol.elems[uint32(e)]
+		if ok && progs == nil {
+			continue
+		}
+		if len(progs) < rareThreshold {
+			pol.elems[uint32(e)] = append(progs, idx)
+			pol.rare[idx]++
+			continue
+		}
+		pol.elems[uint32(e)] = nil
+		for _, idx1 := range progs {
+			pol.rare[idx1]--
+			s.energy.set(idx1, float64(1+pol.rare[idx1]))
+		}
+	}
+	s.energy.set(idx, float64(1+pol.rare[idx]))
+}
+
+func (pol *rarePolicy) newInput(s *powerSchedule, idx int) {}
+
+// recentPolicy favors recently added programs: energy of a program is its signal energy
+// multiplied by 2^(idx/recentHalfLife), i.e. it halves with every recentHalfLife newer programs.
+type recentPolicy struct {
+	base []float64
+	// Index of the program with the multiplier of 1, it's moved forward to avoid overflows.
+	origin int
+}
+
+const (
+	recentHalfLife = 1000
+	// All energies are rescaled once the maximal multiplier reaches 2^recentMaxExp.
+	recentMaxExp = 64
+)
+
+func (pol *recentPolicy) added(s *powerSchedule, idx int, sign signal.Signal) {
+	pol.base = append(pol.base, signalEnergy(sign))
+	if idx-pol.origin >= recentMaxExp*recentHalfLife {
+		pol.origin = idx
+		energies := make([]float64, idx)
+		for i := range energies {
+			energies[i] = pol.energy(i)
+		}
+		s.energy.reset(energies)
+	}
+	s.energy.append(pol.energy(idx))
+}
+
+func (pol *recentPolicy) energy(idx int) float64 {
+	return pol.base[idx] * math.Exp2(float64(idx-pol.origin)/recentHalfLife)
+}
+
+func (pol *recentPolicy) newInput(s *powerSchedule, idx int) {}
+
+// productivePolicy favors programs whose mutations recently gave new inputs: energy of a program
+// is its signal energy multiplied by 1 + the number of new inputs among the last productiveWindow
+// new inputs that were produced by mutating the program.
+type productivePolicy struct {
+	base   []float64
+	count  []int
+	window []int
+	pos    int
+}
+
+const productiveWindow = 1000
+
+func (pol *productivePolicy) added(s *powerSchedule, idx int, sign signal.Signal) {
+	pol.base = append(pol.base, signalEnergy(sign))
+	pol.count = append(pol.count, 0)
+	s.energy.append(pol.base[idx])
+}
+
+func (pol *productivePolicy) newInput(s *powerSchedule, idx int) {
+	if len(pol.window) < productiveWindow {
+		pol.window = append(pol.window, idx)
+	} else {
+		pol.update(s, pol.window[pol.pos], -1)
+		pol.window[pol.pos] = idx
+		pol.pos = (pol.pos + 1) % productiveWindow
+	}
+	pol.update(s, idx, +1)
+}
+
+func (pol *productivePolicy) update(s *powerSchedule, idx, delta int) {
+	pol.count[idx] += delta
+	s.energy.set(idx, pol.base[idx]*float64(1+pol.count[idx]))
+}
+
+// energyTree is a Fenwick tree of energies that supports updates of individual energies
+// and weighted random choice in O(log n).
+type energyTree struct {
+	vals []float64
+	tree []float64 // tree[i-1] holds the sum of vals in (i-lowbit(i), i]
+}
+
+func (t *energyTree) append(v float64) {
+	i := len(t.tree) + 1
+	sum := v
+	for j := i - 1; j > i-i&-i; j -= j & -j {
+		sum += t.tree[j-1]
+	}
+	t.vals = append(t.vals, v)
+	t.tree = append(t.tree, sum)
+}
+
+func (t *energyTree) set(idx int, v float64) {
+	delta := v - t.vals[idx]
+	t.vals[idx] = v
+	for i := idx + 1; i <= len(t.tree); i += i & -i {
+		t.tree[i-1] += delta
+	}
+}
+
+func (t *energyTree) reset(vals []float64) {
+	t.vals, t.tree = nil, nil
+	for _, v := range vals {
+		t.append(v)
+	}
+}
+
+func (t *energyTree) total() float64 {
+	sum := 0.0
+	for i := len(t.tree); i > 0; i -= i & -i {
+		sum += t.tree[i-1]
+	}
+	return sum
+}
+
+// choose returns index of the element where the prefix sum of energies crosses x.
+func (t *energyTree) choose(x float64) int {
+	n := len(t.tree)
+	step := 1
+	for step*2 <= n {
+		step *= 2
+	}
+	pos := 0
+	for ; step > 0; step /= 2 {
+		if pos+step <= n && t.tree[pos+step-1] <= x {
+			pos += step
+			x -= t.tree[pos-1]
+		}
+	}
+	// Rounding errors can push us past the last element.
+	if pos >= n {
+		pos = n - 1
+	}
+	return pos
+}
@@ -5,6 +5,7 @@ package main
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"math/rand"
 	"os"
@@ -17,6 +18,7 @@ import (
 	"github.com/google/syzkaller/pkg/hash"
 	"github.com/google/syzkaller/pkg/ipc"
 	"github.com/google/syzkaller/pkg/log"
+	"github.com/google/syzkaller/pkg/progring"
 	"github.com/google/syzkaller/pkg/rpctype"
 	"github.com/google/syzkaller/pkg/signal"
 	"github.com/google/syzkaller/prog"
@@ -32,6 +34,14 @@ type Proc struct {
 	execOptsCollide *ipc.ExecOpts
 	execOptsCover   *ipc.ExecOpts
 	execOptsComps   *ipc.ExecOpts
+	scheduler       *mutationScheduler
+	// Mutant that is being executed now, and its trace.
+	mutant *mutant
+	trace  *prog.Trace
+	// The candidate that is being executed now (see candidateTriage).
+	candidate *candidateTriage
+	// Recently executed programs, dumped to the console on executor failures, nil if disabled.
+	recent *progring.Ring
 }
 
 func newProc(fuzzer *Fuzzer, pid int) (*Proc, error) {
@@ -55,6 +65,10 @@ func newProc(fuzzer *Fuzzer, pid int) (*Proc, error) {
 		execOptsCollide: &execOptsCollide,
 		execOptsCover:   &execOptsCover,
 		execOptsComps:   &execOptsComps,
+		scheduler:       newMutationScheduler(),
+	}
+	if fuzzer.recentProgs > 0 {
+		proc.recent = progring.New(fuzzer.recentProgs)
 	}
 	return proc, nil
 }
@@ -73,7 +87,15 @@ func (proc *Proc) loop() {
 			case *WorkTriage:
 				proc.triageInput(item)
 			case *WorkCandidate:
-				proc.execute(proc.execOpts, item.p, item.flags, StatCandidate)
+				proc.candidate = item.candidate
+				proc.executeOrigin(proc.execOpts, item.p, item.flags, StatCandidate, item.origin)
+				proc.candidate = nil
+				proc.fuzzer.candidateDone(item.candidate)
+			case *WorkSeed:
+				proc.candidate = item.candidate
+				proc.seedInput(item)
+				proc.candidate = nil
+				proc.fuzzer.candidateDone(item.candidate)
 			case *WorkSmash:
 				proc.smashInput(item)
 			default:
@@ -82,18 +104,31 @@ func (proc *Proc) loop() {
 			continue
 		}
 
-		ct := proc.fuzzer.choiceTable
+		ct := proc.fuzzer.currentChoiceTable()
 		fuzzerSnapshot := proc.fuzzer.snapshot()
-		if len(fuzzerSnapshot.corpus) == 0 || i%generatePeriod == 0 {
+		if templates := proc.fuzzer.templates; len(templates) != 0 && i%generatePeriod == 0 && proc.rnd.Intn(2) == 0 {
+			// Half of the new progs are generated from templates, if there are any.
+			tmpl := templates[proc.rnd.Intn(len(templates))]
+			p := proc.fuzzer.target.GenerateFromTemplate(proc.rnd, tmpl, ct)
+			log.Logf(1, "#%v: generated from template", proc.pid)
+			proc.executeAndCollide(proc.execOpts, p, ProgNormal, StatTemplate)
+		} else if len(fuzzerSnapshot.corpus) == 0 || i%generatePeriod == 0 {
 			// Generate a new prog.
-			p := proc.fuzzer.target.Generate(proc.rnd, prog.RecommendedCalls, ct)
+			var p *prog.Prog
+			if proc.fuzzer.traceMutations {
+				p, proc.trace = proc.fuzzer.target.GenerateTraced(proc.rnd.Int63(), prog.RecommendedCalls, ct)
+			} else {
+				p = proc.fuzzer.target.Generate(proc.rnd, prog.RecommendedCalls, ct)
+			}
 			log.Logf(1, "#%v: generated", proc.pid)
 			proc.executeAndCollide(proc.execOpts, p, ProgNormal, StatGenerate)
 		} else {
 			// Mutate an existing prog.
-			p := fuzzerSnapshot.chooseProgram(proc.rnd).Clone()
-			p.Mutate(proc.rnd, prog.RecommendedCalls, ct, proc.fuzzer.noMutate, fuzzerSnapshot.corpus)
+			parent := fuzzerSnapshot.chooseProgram(proc.rnd)
+			p := parent.Clone()
+			proc.mutate(p, ct, fuzzerSnapshot)
 			log.Logf(1, "#%v: mutated", proc.pid)
+			proc.mutant.parent = parent
 			proc.executeAndCollide(proc.execOpts, p, ProgNormal, StatFuzz)
 		}
 	}
@@ -101,6 +136,7 @@ func (proc *Proc) loop() {
 
 func (proc *Proc) triageInput(item *WorkTriage) {
 	log.Logf(1, "#%v: triaging type=%x", proc.pid, item.flags)
+	defer proc.fuzzer.candidateDone(item.candidate)
 
 	prio := signalPrio(item.p, &item.info, item.call)
 	inputSignal := signal.FromRaw(item.info.Signal, prio)
@@ -115,55 +151,43 @@ func (proc *Proc) triageInput(item *WorkTriage) {
 		logCallName = fmt.Sprintf("call #%v %v", item.call, callName)
 	}
 	log.Logf(3, "triaging input for %v (new signal=%v)", logCallName, newSignal.Len())
-	var inputCover cover.Cover
-	const (
-		signalRuns       = 3
-		minimizeAttempts = 3
-	)
+	const minimizeAttempts = 3
 	// Compute input coverage and non-flaky signal for minimization.
-	notexecuted := 0
-	rawCover := []uint32{}
-	for i := 0; i < signalRuns; i++ {
-		info := proc.executeRaw(proc.execOptsCover, item.p, StatTriage)
-		if !reexecutionSuccess(info, &item.info, item.call) {
-			// The call was not executed or failed.
-			notexecuted++
-			if notexecuted > signalRuns/2+1 {
-				return // if happens too often, give up
+	signalRuns := proc.fuzzer.flakes.triageRuns(item.p, item.call)
+	newSignal, inputCover, rawCover, ok := triageSignal(item, newSignal, signalRuns, proc.fuzzer.fetchRawCover,
+		proc.fuzzer.flakes, func() *ipc.ProgInfo {
+			return proc.executeRaw(proc.execOptsCover, item.p, StatTriage)
+		})
+	if !ok {
+		return
+	}
+	// Calls and arguments of templates are fixed, and holes change with every mutation anyway.
+	if item.flags&ProgMinimized == 0 && !item.p.IsTemplate() {
+		pred := func(p1 *prog.Prog, call1 int) bool {
+			for i := 0; i < minimizeAttempts; i++ {
+				info := proc.execute(proc.execOpts, p1, ProgNormal, StatMinimize)
+				if !reexecutionSuccess(info, &item.info, call1) {
+					// The call was not executed or failed.
+					continue
+				}
+				thisSignal, _ := getSignalAndCover(p1, info, call1)
+				if newSignal.Intersection(thisSignal).Len() == newSignal.Len() {
+					return true
+				}
 			}
-			continue
-		}
-		thisSignal, thisCover := getSignalAndCover(item.p, info, item.call)
-		if len(rawCover) == 0 && proc.fuzzer.fetchRawCover {
-			rawCover = append([]uint32{}, thisCover...)
+			return false
 		}
-		newSignal = newSignal.Intersection(thisSignal)
-		// Without !minimized check manager starts losing some considerable amount
-		// of coverage after each restart. Mechanics of this are not completely clear.
-		if newSignal.Empty() && item.flags&ProgMinimized == 0 {
-			return
+		if item.trace != nil {
+			// The trace is shared by all triage items of the program, so it's copied and extended
+			// to describe the minimized program that is added to the corpus.
+			item.p, item.call, item.trace = prog.MinimizeTraced(item.p, item.call, item.trace, pred)
+		} else {
+			item.p, item.call = prog.Minimize(item.p, item.call, false, pred)
 		}
-		inputCover.Merge(thisCover)
-	}
-	if item.flags&ProgMinimized == 0 {
-		item.p, item.call = prog.Minimize(item.p, item.call, false,
-			func(p1 *prog.Prog, call1 int) bool {
-				for i := 0; i < minimizeAttempts; i++ {
-					info := proc.execute(proc.execOpts, p1, ProgNormal, StatMinimize)
-					if !reexecutionSuccess(info, &item.info, call1) {
-						// The call was not executed or failed.
-						continue
-					}
-					thisSignal, _ := getSignalAndCover(p1, info, call1)
-					if newSignal.Intersection(thisSignal).Len() == newSignal.Len() {
-						return true
-					}
-				}
-				return false
-			})
 	}
 
-	data := item.p.Serialize()
+	// Holes are local to the fuzzer (see prog.Prog.WithoutHoles).
+	data := item.p.WithoutHoles().Serialize()
 	sig := hash.Hash(data)
 
 	coverCalls := make(map[string]struct{})
@@ -173,24 +197,90 @@ func (proc *Proc) triageInput(item *WorkTriage) {
 		coverCalls[name] = struct{}{}
 	}
 
+	var trace []byte
+	if item.trace != nil {
+		var err error
+		if trace, err = json.Marshal(item.trace); err != nil {
+			log.SyzFatalf("failed to serialize trace: %v", err)
+		}
+	}
 	log.Logf(2, "added new input for %v to corpus:\n%s", logCallName, data)
+	cov := inputCover.Serialize()
+	dist := proc.fuzzer.directed.distance(cov)
 	proc.fuzzer.sendInputToManager(rpctype.Input{
-		Call:       callName,
-		CallID:     item.call,
-		Prog:       data,
-		Signal:     inputSignal.Serialize(),
-		Cover:      inputCover.Serialize(),
-		RawCover:   rawCover,
-		CoverCalls: coverCalls,
+		Call:             callName,
+		CallID:           item.call,
+		Prog:             data,
+		Signal:           inputSignal.Serialize(),
+		Cover:            cov,
+		RawCover:         rawCover,
+		CoverCalls:       coverCalls,
+		Origin:           item.origin,
+		Trace:            trace,
+		DirectedDistance: dist,
 	})
 
-	proc.fuzzer.addInputToCorpus(item.p, inputSignal, sig)
+	proc.fuzzer.addInputToCorpus(item.p, inputSignal, sig, dist)
+	if m := item.mutant; m.credit() {
+		m.scheduler.credit(m.mutation.Ops)
+		proc.fuzzer.callPairs.newInput(m.mutation.Insertions)
+		if m.parent != nil {
+			proc.fuzzer.currentSchedule().newInput(m.parent)
+		}
+	}
+	proc.fuzzer.focus.newInput(item.p)
+	proc.fuzzer.directed.newInput(dist)
+	proc.fuzzer.slowCalls.newInput(item.p, item.call)
 
 	if item.flags&ProgSmashed == 0 {
 		proc.fuzzer.workQueue.enqueue(&WorkSmash{item.p, item.call})
 	}
 }
 
+// triageSignal executes the input runs times with exec to find its stable new signal and collect its coverage.
+// It returns false if the input must be dropped: the call was not executed or failed in more than
+// runs/2+1 runs, or none of the new signal is stable.
+// The outcome is recorded in flakes, dropped inputs and inputs with failed runs count as flaky.
+func triageSignal(item *WorkTriage, newSignal signal.Signal, runs int, fetchRawCover bool, flakes *flakeTracker,
+	exec func() *ipc.ProgInfo) (signal.Signal, cover.Cover, []uint32, bool) {
+	var inputCover cover.Cover
+	initialSignal := newSignal.Len()
+	notexecuted := 0
+	rawCover := []uint32{}
+	for i := 0; i < runs; i++ {
+		info := exec()
+		if !reexecutionSuccess(info, &item.info, item.call) {
+			// The call was not executed or failed.
+			notexecuted++
+			if notexecuted > runs/2+1 {
+				// If happens too often, give up.
+				flakes.record(item.p, item.call, initialSignal, 0)
+				return nil, nil, nil, false
+			}
+			continue
+		}
+		thisSignal, thisCover := getSignalAndCover(item.p, info, item.call)
+		if len(rawCover) == 0 && fetchRawCover {
+			rawCover = append([]uint32{}, thisCover...)
+		}
+		newSignal = newSignal.Intersection(thisSignal)
+		// Without !minimized check manager starts losing some considerable amount
+		// of coverage after each restart. Mechanics of this are not completely clear.
+		if newSignal.Empty() && item.flags&ProgMinimized == 0 {
+			flakes.record(item.p, item.call, initialSignal, 0)
+			return nil, nil, nil, false
+		}
+		inputCover.Merge(thisCover)
+	}
+	stable := newSignal.Len()
+	if notexecuted != 0 {
+		// The new signal was not reproduced by the failed runs.
+		stable = 0
+	}
+	flakes.record(item.p, item.call, initialSignal, stable)
+	return newSignal, inputCover, rawCover, true
+}
+
 func reexecutionSuccess(info *ipc.ProgInfo, oldInfo *ipc.CallInfo, call int) bool {
 	if info == nil || len(info.Calls) == 0 {
 		return false
@@ -218,18 +308,35 @@ func (proc *Proc) smashInput(item *WorkSmash) {
 	if proc.fuzzer.faultInjectionEnabled && item.call != -1 {
 		proc.failCall(item.p, item.call)
 	}
-	if proc.fuzzer.comparisonTracingEnabled && item.call != -1 {
+	iters, hints := proc.fuzzer.focus.smashBudget(item.p, 100, proc.rnd)
+	iters = proc.fuzzer.directed.smashBudget(item.p, iters)
+	if proc.fuzzer.comparisonTracingEnabled && item.call != -1 && hints {
 		proc.executeHintSeed(item.p, item.call)
 	}
 	fuzzerSnapshot := proc.fuzzer.snapshot()
-	for i := 0; i < 100; i++ {
+	ct := proc.fuzzer.currentChoiceTable()
+	for i := 0; i < iters; i++ {
 		p := item.p.Clone()
-		p.Mutate(proc.rnd, prog.RecommendedCalls, proc.fuzzer.choiceTable, proc.fuzzer.noMutate, fuzzerSnapshot.corpus)
+		proc.mutate(p, ct, fuzzerSnapshot)
 		log.Logf(1, "#%v: smash mutated", proc.pid)
 		proc.executeAndCollide(proc.execOpts, p, ProgNormal, StatSmash)
 	}
 }
 
+func (proc *Proc) mutate(p *prog.Prog, ct *prog.ChoiceTable, fuzzerSnapshot FuzzerSnapshot) {
+	var mutation prog.Mutation
+	if proc.fuzzer.traceMutations {
+		mutation, proc.trace = p.MutateTraced(proc.rnd.Int63(), prog.RecommendedCalls, ct, proc.fuzzer.noMutate,
+			fuzzerSnapshot.corpus, proc.scheduler)
+	} else {
+		mutation = p.MutateWithScheduler(proc.rnd, prog.RecommendedCalls, ct, proc.fuzzer.noMutate,
+			fuzzerSnapshot.corpus, proc.scheduler)
+	}
+	proc.mutant = &mutant{scheduler: proc.scheduler, mutation: mutation}
+	proc.scheduler.used(mutation.Ops)
+	proc.fuzzer.callPairs.inserted(mutation.Insertions)
+}
+
 func (proc *Proc) failCall(p *prog.Prog, call int) {
 	for nth := 1; nth <= 100; nth++ {
 		log.Logf(1, "#%v: injecting fault into call %v/%v", proc.pid, call, nth)
@@ -253,6 +360,15 @@ func (proc *Proc) executeHintSeed(p *prog.Prog, call int) {
 	// Then mutate the initial program for every match between
 	// a syscall argument and a comparison operand.
 	// Execute each of such mutants to check if it gives new coverage.
+	if proc.fuzzer.traceMutations {
+		p.MutateWithHintsTraced(call, info.Calls[call].Comps, func(p *prog.Prog, trace *prog.Trace) {
+			log.Logf(1, "#%v: executing comparison hint", proc.pid)
+			proc.trace = trace
+			proc.execute(proc.execOpts, p, ProgNormal, StatHint)
+			proc.trace = nil
+		})
+		return
+	}
 	p.MutateWithHints(call, info.Calls[call].Comps, func(p *prog.Prog) {
 		log.Logf(1, "#%v: executing comparison hint", proc.pid)
 		proc.execute(proc.execOpts, p, ProgNormal, StatHint)
@@ -260,36 +376,59 @@ func (proc *Proc) executeHintSeed(p *prog.Prog, call int) {
 }
 
 func (proc *Proc) execute(execOpts *ipc.ExecOpts, p *prog.Prog, flags ProgTypes, stat Stat) *ipc.ProgInfo {
+	return proc.executeOrigin(execOpts, p, flags, stat, stat.origin())
+}
+
+// executeOrigin is like execute, but attributes inputs with new signal to the given origin.
+func (proc *Proc) executeOrigin(execOpts *ipc.ExecOpts, p *prog.Prog, flags ProgTypes, stat Stat,
+	origin string) *ipc.ProgInfo {
+	info, _ := proc.executeCheck(execOpts, p, flags, stat, origin)
+	return info
+}
+
+// executeCheck is like executeOrigin, but also says if the program gave new signal.
+func (proc *Proc) executeCheck(execOpts *ipc.ExecOpts, p *prog.Prog, flags ProgTypes, stat Stat,
+	origin string) (*ipc.ProgInfo, bool) {
 	info := proc.executeRaw(execOpts, p, stat)
 	if info == nil {
-		return nil
+		return nil, false
 	}
 	calls, extra := proc.fuzzer.checkNewSignal(p, info)
 	for _, callIndex := range calls {
-		proc.enqueueCallTriage(p, flags, callIndex, info.Calls[callIndex])
+		proc.enqueueCallTriage(p, flags, origin, callIndex, info.Calls[callIndex])
 	}
 	if extra {
-		proc.enqueueCallTriage(p, flags, -1, info.Extra)
+		proc.enqueueCallTriage(p, flags, origin, -1, info.Extra)
 	}
-	return info
+	return info, len(calls) != 0 || extra
 }
 
-func (proc *Proc) enqueueCallTriage(p *prog.Prog, flags ProgTypes, callIndex int, info ipc.CallInfo) {
+func (proc *Proc) enqueueCallTriage(p *prog.Prog, flags ProgTypes, origin string, callIndex int,
+	info ipc.CallInfo) {
 	// info.Signal points to the output shmem region, detach it before queueing.
 	info.Signal = append([]uint32{}, info.Signal...)
 	// None of the caller use Cover, so just nil it instead of detaching.
 	// Note: triage input uses executeRaw to get coverage.
 	info.Cover = nil
+	if proc.candidate != nil {
+		atomic.AddInt32(&proc.candidate.refs, 1)
+	}
 	proc.fuzzer.workQueue.enqueue(&WorkTriage{
-		p:     p.Clone(),
-		call:  callIndex,
-		info:  info,
-		flags: flags,
+		p:         p.Clone(),
+		call:      callIndex,
+		info:      info,
+		flags:     flags,
+		origin:    origin,
+		mutant:    proc.mutant,
+		trace:     proc.trace,
+		candidate: proc.candidate,
 	})
 }
 
 func (proc *Proc) executeAndCollide(execOpts *ipc.ExecOpts, p *prog.Prog, flags ProgTypes, stat Stat) {
 	proc.execute(execOpts, p, flags, stat)
+	// The mutant and the trace describe only p, not its collide variants.
+	proc.mutant, proc.trace = nil, nil
 
 	if proc.execOptsCollide.Flags&ipc.FlagThreaded == 0 {
 		// We cannot collide syscalls without being in the threaded mode.
@@ -330,7 +469,20 @@ func (proc *Proc) executeRaw(opts *ipc.ExecOpts, p *prog.Prog, stat Stat) *ipc.P
 	ticket := proc.fuzzer.gate.Enter()
 	defer proc.fuzzer.gate.Leave(ticket)
 
-	proc.logProgram(opts, p)
+	var data []byte
+	if proc.fuzzer.outputType != OutputNone {
+		data = p.WithoutHoles().Serialize()
+	}
+	if proc.recent != nil {
+		if data != nil {
+			proc.recent.Add(progring.Serialized(data))
+		} else {
+			// Callers may change p after execution (e.g. hints do), so it's cloned.
+			// Cloning is cheaper than serialization, which is done only if the programs are dumped.
+			proc.recent.Add(p.Clone().WithoutHoles())
+		}
+	}
+	proc.logProgram(opts, data)
 	for try := 0; ; try++ {
 		atomic.AddUint64(&proc.fuzzer.stats[stat], 1)
 		output, info, hanged, err := proc.env.Exec(opts, p)
@@ -342,6 +494,9 @@ func (proc *Proc) executeRaw(opts *ipc.ExecOpts, p *prog.Prog, stat Stat) *ipc.P
 				atomic.AddUint64(&proc.fuzzer.stats[StatBufferTooSmall], 1)
 				return nil
 			}
+			if try == 0 {
+				proc.dumpRecent()
+			}
 			if try > 10 {
 				log.SyzFatalf("executor %v failed %v times: %v", proc.pid, try, err)
 			}
@@ -351,26 +506,48 @@ func (proc *Proc) executeRaw(opts *ipc.ExecOpts, p *prog.Prog, stat Stat) *ipc.P
 			continue
 		}
 		log.Logf(2, "result hanged=%v: %s", hanged, output)
+		proc.fuzzer.callResults.record(p, info)
+		proc.fuzzer.slowCalls.record(p, info)
 		return info
 	}
 }
 
-func (proc *Proc) logProgram(opts *ipc.ExecOpts, p *prog.Prog) {
-	if proc.fuzzer.outputType == OutputNone {
+// dumpRecent prints recently executed programs so that the manager can extract them from the console
+// output if the executor failure is followed by a crash (see progring.Extract).
+func (proc *Proc) dumpRecent() {
+	if proc.recent == nil {
 		return
 	}
+	proc.fuzzer.logMu.Lock()
+	defer proc.fuzzer.logMu.Unlock()
+	if err := proc.recent.Dump(os.Stdout, proc.pid); err != nil {
+		log.Logf(0, "failed to dump recent programs: %v", err)
+	}
+}
 
-	data := p.Serialize()
+func (proc *Proc) logProgram(opts *ipc.ExecOpts, data []byte) {
+	if proc.fuzzer.outputType == OutputNone {
+		return
+	}
 
 	// The following output helps to understand what program crashed kernel.
 	// It must not be intermixed.
 	switch proc.fuzzer.outputType {
 	case OutputStdout:
 		now := time.Now()
+		trace := ""
+		if proc.trace != nil {
+			// Traces can be extracted from execution logs with prog.ParseLog.
+			data, err := json.Marshal(proc.trace)
+			if err != nil {
+				log.SyzFatalf("failed to serialize trace: %v", err)
+			}
+			trace = fmt.Sprintf(" [trace %s]", data)
+		}
 		proc.fuzzer.logMu.Lock()
-		fmt.Printf("%02v:%02v:%02v executing program %v:\n%s\n",
+		fmt.Printf("%02v:%02v:%02v executing program %v%v:\n%s\n",
 			now.Hour(), now.Minute(), now.Second(),
-			proc.pid, data)
+			proc.pid, trace, data)
 		proc.fuzzer.logMu.Unlock()
 	case OutputDmesg:
 		fd, err := syscall.Open("/dev/kmsg", syscall.O_WRONLY, 0)
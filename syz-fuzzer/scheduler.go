@@ -0,0 +1,106 @@
+// Copyright 2026 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"sync"
+
+	"github.com/google/syzkaller/prog"
+)
+
+// mutationScheduler adaptively chooses mutation operators (in the spirit of MOpt).
+// Operators are credited when a mutant they produced is added to the corpus,
+// and every schedulerPeriod mutations weights are moved towards per-operator success rates.
+// Each proc has own scheduler, but triage of a mutant can happen on another proc.
+type mutationScheduler struct {
+	mu        sync.Mutex
+	weights   []int
+	mutations int
+	applied   [prog.MutationOpCount]uint64 // since the last weights update
+	succeeded [prog.MutationOpCount]uint64
+	// Totals since the last poll of the manager.
+	statApplied   [prog.MutationOpCount]uint64
+	statSucceeded [prog.MutationOpCount]uint64
+}
+
+const (
+	schedulerPeriod = 1000
+	// Sum of all weights after an update.
+	schedulerTotalWeight = 1000
+	// Minimal weight so that no operator is excluded completely
+	// (its efficiency may change as the corpus evolves).
+	schedulerMinWeight = 10
+)
+
+func newMutationScheduler() *mutationScheduler {
+	return &mutationScheduler{
+		weights: append([]int{}, prog.DefaultMutationWeights...),
+	}
+}
+
+func (s *mutationScheduler) Weights() []int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// The slice is replaced rather than updated in place, so it's safe to return it.
+	return s.weights
+}
+
+// used records operators applied to produce a mutant.
+func (s *mutationScheduler) used(ops []prog.MutationOp) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, op := range ops {
+		s.applied[op]++
+		s.statApplied[op]++
+	}
+	s.mutations++
+	if s.mutations >= schedulerPeriod {
+		s.updateLocked()
+	}
+}
+
+// credit records that a mutant produced with ops was added to the corpus.
+func (s *mutationScheduler) credit(ops []prog.MutationOp) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, op := range ops {
+		s.succeeded[op]++
+		s.statSucceeded[op]++
+	}
+}
+
+func (s *mutationScheduler) updateLocked() {
+	var rates [prog.MutationOpCount]float64
+	sum := 0.0
+	for op := range rates {
+		// Laplace smoothing, so that rarely applied operators don't get extreme rates.
+		rates[op] = float64(s.succeeded[op]+1) / float64(s.applied[op]+2)
+		sum += rates[op]
+	}
+	weights := make([]int, prog.MutationOpCount)
+	for op := range weights {
+		// Move only halfway towards the observed efficiency to avoid oscillations.
+		w := (s.weights[op] + int(schedulerTotalWeight*rates[op]/sum)) / 2
+		if w < schedulerMinWeight {
+			w = schedulerMinWeight
+		}
+		weights[op] = w
+	}
+	s.weights = weights
+	s.mutations = 0
+	s.applied = [prog.MutationOpCount]uint64{}
+	s.succeeded = [prog.MutationOpCount]uint64{}
+}
+
+// collectStats adds per-operator stats accumulated since the previous call to stats.
+func (s *mutationScheduler) collectStats(stats map[string]uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for op := prog.MutationOp(0); op < prog.MutationOpCount; op++ {
+		stats["mutation "+op.String()] += s.statApplied[op]
+		stats["mutation "+op.String()+" new input"] += s.statSucceeded[op]
+	}
+	s.statApplied = [prog.MutationOpCount]uint64{}
+	s.statSucceeded = [prog.MutationOpCount]uint64{}
+}
@@ -0,0 +1,101 @@
+// Copyright 2026 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"sync/atomic"
+
+	"github.com/google/syzkaller/pkg/ipc"
+	"github.com/google/syzkaller/pkg/log"
+)
+
+// Externally sourced seeds are often structurally valuable, but reach new code slightly off-target:
+// a single execution may miss the new signal because of a race, a blocked call or a wrong argument.
+// So seeds are executed several times with varied execution options, and seeds that still don't give
+// new signal receive a bounded burst of mutations before they are dropped.
+// Inputs found by the mutations have the seedBurstOrigin origin, so they are reported separately.
+
+const (
+	// The number of executions of a seed before the burst of mutations.
+	seedExecutions = 3
+	// The number of mutations of a seed that gave no new signal.
+	seedBurstMutations = 20
+	seedBurstOrigin    = "ext seed burst"
+)
+
+type seedStats struct {
+	seeds          uint64 // seeds executed
+	newSignal      uint64 // seeds that gave new signal on one of the runs
+	bursts         uint64 // seeds that got a burst of mutations
+	burstNewSignal uint64 // bursts that gave new signal
+}
+
+func (proc *Proc) seedInput(item *WorkSeed) {
+	stats := &proc.fuzzer.seeds
+	atomic.AddUint64(&stats.seeds, 1)
+	if proc.seedRuns(item) {
+		atomic.AddUint64(&stats.newSignal, 1)
+		return
+	}
+	atomic.AddUint64(&stats.bursts, 1)
+	fuzzerSnapshot := proc.fuzzer.snapshot()
+	ct := proc.fuzzer.currentChoiceTable()
+	for i := 0; i < seedBurstMutations; i++ {
+		p := item.p.Clone()
+		proc.mutate(p, ct, fuzzerSnapshot)
+		log.Logf(1, "#%v: seed burst mutated", proc.pid)
+		_, newSignal := proc.executeCheck(proc.execOpts, p, ProgNormal, StatExtSeedBurst, seedBurstOrigin)
+		proc.mutant, proc.trace = nil, nil
+		if newSignal {
+			atomic.AddUint64(&stats.burstNewSignal, 1)
+			return
+		}
+	}
+}
+
+// seedRuns executes the seed with all seed run variants (see seedRunVariants).
+// It returns whether any of the runs gave new signal.
+func (proc *Proc) seedRuns(item *WorkSeed) bool {
+	newSignal := false
+	for _, run := range seedRunVariants(proc.execOpts) {
+		p, flags := item.p, item.flags
+		if run.collide {
+			// Collided programs differ from the seed, so they need minimization.
+			p, flags = proc.randomCollide(item.p), item.flags&^ProgMinimized
+		}
+		_, runNewSignal := proc.executeCheck(run.opts, p, flags, StatExtSeed, item.origin)
+		newSignal = newSignal || runNewSignal
+	}
+	return newSignal
+}
+
+type seedRun struct {
+	opts    *ipc.ExecOpts
+	collide bool
+}
+
+// seedRunVariants returns seedExecutions variants of seed execution: with the default options,
+// and if the threaded mode is enabled, without it and with random collisions.
+// Without the threaded mode the default options are repeated, since a single execution
+// may miss the new signal anyway.
+func seedRunVariants(opts *ipc.ExecOpts) []seedRun {
+	runs := []seedRun{{opts: opts}}
+	if opts.Flags&ipc.FlagThreaded != 0 {
+		optsNonThreaded := *opts
+		optsNonThreaded.Flags &^= ipc.FlagThreaded
+		runs = append(runs, seedRun{opts: &optsNonThreaded}, seedRun{opts: opts, collide: true})
+	}
+	for len(runs) < seedExecutions {
+		runs = append(runs, seedRun{opts: opts})
+	}
+	return runs
+}
+
+// collectStats adds stats accumulated since the previous call to stats.
+func (s *seedStats) collectStats(stats map[string]uint64) {
+	stats["ext seeds"] += atomic.SwapUint64(&s.seeds, 0)
+	stats["ext seeds new signal"] += atomic.SwapUint64(&s.newSignal, 0)
+	stats["ext seed bursts"] += atomic.SwapUint64(&s.bursts, 0)
+	stats["ext seed bursts new signal"] += atomic.SwapUint64(&s.burstNewSignal, 0)
+}
@@ -0,0 +1,174 @@
+// Copyright 2026 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/syzkaller/pkg/ipc"
+	"github.com/google/syzkaller/pkg/log"
+	"github.com/google/syzkaller/prog"
+	"github.com/google/syzkaller/sys/targets"
+)
+
+// slowCallTracker deprioritizes chronically slow calls in generation and mutation.
+// An execution of a call is slow if it takes at least half of the call timeout (or hits the timeout).
+// A call is deprioritized if at least slowCallShare of its recent executions are slow and it did not give
+// new corpus inputs during its last slowCallQuietExecs executions. Deprioritized calls get the lowest
+// priority in slowCallPriorFactor share of the choice table priorities.
+// While a focus is active, the focus choice table takes precedence over the slow calls choice table
+// (see Fuzzer.currentChoiceTable): the focus calls are requested explicitly, even if they are slow.
+type slowCallTracker struct {
+	timeouts targets.Timeouts
+
+	mu       sync.Mutex
+	calls    map[int]*slowCallStats
+	slow     map[int]bool      // currently deprioritized calls
+	ct       *prog.ChoiceTable // choice table with deprioritized slow calls, nil if there are none
+	lastSwap time.Time
+}
+
+type slowCallStats struct {
+	execs uint64
+	// The number of executions when the call gave the last new corpus input.
+	lastNewInput uint64
+	// Decaying counts of executions and slow executions, both are halved every slowCallWindow executions,
+	// so that the share of slow executions follows the recent behavior of the call.
+	recentExecs uint64
+	recentSlow  uint64
+}
+
+const (
+	slowCallShare       = 0.5
+	slowCallQuietExecs  = 1000
+	slowCallWindow      = 2 * slowCallQuietExecs
+	slowCallPriorFactor = 0.8
+	// The choice table is rebuilt at most once per the period.
+	slowCallUpdatePeriod = 5 * time.Minute
+)
+
+func newSlowCallTracker(timeouts targets.Timeouts) *slowCallTracker {
+	return &slowCallTracker{
+		timeouts: timeouts,
+		calls:    make(map[int]*slowCallStats),
+	}
+}
+
+func (st *slowCallTracker) record(p *prog.Prog, info *ipc.ProgInfo) {
+	if info == nil {
+		return
+	}
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	for i, inf := range info.Calls {
+		if i >= len(p.Calls) || inf.Flags&ipc.CallExecuted == 0 || inf.Flags&ipc.CallFaultInjected != 0 {
+			continue
+		}
+		meta := p.Calls[i].Meta
+		stats := st.calls[meta.ID]
+		if stats == nil {
+			stats = &slowCallStats{}
+			st.calls[meta.ID] = stats
+		}
+		stats.execs++
+		if stats.recentExecs >= slowCallWindow {
+			stats.recentExecs /= 2
+			stats.recentSlow /= 2
+		}
+		stats.recentExecs++
+		if inf.Duration >= callTimeout(meta, st.timeouts)/2 || callTimedOut(meta, &inf, st.timeouts) {
+			stats.recentSlow++
+		}
+	}
+}
+
+// newInput is called when new signal of the call of the program gave a new corpus input.
+func (st *slowCallTracker) newInput(p *prog.Prog, call int) {
+	if call == -1 {
+		return
+	}
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if stats := st.calls[p.Calls[call].Meta.ID]; stats != nil {
+		stats.lastNewInput = stats.execs
+	}
+}
+
+func (stats *slowCallStats) deprioritized() bool {
+	return stats.execs-stats.lastNewInput >= slowCallQuietExecs &&
+		float64(stats.recentSlow) >= slowCallShare*float64(stats.recentExecs)
+}
+
+// choiceTable returns the choice table with deprioritized slow calls, or nil if there are none.
+func (st *slowCallTracker) choiceTable() *prog.ChoiceTable {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	return st.ct
+}
+
+// updateSlowCalls rebuilds the slow calls choice table if the set of deprioritized calls has changed.
+func (fuzzer *Fuzzer) updateSlowCalls() {
+	st := fuzzer.slowCalls
+	st.mu.Lock()
+	if fuzzer.choiceTable == nil || time.Since(st.lastSwap) < slowCallUpdatePeriod {
+		st.mu.Unlock()
+		return
+	}
+	slow := make(map[int]bool)
+	for id, stats := range st.calls {
+		if stats.deprioritized() {
+			slow[id] = true
+		}
+	}
+	same := len(slow) == len(st.slow)
+	for id := range slow {
+		same = same && st.slow[id]
+	}
+	st.mu.Unlock()
+	if same {
+		return
+	}
+	var ct *prog.ChoiceTable
+	var names []string
+	if len(slow) != 0 {
+		syscalls := fuzzer.target.Syscalls
+		row := make(map[string]float64)
+		for _, c := range syscalls {
+			if fuzzer.choiceTable.Generatable(c.ID) {
+				if slow[c.ID] {
+					names = append(names, c.Name)
+				} else {
+					row[c.Name] = 1
+				}
+			}
+		}
+		weights := make(map[string]map[string]float64)
+		for _, c := range syscalls {
+			if fuzzer.choiceTable.Generatable(c.ID) {
+				weights[c.Name] = row
+			}
+		}
+		priors, err := fuzzer.target.MakeCallPriors(weights, slowCallPriorFactor)
+		if err != nil {
+			log.SyzFatalf("failed to create slow call priors: %v", err)
+		}
+		fuzzer.corpusMu.RLock()
+		corpus := fuzzer.corpus
+		fuzzer.corpusMu.RUnlock()
+		ct = fuzzer.target.BuildChoiceTableWithPriors(corpus, fuzzer.enabledCalls(),
+			append(fuzzer.priors, priors)...)
+	}
+	if len(names) != 0 {
+		sort.Strings(names)
+		log.Logf(0, "deprioritizing %v slow calls: %v", len(names), strings.Join(names, " "))
+	} else {
+		log.Logf(0, "no slow calls are deprioritized")
+	}
+	st.mu.Lock()
+	st.slow, st.ct, st.lastSwap = slow, ct, time.Now()
+	st.mu.Unlock()
+}
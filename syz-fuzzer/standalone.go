@@ -0,0 +1,190 @@
+// Copyright 2026 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/syzkaller/pkg/db"
+	"github.com/google/syzkaller/pkg/hash"
+	"github.com/google/syzkaller/pkg/log"
+	"github.com/google/syzkaller/pkg/rpctype"
+	"github.com/google/syzkaller/pkg/signal"
+	"github.com/google/syzkaller/prog"
+)
+
+// Standalone mode (syz-fuzzer -standalone) allows to run the fuzzer without syz-manager and VMs,
+// e.g. against the host kernel or against the fake syscalls of the test target:
+//
+//	syz-fuzzer -standalone -os=test -arch=64 -executor=./syz-executor -seeds=seeds -corpus=corpus.db
+//
+// The fuzzer starts an in-process manager that serves the regular manager RPC interface on a local port,
+// so the fuzzer itself works exactly as it does under syz-manager. The manager keeps the corpus signal
+// and stats in memory, hands out programs from the corpus database and the seeds directory
+// as candidates, saves new inputs to the corpus database and periodically prints stats.
+type standaloneManager struct {
+	target *prog.Target
+
+	mu           sync.Mutex
+	corpusDB     *db.DB
+	candidates   []rpctype.Candidate
+	corpusSignal signal.Signal
+	maxSignal    signal.Signal
+	stats        map[string]uint64
+	startTime    time.Time
+	lastPrint    time.Time
+}
+
+const (
+	standaloneCandidateBatch = 100
+	standalonePrintPeriod    = 10 * time.Second
+)
+
+// startStandaloneManager starts the in-process manager and returns its RPC address.
+func startStandaloneManager(target *prog.Target, corpusFile, seedDir string) string {
+	corpusDB, err := db.Open(corpusFile, true)
+	if err != nil {
+		if corpusDB == nil {
+			log.SyzFatalf("failed to open corpus database: %v", err)
+		}
+		log.Logf(0, "read %v inputs from corpus and got error: %v", len(corpusDB.Records), err)
+	}
+	mgr := &standaloneManager{
+		target:    target,
+		corpusDB:  corpusDB,
+		stats:     make(map[string]uint64),
+		startTime: time.Now(),
+		lastPrint: time.Now(),
+	}
+	for key, rec := range corpusDB.Records {
+		if _, err := target.Deserialize(rec.Val, prog.NonStrict); err != nil {
+			log.Logf(0, "deleting broken corpus input %v: %v", key, err)
+			corpusDB.Delete(key)
+			continue
+		}
+		mgr.candidates = append(mgr.candidates, rpctype.Candidate{
+			Prog:      rec.Val,
+			Minimized: true,
+			Smashed:   true,
+			Source:    "corpus",
+		})
+	}
+	if err := corpusDB.Flush(); err != nil {
+		log.SyzFatalf("failed to save corpus database: %v", err)
+	}
+	if seedDir != "" {
+		seeds, err := mgr.loadSeeds(seedDir)
+		if err != nil {
+			log.SyzFatalf("failed to load seeds: %v", err)
+		}
+		mgr.candidates = append(mgr.candidates, seeds...)
+	}
+	log.Logf(0, "standalone mode: %v corpus inputs, %v candidates", len(corpusDB.Records), len(mgr.candidates))
+	serv, err := rpctype.NewRPCServer("127.0.0.1:0", "Manager", mgr)
+	if err != nil {
+		log.SyzFatalf("failed to create rpc server: %v", err)
+	}
+	go serv.Serve()
+	return serv.Addr().String()
+}
+
+func (mgr *standaloneManager) loadSeeds(dir string) ([]rpctype.Candidate, error) {
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var seeds []rpctype.Candidate
+	for _, file := range files {
+		if file.IsDir() {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, file.Name()))
+		if err != nil {
+			return nil, err
+		}
+		if _, err := mgr.target.Deserialize(data, prog.NonStrict); err != nil {
+			log.Logf(0, "skipping seed %v: %v", file.Name(), err)
+			continue
+		}
+		seeds = append(seeds, rpctype.Candidate{Prog: data, Source: "seed"})
+	}
+	return seeds, nil
+}
+
+func (mgr *standaloneManager) Connect(a *rpctype.ConnectArgs, r *rpctype.ConnectRes) error {
+	log.Logf(1, "fuzzer %v connected", a.Name)
+	r.GitRevision = prog.GitRevision
+	r.TargetRevision = mgr.target.Revision
+	return nil
+}
+
+func (mgr *standaloneManager) Check(a *rpctype.CheckArgs, r *int) error {
+	if a.Error != "" {
+		log.Logf(0, "machine check failed: %v", a.Error)
+		return nil
+	}
+	for sandbox, calls := range a.EnabledCalls {
+		log.Logf(0, "machine check: %v calls enabled for sandbox %v", len(calls), sandbox)
+	}
+	return nil
+}
+
+func (mgr *standaloneManager) NewInput(a *rpctype.NewInputArgs, r *int) error {
+	mgr.mu.Lock()
+	defer mgr.mu.Unlock()
+	mgr.corpusSignal.Merge(a.Input.Signal.Deserialize())
+	mgr.corpusDB.Save(hash.String(a.Input.Prog), a.Input.Prog, 0)
+	if err := mgr.corpusDB.Flush(); err != nil {
+		log.Logf(0, "failed to save corpus database: %v", err)
+	}
+	return nil
+}
+
+func (mgr *standaloneManager) Poll(a *rpctype.PollArgs, r *rpctype.PollRes) error {
+	mgr.mu.Lock()
+	defer mgr.mu.Unlock()
+	for stat, v := range a.Stats {
+		mgr.stats[stat] += v
+	}
+	mgr.maxSignal.Merge(a.MaxSignal.Deserialize())
+	if a.NeedCandidates {
+		n := len(mgr.candidates)
+		if n > standaloneCandidateBatch {
+			n = standaloneCandidateBatch
+		}
+		r.Candidates = mgr.candidates[len(mgr.candidates)-n:]
+		mgr.candidates = mgr.candidates[:len(mgr.candidates)-n]
+	}
+	if time.Since(mgr.lastPrint) >= standalonePrintPeriod {
+		mgr.lastPrint = time.Now()
+		mgr.printStats()
+	}
+	return nil
+}
+
+func (mgr *standaloneManager) printStats() {
+	execs := mgr.stats["exec total"]
+	var names []string
+	for stat, v := range mgr.stats {
+		if v != 0 {
+			names = append(names, stat)
+		}
+	}
+	sort.Strings(names)
+	uptime := time.Since(mgr.startTime)
+	buf := new(strings.Builder)
+	fmt.Fprintf(buf, "uptime %v, corpus %v, signal %v/%v, candidates %v, executed %v (%.0f/sec)",
+		uptime.Truncate(time.Second), len(mgr.corpusDB.Records), mgr.corpusSignal.Len(), mgr.maxSignal.Len(),
+		len(mgr.candidates), execs, float64(execs)/uptime.Seconds())
+	for _, stat := range names {
+		fmt.Fprintf(buf, "\n\t%v: %v", stat, mgr.stats[stat])
+	}
+	log.Logf(0, "%v", buf.String())
+}
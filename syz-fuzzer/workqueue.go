@@ -5,6 +5,7 @@ package main
 
 import (
 	"sync"
+	"sync/atomic"
 
 	"github.com/google/syzkaller/pkg/ipc"
 	"github.com/google/syzkaller/prog"
@@ -18,6 +19,7 @@ type WorkQueue struct {
 	mu              sync.RWMutex
 	triageCandidate []*WorkTriage
 	candidate       []*WorkCandidate
+	seed            []*WorkSeed
 	triage          []*WorkTriage
 	smash           []*WorkSmash
 
@@ -39,18 +41,58 @@ const (
 // During triage we understand if these programs in fact give new coverage,
 // and if yes, minimize them and add to corpus.
 type WorkTriage struct {
-	p     *prog.Prog
-	call  int
-	info  ipc.CallInfo
-	flags ProgTypes
+	p      *prog.Prog
+	call   int
+	info   ipc.CallInfo
+	flags  ProgTypes
+	origin string // what produced the input, see rpctype.Input.Origin
+	// Mutant the input was produced by, if it was mutated.
+	mutant    *mutant
+	trace     *prog.Trace // if tracing is enabled
+	candidate *candidateTriage
 }
 
 // WorkCandidate are programs from hub.
 // We don't know yet if they are useful for this fuzzer or not.
 // A proc handles them the same way as locally generated/mutated programs.
 type WorkCandidate struct {
-	p     *prog.Prog
-	flags ProgTypes
+	p         *prog.Prog
+	flags     ProgTypes
+	origin    string
+	candidate *candidateTriage
+}
+
+// WorkSeed are externally sourced seeds (e.g. enriched programs).
+// They are handled like candidates, but are executed several times with varied
+// execution options, and if that gives no new signal, receive a short burst of mutations
+// (see Proc.seedInput).
+type WorkSeed struct {
+	p         *prog.Prog
+	flags     ProgTypes
+	origin    string
+	candidate *candidateTriage
+}
+
+// candidateTriage counts work items derived from a candidate (the candidate itself and triage
+// of its calls). Once all of them are done, the candidate is reported to the manager as triaged
+// (see rpctype.PollArgs.TriagedCandidates).
+type candidateTriage struct {
+	sig  string
+	refs int32
+}
+
+// mutant describes how a mutated program was produced. It's shared by triage of all calls
+// of the program, so that the mutation is credited once even if several calls give new signal.
+type mutant struct {
+	scheduler *mutationScheduler // the scheduler that chose the mutations
+	mutation  prog.Mutation
+	parent    *prog.Prog // corpus program chosen by the power schedule, if any
+	credited  uint32
+}
+
+// credit says if the mutant needs to be credited for a new input, it returns true only once.
+func (m *mutant) credit() bool {
+	return m != nil && atomic.CompareAndSwapUint32(&m.credited, 0, 1)
 }
 
 // WorkSmash are programs just added to corpus.
@@ -80,6 +122,8 @@ func (wq *WorkQueue) enqueue(item interface{}) {
 		}
 	case *WorkCandidate:
 		wq.candidate = append(wq.candidate, item)
+	case *WorkSeed:
+		wq.seed = append(wq.seed, item)
 	case *WorkSmash:
 		wq.smash = append(wq.smash, item)
 	default:
@@ -89,7 +133,7 @@ func (wq *WorkQueue) enqueue(item interface{}) {
 
 func (wq *WorkQueue) dequeue() (item interface{}) {
 	wq.mu.RLock()
-	if len(wq.triageCandidate)+len(wq.candidate)+len(wq.triage)+len(wq.smash) == 0 {
+	if len(wq.triageCandidate)+len(wq.candidate)+len(wq.seed)+len(wq.triage)+len(wq.smash) == 0 {
 		wq.mu.RUnlock()
 		return nil
 	}
@@ -104,7 +148,12 @@ func (wq *WorkQueue) dequeue() (item interface{}) {
 		last := len(wq.candidate) - 1
 		item = wq.candidate[last]
 		wq.candidate = wq.candidate[:last]
-		wantCandidates = len(wq.candidate) < wq.procs
+		wantCandidates = len(wq.candidate)+len(wq.seed) < wq.procs
+	} else if len(wq.seed) != 0 {
+		last := len(wq.seed) - 1
+		item = wq.seed[last]
+		wq.seed = wq.seed[:last]
+		wantCandidates = len(wq.seed) < wq.procs
 	} else if len(wq.triage) != 0 {
 		last := len(wq.triage) - 1
 		item = wq.triage[last]
@@ -127,5 +176,5 @@ func (wq *WorkQueue) dequeue() (item interface{}) {
 func (wq *WorkQueue) wantCandidates() bool {
 	wq.mu.RLock()
 	defer wq.mu.RUnlock()
-	return len(wq.candidate) < wq.procs
+	return len(wq.candidate)+len(wq.seed) < wq.procs
 }
@@ -0,0 +1,187 @@
+// Copyright 2026 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/google/syzkaller/pkg/log"
+	"github.com/google/syzkaller/pkg/osutil"
+	"github.com/google/syzkaller/pkg/rpctype"
+	"github.com/google/syzkaller/prog"
+)
+
+// Fuzzers report outcomes of call insertions (see syz-fuzzer/callpairs.go),
+// the manager aggregates them and periodically turns success rates of sufficiently tried pairs
+// into call priors for the choice tables of fuzzers. New priors are sent to fuzzers on connect
+// and with the next poll. The aggregated outcomes are saved in the workdir, so they survive restarts.
+
+type callPairOutcomes struct {
+	inserts   uint64
+	newInputs uint64
+}
+
+// Pairs inserted less times than this don't have reliable success rates yet.
+const learnedPriorMinInserts = 100
+
+const (
+	learnedPriorsFile   = "learned_priors.json"
+	learnedPriorsPeriod = 10 * time.Minute
+)
+
+// savedCallPair is the form of call pair outcomes in learnedPriorsFile.
+// Calls are identified by names since syscall IDs change along with descriptions.
+type savedCallPair struct {
+	Prev      string `json:"prev"`
+	Next      string `json:"next"`
+	Inserts   uint64 `json:"inserts"`
+	NewInputs uint64 `json:"new_inputs"`
+}
+
+func (mgr *Manager) initLearnedPriors() {
+	if mgr.cfg.LearnedPriorsFactor == 0 {
+		return
+	}
+	mgr.loadCallPairs()
+	mgr.updateLearnedPriors(false)
+	go func() {
+		for {
+			time.Sleep(learnedPriorsPeriod)
+			mgr.updateLearnedPriors(true)
+		}
+	}()
+}
+
+func (mgr *Manager) loadCallPairs() {
+	data, err := os.ReadFile(filepath.Join(mgr.cfg.Workdir, learnedPriorsFile))
+	if err != nil {
+		return
+	}
+	var saved []savedCallPair
+	if err := json.Unmarshal(data, &saved); err != nil {
+		log.Logf(0, "failed to parse %v: %v", learnedPriorsFile, err)
+		return
+	}
+	mgr.mu.Lock()
+	defer mgr.mu.Unlock()
+	for _, pair := range saved {
+		prev, next := mgr.target.SyscallMap[pair.Prev], mgr.target.SyscallMap[pair.Next]
+		if prev == nil || next == nil {
+			continue
+		}
+		mgr.callPairs[prog.CallPair{Prev: prev.ID, Next: next.ID}] = &callPairOutcomes{
+			inserts:   pair.Inserts,
+			newInputs: pair.NewInputs,
+		}
+	}
+	log.Logf(0, "loaded outcomes of %v call pairs", len(mgr.callPairs))
+}
+
+// updateLearnedPriors recomputes learned priors and optionally saves call pair outcomes to the workdir.
+func (mgr *Manager) updateLearnedPriors(save bool) {
+	mgr.mu.Lock()
+	if priors := mgr.learnedCallPriorsLocked(); !reflect.DeepEqual(priors, mgr.learnedPriors) {
+		mgr.learnedPriors = priors
+		mgr.learnedPriorsVersion++
+	}
+	var saved []savedCallPair
+	if save {
+		for pair, outcomes := range mgr.callPairs {
+			saved = append(saved, savedCallPair{
+				Prev:      mgr.target.Syscalls[pair.Prev].Name,
+				Next:      mgr.target.Syscalls[pair.Next].Name,
+				Inserts:   outcomes.inserts,
+				NewInputs: outcomes.newInputs,
+			})
+		}
+	}
+	mgr.mu.Unlock()
+	if !save {
+		return
+	}
+	sort.Slice(saved, func(i, j int) bool {
+		if saved[i].Prev != saved[j].Prev {
+			return saved[i].Prev < saved[j].Prev
+		}
+		return saved[i].Next < saved[j].Next
+	})
+	data, err := json.MarshalIndent(saved, "", "\t")
+	if err != nil {
+		log.Fatalf("failed to serialize call pair outcomes: %v", err)
+	}
+	if err := osutil.WriteFile(filepath.Join(mgr.cfg.Workdir, learnedPriorsFile), data); err != nil {
+		log.Logf(0, "failed to write %v: %v", learnedPriorsFile, err)
+	}
+}
+
+func (mgr *Manager) mergeCallPairs(pairs []rpctype.CallPairStats) {
+	if len(pairs) == 0 {
+		return
+	}
+	mgr.mu.Lock()
+	defer mgr.mu.Unlock()
+	for _, stats := range pairs {
+		if stats.Prev < 0 || stats.Prev >= len(mgr.target.Syscalls) ||
+			stats.Next < 0 || stats.Next >= len(mgr.target.Syscalls) {
+			log.Logf(0, "bad call pair %v->%v", stats.Prev, stats.Next)
+			continue
+		}
+		pair := prog.CallPair{Prev: stats.Prev, Next: stats.Next}
+		outcomes := mgr.callPairs[pair]
+		if outcomes == nil {
+			outcomes = new(callPairOutcomes)
+			mgr.callPairs[pair] = outcomes
+		}
+		outcomes.inserts += uint64(stats.Inserts)
+		outcomes.newInputs += uint64(stats.NewInputs)
+	}
+}
+
+// learnedCallPriors returns the current learned priors and their version,
+// the version is incremented every time the priors change.
+func (mgr *Manager) learnedCallPriors() (map[string]map[string]float64, int) {
+	mgr.mu.Lock()
+	defer mgr.mu.Unlock()
+	return mgr.learnedPriors, mgr.learnedPriorsVersion
+}
+
+// learnedCallPriorsLocked returns weights for prog.MakeCallPriors: success rates of call pairs.
+// Calls that don't have successful pairs are omitted, so their priorities are not affected.
+func (mgr *Manager) learnedCallPriorsLocked() map[string]map[string]float64 {
+	if mgr.cfg.LearnedPriorsFactor == 0 {
+		return nil
+	}
+	var res map[string]map[string]float64
+	for pair, outcomes := range mgr.callPairs {
+		if outcomes.inserts < learnedPriorMinInserts || outcomes.newInputs == 0 {
+			continue
+		}
+		if res == nil {
+			res = make(map[string]map[string]float64)
+		}
+		prev := mgr.target.Syscalls[pair.Prev].Name
+		if res[prev] == nil {
+			res[prev] = make(map[string]float64)
+		}
+		res[prev][mgr.target.Syscalls[pair.Next].Name] = float64(outcomes.newInputs) / float64(outcomes.inserts)
+	}
+	return res
+}
+
+// learnedCallPriorsTableLocked returns learned priors in the form suitable for prog.BuildChoiceTableWithPriors.
+func (mgr *Manager) learnedCallPriorsTableLocked() *prog.CallPriors {
+	if mgr.learnedPriors == nil {
+		return nil
+	}
+	priors, err := mgr.target.MakeCallPriors(mgr.learnedPriors, mgr.cfg.LearnedPriorsFactor)
+	if err != nil {
+		log.Fatalf("failed to create learned call priors: %v", err)
+	}
+	return priors
+}
@@ -0,0 +1,55 @@
+// Copyright 2026 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/google/syzkaller/pkg/mgrconfig"
+	"github.com/google/syzkaller/pkg/rpctype"
+	"github.com/google/syzkaller/prog"
+	"github.com/google/syzkaller/sys/targets"
+)
+
+func TestLearnedPriors(t *testing.T) {
+	target, err := prog.GetTarget(targets.TestOS, targets.TestArch64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	newManager := func(workdir string) *Manager {
+		return &Manager{
+			cfg:       &mgrconfig.Config{Workdir: workdir, LearnedPriorsFactor: 0.5},
+			target:    target,
+			callPairs: make(map[prog.CallPair]*callPairOutcomes),
+		}
+	}
+	res0, res1 := target.SyscallMap["test$res0"], target.SyscallMap["test$res1"]
+	workdir := t.TempDir()
+	mgr := newManager(workdir)
+	mgr.mergeCallPairs([]rpctype.CallPairStats{
+		{Prev: res0.ID, Next: res1.ID, Inserts: learnedPriorMinInserts, NewInputs: 10},
+		{Prev: res1.ID, Next: res0.ID, Inserts: learnedPriorMinInserts - 1, NewInputs: 10},
+	})
+	mgr.updateLearnedPriors(true)
+	priors, version := mgr.learnedCallPriors()
+	want := map[string]map[string]float64{res0.Name: {res1.Name: 0.1}}
+	if !reflect.DeepEqual(priors, want) || version != 1 {
+		t.Fatalf("got priors %v version %v, want %v version 1", priors, version, want)
+	}
+	mgr.updateLearnedPriors(true)
+	if _, version := mgr.learnedCallPriors(); version != 1 {
+		t.Fatalf("version is changed without changes in priors: %v", version)
+	}
+	// The outcomes survive restarts.
+	mgr = newManager(workdir)
+	mgr.loadCallPairs()
+	mgr.updateLearnedPriors(false)
+	if priors, _ := mgr.learnedCallPriors(); !reflect.DeepEqual(priors, want) {
+		t.Fatalf("got priors %v after restart, want %v", priors, want)
+	}
+	if len(mgr.callPairs) != 2 {
+		t.Fatalf("loaded %v call pairs, want 2", len(mgr.callPairs))
+	}
+}
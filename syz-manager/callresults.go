@@ -0,0 +1,204 @@
+// Copyright 2026 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/syzkaller/pkg/log"
+	"github.com/google/syzkaller/pkg/rpctype"
+	"github.com/google/syzkaller/sys/targets"
+)
+
+// Fuzzers report results of executed calls (see syz-fuzzer/callresults.go),
+// the manager aggregates them per syscall and shows them on the /syscalls page.
+
+type callResults struct {
+	execs     uint64
+	success   uint64
+	errnos    map[int]uint64
+	durations []uint64 // see rpctype.CallResultStats.Durations
+	timeouts  uint64
+}
+
+func (mgr *Manager) mergeCallResults(results []rpctype.CallResultStats) {
+	if len(results) == 0 {
+		return
+	}
+	mgr.mu.Lock()
+	defer mgr.mu.Unlock()
+	for _, stats := range results {
+		if stats.ID < 0 || stats.ID >= len(mgr.target.Syscalls) {
+			log.Logf(0, "bad call result id %v", stats.ID)
+			continue
+		}
+		res := mgr.callResults[stats.ID]
+		if res == nil {
+			res = &callResults{errnos: make(map[int]uint64)}
+			mgr.callResults[stats.ID] = res
+		}
+		res.execs += uint64(stats.Execs)
+		res.success += uint64(stats.Success)
+		for errno, count := range stats.Errnos {
+			res.errnos[errno] += uint64(count)
+		}
+		for len(res.durations) < len(stats.Durations) {
+			res.durations = append(res.durations, 0)
+		}
+		for i, count := range stats.Durations {
+			res.durations[i] += uint64(count)
+		}
+		res.timeouts += uint64(stats.Timeouts)
+	}
+}
+
+// durationPercentile returns the upper bound of the duration of q share of the calls.
+func (res *callResults) durationPercentile(q float64) time.Duration {
+	total := uint64(0)
+	for _, count := range res.durations {
+		total += count
+	}
+	if total == 0 {
+		return 0
+	}
+	sum := uint64(0)
+	for i, count := range res.durations {
+		sum += count
+		if float64(sum) >= q*float64(total) {
+			return time.Duration(1<<i) * time.Millisecond
+		}
+	}
+	return time.Duration(1<<(len(res.durations)-1)) * time.Millisecond
+}
+
+// formatDurations formats duration percentiles as "p50/p90/p99".
+func (res *callResults) formatDurations() string {
+	var parts []string
+	for _, q := range []float64{0.5, 0.9, 0.99} {
+		parts = append(parts, fmt.Sprintf("<%v", res.durationPercentile(q)))
+	}
+	return strings.Join(parts, " / ")
+}
+
+// errnoCount is the number of failures of a call with the errno.
+type errnoCount struct {
+	name  string
+	count uint64
+}
+
+// topErrnos returns errnos of the call results sorted by the number of failures.
+func (mgr *Manager) topErrnos(res *callResults) []errnoCount {
+	var errnos []errnoCount
+	for errno, count := range res.errnos {
+		errnos = append(errnos, errnoCount{mgr.errnoName(errno), count})
+	}
+	sort.Slice(errnos, func(i, j int) bool {
+		if errnos[i].count != errnos[j].count {
+			return errnos[i].count > errnos[j].count
+		}
+		return errnos[i].name < errnos[j].name
+	})
+	return errnos
+}
+
+// formatErrnos formats errnos as "EINVAL:10 EFAULT:2", at most limit errnos are included (0 means all).
+func formatErrnos(errnos []errnoCount, limit int) string {
+	if limit != 0 && len(errnos) > limit {
+		errnos = errnos[:limit]
+	}
+	var parts []string
+	for _, e := range errnos {
+		parts = append(parts, fmt.Sprintf("%v:%v", e.name, e.count))
+	}
+	return strings.Join(parts, " ")
+}
+
+func (mgr *Manager) errnoName(errno int) string {
+	if mgr.cfg.TargetOS == targets.Linux && linuxGenericErrnoArches[mgr.cfg.TargetArch] {
+		if name := linuxErrnos[errno]; name != "" {
+			return name
+		}
+	}
+	return fmt.Sprintf("errno%v", errno)
+}
+
+// linuxGenericErrnoArches are the arches that use errno numbers from asm-generic/errno.h.
+// Other arches (mips, sparc, alpha, parisc) have their own numbers, so raw numbers are shown for them.
+var linuxGenericErrnoArches = map[string]bool{
+	targets.AMD64:   true,
+	targets.I386:    true,
+	targets.ARM64:   true,
+	targets.ARM:     true,
+	targets.PPC64LE: true,
+	targets.S390x:   true,
+	targets.RiscV64: true,
+}
+
+// linuxErrnos are names of errnos that are commonly returned by Linux syscalls (asm-generic numbers).
+var linuxErrnos = map[int]string{
+	1:   "EPERM",
+	2:   "ENOENT",
+	3:   "ESRCH",
+	4:   "EINTR",
+	5:   "EIO",
+	6:   "ENXIO",
+	7:   "E2BIG",
+	8:   "ENOEXEC",
+	9:   "EBADF",
+	10:  "ECHILD",
+	11:  "EAGAIN",
+	12:  "ENOMEM",
+	13:  "EACCES",
+	14:  "EFAULT",
+	15:  "ENOTBLK",
+	16:  "EBUSY",
+	17:  "EEXIST",
+	18:  "EXDEV",
+	19:  "ENODEV",
+	20:  "ENOTDIR",
+	21:  "EISDIR",
+	22:  "EINVAL",
+	23:  "ENFILE",
+	24:  "EMFILE",
+	25:  "ENOTTY",
+	26:  "ETXTBSY",
+	27:  "EFBIG",
+	28:  "ENOSPC",
+	29:  "ESPIPE",
+	30:  "EROFS",
+	31:  "EMLINK",
+	32:  "EPIPE",
+	33:  "EDOM",
+	34:  "ERANGE",
+	35:  "EDEADLK",
+	36:  "ENAMETOOLONG",
+	37:  "ENOLCK",
+	38:  "ENOSYS",
+	39:  "ENOTEMPTY",
+	40:  "ELOOP",
+	61:  "ENODATA",
+	71:  "EPROTO",
+	75:  "EOVERFLOW",
+	88:  "ENOTSOCK",
+	90:  "EMSGSIZE",
+	92:  "ENOPROTOOPT",
+	93:  "EPROTONOSUPPORT",
+	95:  "EOPNOTSUPP",
+	97:  "EAFNOSUPPORT",
+	98:  "EADDRINUSE",
+	99:  "EADDRNOTAVAIL",
+	103: "ECONNABORTED",
+	104: "ECONNRESET",
+	106: "EISCONN",
+	107: "ENOTCONN",
+	110: "ETIMEDOUT",
+	111: "ECONNREFUSED",
+	114: "EALREADY",
+	115: "EINPROGRESS",
+	125: "ECANCELED",
+	524: "ENOTSUPP",
+}
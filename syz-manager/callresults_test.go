@@ -0,0 +1,34 @@
+// Copyright 2026 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"testing"
+
+	"github.com/google/syzkaller/pkg/mgrconfig"
+	"github.com/google/syzkaller/sys/targets"
+)
+
+func TestErrnoName(t *testing.T) {
+	tests := []struct {
+		os    string
+		arch  string
+		errno int
+		name  string
+	}{
+		{targets.Linux, targets.AMD64, 22, "EINVAL"},
+		{targets.Linux, targets.ARM64, 95, "EOPNOTSUPP"},
+		{targets.Linux, targets.AMD64, 1000, "errno1000"},
+		// EOPNOTSUPP is 122 on mips, 95 is ENOTSOCK there.
+		{targets.Linux, targets.MIPS64LE, 95, "errno95"},
+		{targets.Linux, targets.MIPS64LE, 22, "errno22"},
+		{targets.FreeBSD, targets.AMD64, 22, "errno22"},
+	}
+	for _, test := range tests {
+		mgr := &Manager{cfg: &mgrconfig.Config{Derived: mgrconfig.Derived{TargetOS: test.os, TargetArch: test.arch}}}
+		if name := mgr.errnoName(test.errno); name != test.name {
+			t.Errorf("%v/%v: errno %v: got %v, want %v", test.os, test.arch, test.errno, name, test.name)
+		}
+	}
+}
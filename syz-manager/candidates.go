@@ -0,0 +1,178 @@
+// Copyright 2026 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/google/syzkaller/pkg/hash"
+	"github.com/google/syzkaller/pkg/mgrconfig"
+	"github.com/google/syzkaller/pkg/rpctype"
+)
+
+// Candidates are untriaged inputs that the manager hands out to fuzzers.
+// Each source of candidates has own queue, queues are served in proportion to their weights
+// and can be rate limited. This way e.g. a large batch of enriched seeds does not starve
+// corpus reload and vice versa.
+
+type CandidateSource int
+
+const (
+	SourceCorpus CandidateSource = iota // corpus and seeds loaded on start
+	SourceHub                           // inputs received from syz-hub
+	SourceEnrich                        // external programs loaded with -enrich
+	SourceCount
+)
+
+var sourceNames = [SourceCount]string{
+	SourceCorpus: "corpus",
+	SourceHub:    "hub",
+	SourceEnrich: "enrich",
+}
+
+func (src CandidateSource) String() string {
+	return sourceNames[src]
+}
+
+type candidateQueue struct {
+	weight int
+	rate   int // max candidates per minute, 0 means unlimited
+	items  []rpctype.Candidate
+	// If secondChance is set, handed out items are saved in retry and are handed out
+	// once more (in random order) after the queue is drained.
+	// A fuzzer can crash while triaging candidates, in such case it will also lose all cached
+	// candidates. Or, the input can be somewhat flaky and doesn't give the coverage on first try.
+	// Shuffling should alleviate deterministically losing the same inputs on fuzzer crashing.
+	secondChance bool
+	retry        []rpctype.Candidate
+	windowStart  time.Time
+	windowTaken  int
+}
+
+type CandidateQueues struct {
+	queues [SourceCount]candidateQueue
+	queued map[string]bool // hashes of the queued programs
+	rnd    *rand.Rand
+}
+
+func newCandidateQueues(cfg map[string]mgrconfig.CandidateQueue, rnd *rand.Rand) *CandidateQueues {
+	cq := &CandidateQueues{
+		queued: make(map[string]bool),
+		rnd:    rnd,
+	}
+	for src := range cq.queues {
+		queue := &cq.queues[src]
+		queue.weight = 1
+		if qcfg, ok := cfg[sourceNames[src]]; ok {
+			if qcfg.Weight != 0 {
+				queue.weight = qcfg.Weight
+			}
+			queue.rate = qcfg.Rate
+		}
+	}
+	return cq
+}
+
+// push adds the candidate to the queue of the source.
+// Returns false if the same program is already queued.
+func (cq *CandidateQueues) push(src CandidateSource, cand rpctype.Candidate) bool {
+	sig := hash.String(cand.Prog)
+	if cq.queued[sig] {
+		return false
+	}
+	cq.queued[sig] = true
+	cq.queues[src].items = append(cq.queues[src].items, cand)
+	return true
+}
+
+// contains says whether the program is queued (including candidates that will be handed out once more).
+func (cq *CandidateQueues) contains(sig string) bool {
+	return cq.queued[sig]
+}
+
+// enableSecondChance makes all candidates currently queued for the source to be handed out twice.
+func (cq *CandidateQueues) enableSecondChance(src CandidateSource) {
+	cq.queues[src].secondChance = true
+}
+
+// next returns the next candidate to triage and its source,
+// or false if all non-empty queues are rate limited.
+func (cq *CandidateQueues) next(now time.Time) (rpctype.Candidate, CandidateSource, bool) {
+	totalWeight := 0
+	var eligible [SourceCount]bool
+	for src := range cq.queues {
+		queue := &cq.queues[src]
+		if queue.len() == 0 {
+			continue
+		}
+		if now.Sub(queue.windowStart) >= time.Minute {
+			queue.windowStart = now
+			queue.windowTaken = 0
+		}
+		if queue.rate != 0 && queue.windowTaken >= queue.rate {
+			continue
+		}
+		eligible[src] = true
+		totalWeight += queue.weight
+	}
+	if totalWeight == 0 {
+		return rpctype.Candidate{}, 0, false
+	}
+	val := cq.rnd.Intn(totalWeight)
+	for src := range cq.queues {
+		if !eligible[src] {
+			continue
+		}
+		queue := &cq.queues[src]
+		if val >= queue.weight {
+			val -= queue.weight
+			continue
+		}
+		queue.windowTaken++
+		return cq.pop(queue), CandidateSource(src), true
+	}
+	panic(fmt.Sprintf("bad candidate queue choice: %v/%v", val, totalWeight))
+}
+
+func (cq *CandidateQueues) pop(queue *candidateQueue) rpctype.Candidate {
+	if len(queue.items) == 0 {
+		// Retry candidates are handed out only once.
+		cq.rnd.Shuffle(len(queue.retry), func(i, j int) {
+			queue.retry[i], queue.retry[j] = queue.retry[j], queue.retry[i]
+		})
+		queue.items, queue.retry = queue.retry, nil
+		queue.secondChance = false
+	}
+	cand := queue.items[0]
+	queue.items[0] = rpctype.Candidate{}
+	queue.items = queue.items[1:]
+	if len(queue.items) == 0 {
+		queue.items = nil
+	}
+	if queue.secondChance {
+		queue.retry = append(queue.retry, cand)
+	} else {
+		delete(cq.queued, hash.String(cand.Prog))
+	}
+	return cand
+}
+
+// len returns the number of candidates pending in the source queue.
+func (cq *CandidateQueues) len(src CandidateSource) int {
+	return cq.queues[src].len()
+}
+
+func (cq *CandidateQueues) total() int {
+	total := 0
+	for src := range cq.queues {
+		total += cq.queues[src].len()
+	}
+	return total
+}
+
+func (queue *candidateQueue) len() int {
+	return len(queue.items) + len(queue.retry)
+}
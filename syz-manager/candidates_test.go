@@ -0,0 +1,91 @@
+// Copyright 2026 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/google/syzkaller/pkg/mgrconfig"
+	"github.com/google/syzkaller/pkg/rpctype"
+)
+
+func testCandidate(i int) rpctype.Candidate {
+	return rpctype.Candidate{Prog: []byte(fmt.Sprintf("prog%v()", i))}
+}
+
+func TestCandidateQueuesDedup(t *testing.T) {
+	cq := newCandidateQueues(nil, rand.New(rand.NewSource(0)))
+	if !cq.push(SourceCorpus, testCandidate(0)) {
+		t.Fatalf("failed to push a new candidate")
+	}
+	if cq.push(SourceHub, testCandidate(0)) {
+		t.Fatalf("pushed a duplicate candidate")
+	}
+	if cq.total() != 1 || cq.len(SourceCorpus) != 1 || cq.len(SourceHub) != 0 {
+		t.Fatalf("bad queue lengths: total %v, corpus %v, hub %v",
+			cq.total(), cq.len(SourceCorpus), cq.len(SourceHub))
+	}
+	if _, _, ok := cq.next(time.Now()); !ok {
+		t.Fatalf("no candidate")
+	}
+	if !cq.push(SourceHub, testCandidate(0)) {
+		t.Fatalf("failed to push a candidate after it was handed out")
+	}
+}
+
+func TestCandidateQueuesSecondChance(t *testing.T) {
+	cq := newCandidateQueues(nil, rand.New(rand.NewSource(0)))
+	const count = 10
+	for i := 0; i < count; i++ {
+		cq.push(SourceCorpus, testCandidate(i))
+	}
+	cq.enableSecondChance(SourceCorpus)
+	seen := make(map[string]int)
+	for now := time.Now(); ; {
+		cand, _, ok := cq.next(now)
+		if !ok {
+			break
+		}
+		seen[string(cand.Prog)]++
+	}
+	if len(seen) != count {
+		t.Fatalf("got %v distinct candidates, want %v", len(seen), count)
+	}
+	for prog, n := range seen {
+		if n != 2 {
+			t.Errorf("candidate %q handed out %v times, want 2", prog, n)
+		}
+	}
+}
+
+func TestCandidateQueuesWeightsAndRate(t *testing.T) {
+	cfg := map[string]mgrconfig.CandidateQueue{
+		"corpus": {Weight: 3},
+		"enrich": {Rate: 5},
+	}
+	cq := newCandidateQueues(cfg, rand.New(rand.NewSource(0)))
+	for i := 0; i < 100; i++ {
+		cq.push(SourceCorpus, testCandidate(i))
+		cq.push(SourceEnrich, testCandidate(1000+i))
+	}
+	now := time.Now()
+	for i := 0; i < 105; i++ {
+		if _, _, ok := cq.next(now); !ok {
+			t.Fatalf("no candidate")
+		}
+	}
+	if taken := 100 - cq.len(SourceEnrich); taken != 5 {
+		t.Fatalf("rate limited queue handed out %v candidates, want 5", taken)
+	}
+	// Corpus is drained now, enrich queue is rate limited until the next minute.
+	if _, _, ok := cq.next(now); ok {
+		t.Fatalf("rate limit is not respected")
+	}
+	if _, _, ok := cq.next(now.Add(time.Minute)); !ok {
+		t.Fatalf("rate limit window is not reset")
+	}
+}
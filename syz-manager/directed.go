@@ -0,0 +1,263 @@
+// Copyright 2026 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/syzkaller/pkg/cover/backend"
+	"github.com/google/syzkaller/pkg/html/pages"
+	"github.com/google/syzkaller/pkg/log"
+)
+
+// Directed fuzzing (see mgrconfig.Config.Directed).
+// The manager computes call graph distances from kernel functions to the target functions,
+// and sends fuzzers the distance of each coverage PC to the closest target. Fuzzers favor
+// corpus programs whose coverage gets closest to the targets. The manager tracks the best
+// distance reached by the corpus for each target and shows it on the /directed page.
+// Coverage PCs are in the form reported by the executor, so distances can be looked up
+// with raw (canonical in the manager) coverage without restoring PCs.
+
+type directedState struct {
+	targets []*directedTarget
+	// Distance to the closest target for every PC within the max distance to any of the targets.
+	distances map[uint32]uint32
+}
+
+type directedTarget struct {
+	name string
+	syms []*backend.Symbol
+	pc   uint64 // target PC, 0 if all of the functions are targeted
+	dist map[uint32]uint32
+	// Progress of the corpus.
+	best      int // -1 if the corpus does not reach the max distance yet
+	bestInput string
+	improved  time.Time
+	reached   time.Time
+	reachedBy map[string]bool // corpus inputs that cover the target
+}
+
+func (mgr *Manager) createDirected() (*directedState, error) {
+	cfg := mgr.cfg.Directed
+	if cfg == nil {
+		return nil, nil
+	}
+	rg, err := getReportGenerator(mgr.cfg, mgr.modules)
+	if err != nil {
+		return nil, err
+	}
+	if rg.CallGraph == nil {
+		return nil, fmt.Errorf("directed fuzzing is not supported for %v", mgr.cfg.Target.Arch)
+	}
+	graph, err := rg.CallGraph()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the call graph: %w", err)
+	}
+	symbols := make(map[string][]*backend.Symbol)
+	for _, sym := range rg.Symbols {
+		symbols[sym.Name] = append(symbols[sym.Name], sym)
+	}
+	var targets []*directedTarget
+	for _, fn := range cfg.Functions {
+		if len(symbols[fn]) == 0 {
+			return nil, fmt.Errorf("directed function %v is not found", fn)
+		}
+		targets = append(targets, &directedTarget{name: fn, syms: symbols[fn]})
+	}
+	for _, pc := range cfg.RawPCs {
+		t, err := directedPCTarget(rg.Symbols, pc)
+		if err != nil {
+			return nil, err
+		}
+		targets = append(targets, t)
+	}
+	state := &directedState{
+		targets:   targets,
+		distances: make(map[uint32]uint32),
+	}
+	for _, t := range targets {
+		t.best = -1
+		t.reachedBy = make(map[string]bool)
+		t.dist = make(map[uint32]uint32)
+		for sym, d := range graph.Distances(t.syms, cfg.MaxDistance) {
+			for _, pc := range sym.PCs {
+				dist := uint32(d)
+				if t.pc != 0 && pc != t.pc {
+					// Other PCs of the target function are one step away from the target PC.
+					dist++
+				}
+				key := uint32(backend.NextInstructionPC(mgr.cfg.SysTarget, pc))
+				t.dist[key] = dist
+				if old, ok := state.distances[key]; !ok || dist < old {
+					state.distances[key] = dist
+				}
+			}
+		}
+		log.Logf(0, "directed target %v: %v PCs within distance %v", t.name, len(t.dist), cfg.MaxDistance)
+	}
+	return state, nil
+}
+
+func directedPCTarget(symbols []*backend.Symbol, pc uint64) (*directedTarget, error) {
+	for _, sym := range symbols {
+		if pc < sym.Start || pc >= sym.End {
+			continue
+		}
+		for _, pc1 := range sym.PCs {
+			if pc1 == pc {
+				return &directedTarget{
+					name: fmt.Sprintf("0x%x (%v)", pc, sym.Name),
+					syms: []*backend.Symbol{sym},
+					pc:   pc,
+				}, nil
+			}
+		}
+		return nil, fmt.Errorf("directed pc 0x%x in %v is not a coverage point", pc, sym.Name)
+	}
+	return nil, fmt.Errorf("directed pc 0x%x is not found in kernel symbols", pc)
+}
+
+func (mgr *Manager) directedDistances() map[uint32]uint32 {
+	mgr.mu.Lock()
+	defer mgr.mu.Unlock()
+	if mgr.directed == nil {
+		return nil
+	}
+	return mgr.directed.distances
+}
+
+// directedDistanceLocked returns the minimal distance of the canonical coverage to the targets,
+// or -1 if it's beyond the max distance (see rpctype.Input.DirectedDistance).
+func (mgr *Manager) directedDistanceLocked(cov []uint32) int {
+	if mgr.directed == nil {
+		return -1
+	}
+	best := -1
+	for _, pc := range cov {
+		if d, ok := mgr.directed.distances[pc]; ok && (best == -1 || int(d) < best) {
+			best = int(d)
+		}
+	}
+	return best
+}
+
+// directedInputLocked updates progress towards the targets with the canonical coverage of the corpus input.
+func (mgr *Manager) directedInputLocked(sig string, cov []uint32) {
+	if mgr.directed == nil {
+		return
+	}
+	now := time.Now()
+	for _, t := range mgr.directed.targets {
+		best := -1
+		for _, pc := range cov {
+			if d, ok := t.dist[pc]; ok && (best == -1 || int(d) < best) {
+				best = int(d)
+			}
+		}
+		if best == -1 {
+			continue
+		}
+		if best == 0 {
+			t.reachedBy[sig] = true
+		}
+		if t.best != -1 && best >= t.best {
+			continue
+		}
+		t.best, t.bestInput, t.improved = best, sig, now
+		log.Logf(0, "directed target %v: distance %v", t.name, best)
+		if best == 0 {
+			t.reached = now
+		}
+	}
+}
+
+// directedReachedLocked returns the number of reached targets and the total number of targets.
+func (mgr *Manager) directedReachedLocked() (int, int) {
+	if mgr.directed == nil {
+		return 0, 0
+	}
+	reached := 0
+	for _, t := range mgr.directed.targets {
+		if t.best == 0 {
+			reached++
+		}
+	}
+	return reached, len(mgr.directed.targets)
+}
+
+func (mgr *Manager) httpDirected(w http.ResponseWriter, r *http.Request) {
+	mgr.mu.Lock()
+	data := &UIDirectedData{}
+	if mgr.cfg.Directed != nil {
+		data.MaxDistance = mgr.cfg.Directed.MaxDistance
+	}
+	if mgr.directed != nil {
+		for _, t := range mgr.directed.targets {
+			data.Targets = append(data.Targets, UIDirectedTarget{
+				Name:      t.name,
+				PCs:       len(t.dist),
+				Best:      t.best,
+				BestInput: t.bestInput,
+				Improved:  t.improved,
+				Reached:   t.reached,
+				ReachedBy: len(t.reachedBy),
+			})
+		}
+	}
+	mgr.mu.Unlock()
+	executeTemplate(w, directedTemplate, data)
+}
+
+type UIDirectedData struct {
+	MaxDistance int
+	Targets     []UIDirectedTarget
+}
+
+type UIDirectedTarget struct {
+	Name      string
+	PCs       int
+	Best      int
+	BestInput string
+	Improved  time.Time
+	Reached   time.Time
+	ReachedBy int
+}
+
+var directedTemplate = pages.Create(`
+<!doctype html>
+<html>
+<head>
+	<title>syzkaller directed fuzzing</title>
+	{{HEAD}}
+</head>
+<body>
+{{if $.Targets}}
+<table class="list_table">
+	<caption>Directed fuzzing targets (max distance {{$.MaxDistance}}):</caption>
+	<tr>
+		<th>Target</th>
+		<th>PCs within max distance</th>
+		<th>Best distance</th>
+		<th>Improved</th>
+		<th>Reached</th>
+		<th>Inputs reaching</th>
+	</tr>
+	{{range $t := $.Targets}}
+	<tr>
+		<td>{{$t.Name}}</td>
+		<td>{{$t.PCs}}</td>
+		<td>{{if ge $t.Best 0}}<a href="/input?sig={{$t.BestInput}}">{{$t.Best}}</a>{{else}}-{{end}}</td>
+		<td>{{if not $t.Improved.IsZero}}{{formatTime $t.Improved}}{{end}}</td>
+		<td>{{if not $t.Reached.IsZero}}{{formatTime $t.Reached}}{{end}}</td>
+		<td>{{$t.ReachedBy}}</td>
+	</tr>
+	{{end}}
+</table>
+{{else}}
+<p>Directed fuzzing is not enabled{{if $.MaxDistance}} yet, distances are computed once the first fuzzer connects{{end}}.</p>
+{{end}}
+</body></html>
+`)
@@ -0,0 +1,83 @@
+// Copyright 2026 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/google/syzkaller/pkg/cover/backend"
+)
+
+func TestDirectedProgress(t *testing.T) {
+	newTarget := func(name string) *directedTarget {
+		return &directedTarget{
+			name:      name,
+			best:      -1,
+			dist:      map[uint32]uint32{10: 0, 20: 2},
+			reachedBy: make(map[string]bool),
+		}
+	}
+	t0, t1 := newTarget("t0"), newTarget("t1")
+	t1.dist = map[uint32]uint32{30: 1}
+	mgr := &Manager{directed: &directedState{targets: []*directedTarget{t0, t1}}}
+	mgr.directedInputLocked("a", []uint32{1, 20})
+	if t0.best != 2 || t0.bestInput != "a" || !t0.reached.IsZero() || t1.best != -1 {
+		t.Fatalf("bad progress after the first input: %+v %+v", t0, t1)
+	}
+	mgr.directedInputLocked("b", []uint32{10, 30})
+	mgr.directedInputLocked("c", []uint32{10})
+	if t0.best != 0 || t0.bestInput != "b" || t0.reached.IsZero() || len(t0.reachedBy) != 2 {
+		t.Fatalf("target is not reached: %+v", t0)
+	}
+	if t1.best != 1 || t1.bestInput != "b" {
+		t.Fatalf("bad progress of the second target: %+v", t1)
+	}
+	if reached, total := mgr.directedReachedLocked(); reached != 1 || total != 2 {
+		t.Fatalf("reached %v/%v targets, want 1/2", reached, total)
+	}
+}
+
+func TestDirectedCorpusInputs(t *testing.T) {
+	mgr := &Manager{
+		directed: &directedState{distances: map[uint32]uint32{10: 0, 20: 2}},
+		corpus: map[string]CorpusItem{
+			"a": {Prog: []byte("a"), Cover: []uint32{1, 20}},
+			"b": {Prog: []byte("b"), Cover: []uint32{10, 20}},
+			"c": {Prog: []byte("c"), Cover: []uint32{1}},
+		},
+	}
+	// Fuzzers get distances of inputs from the manager, since they don't use coverage of other fuzzers.
+	got := make(map[string]int)
+	for _, inp := range mgr.corpusInputsLocked() {
+		got[string(inp.Prog)] = inp.DirectedDistance
+	}
+	if want := map[string]int{"a": 2, "b": 0, "c": -1}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got distances %v, want %v", got, want)
+	}
+	mgr.directed = nil
+	if inp := mgr.corpusInputsLocked()[0]; inp.DirectedDistance != -1 {
+		t.Fatalf("got distance %v without directed fuzzing", inp.DirectedDistance)
+	}
+}
+
+func TestDirectedPCTarget(t *testing.T) {
+	symbols := []*backend.Symbol{
+		{ObjectUnit: backend.ObjectUnit{Name: "foo", PCs: []uint64{0x1004, 0x1010}}, Start: 0x1000, End: 0x1020},
+		{ObjectUnit: backend.ObjectUnit{Name: "bar", PCs: []uint64{0x1024}}, Start: 0x1020, End: 0x1040},
+	}
+	target, err := directedPCTarget(symbols, 0x1010)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if target.pc != 0x1010 || len(target.syms) != 1 || target.syms[0].Name != "foo" {
+		t.Fatalf("bad target: %+v", target)
+	}
+	if _, err := directedPCTarget(symbols, 0x1008); err == nil {
+		t.Fatalf("no error for a PC that is not a coverage point")
+	}
+	if _, err := directedPCTarget(symbols, 0x2000); err == nil {
+		t.Fatalf("no error for a PC outside of symbols")
+	}
+}
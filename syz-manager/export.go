@@ -0,0 +1,322 @@
+// Copyright 2026 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/google/syzkaller/pkg/log"
+	"github.com/google/syzkaller/pkg/signal"
+	"github.com/google/syzkaller/prog"
+)
+
+// Machine-readable variants of /syscalls, /corpus and /prio pages (requested with ?format=json|csv).
+// Responses are streamed row by row: JSON output is an array of objects, CSV output starts with a header.
+
+type ExportSyscall struct {
+	Name           string `json:"name"`
+	ID             int    `json:"id"`
+	Enabled        bool   `json:"enabled"`
+	DisabledReason string `json:"disabled_reason,omitempty"`
+	Inputs         int    `json:"inputs"`
+	Cover          int    `json:"cover"`
+	Signal         int    `json:"signal"`
+	Execs          uint64 `json:"execs"`
+	Success        uint64 `json:"success"`
+	// Number of failed executions per errno name.
+	Errnos map[string]uint64 `json:"errnos,omitempty"`
+	errnos []errnoCount
+	// Number of triaged inputs and the number of them with unstable new signal.
+	Triaged uint64 `json:"triaged"`
+	Flaky   uint64 `json:"flaky"`
+	// Number of calls that hit the timeout and upper bounds of percentiles of call durations.
+	Timeouts uint64 `json:"timeouts"`
+	P50Ms    uint64 `json:"p50_ms"`
+	P90Ms    uint64 `json:"p90_ms"`
+	P99Ms    uint64 `json:"p99_ms"`
+}
+
+type ExportInput struct {
+	Sig    string `json:"sig"`
+	Call   string `json:"call"`
+	Signal int    `json:"signal"`
+	Cover  int    `json:"cover"`
+	Origin string `json:"origin,omitempty"`
+	Prog   string `json:"prog"`
+}
+
+// ExportPrioRow is a row of the choice table matrix: weights of choosing each of the calls
+// next to Call, keyed by the call name.
+type ExportPrioRow struct {
+	Call    string           `json:"call"`
+	Weights map[string]int32 `json:"weights"`
+}
+
+type exportWriter struct {
+	w    io.Writer
+	csv  *csv.Writer
+	rows int
+}
+
+func newExportWriter(w http.ResponseWriter, format string, header []string) (*exportWriter, error) {
+	ew := &exportWriter{w: w}
+	switch format {
+	case "json":
+		w.Header().Set("Content-Type", "application/json")
+		if _, err := io.WriteString(w, "["); err != nil {
+			return nil, err
+		}
+	case "csv":
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		ew.csv = csv.NewWriter(w)
+		if err := ew.csv.Write(header); err != nil {
+			return nil, err
+		}
+	default:
+		err := fmt.Errorf("unknown format %q, supported formats: json, csv", format)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil, err
+	}
+	return ew, nil
+}
+
+// row writes obj as the next JSON array element, or fields as the next CSV row.
+func (ew *exportWriter) row(obj interface{}, fields ...string) error {
+	ew.rows++
+	if ew.csv != nil {
+		return ew.csv.Write(fields)
+	}
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return err
+	}
+	sep := ",\n"
+	if ew.rows == 1 {
+		sep = "\n"
+	}
+	if _, err := io.WriteString(ew.w, sep); err != nil {
+		return err
+	}
+	_, err = ew.w.Write(data)
+	return err
+}
+
+func (ew *exportWriter) close() error {
+	if ew.csv != nil {
+		ew.csv.Flush()
+		return ew.csv.Error()
+	}
+	_, err := io.WriteString(ew.w, "\n]\n")
+	return err
+}
+
+func (mgr *Manager) exportSyscalls(w http.ResponseWriter, format string) {
+	calls := mgr.collectExportSyscalls()
+	ew, err := newExportWriter(w, format, []string{
+		"name", "id", "enabled", "disabled_reason", "inputs", "cover", "signal", "execs", "success", "errnos",
+		"triaged", "flaky", "timeouts", "p50_ms", "p90_ms", "p99_ms"})
+	if err != nil {
+		return
+	}
+	for _, c := range calls {
+		err := ew.row(c, c.Name, strconv.Itoa(c.ID), strconv.FormatBool(c.Enabled), c.DisabledReason,
+			strconv.Itoa(c.Inputs), strconv.Itoa(c.Cover), strconv.Itoa(c.Signal),
+			strconv.FormatUint(c.Execs, 10), strconv.FormatUint(c.Success, 10), formatErrnos(c.errnos, 0),
+			strconv.FormatUint(c.Triaged, 10), strconv.FormatUint(c.Flaky, 10),
+			strconv.FormatUint(c.Timeouts, 10), strconv.FormatUint(c.P50Ms, 10),
+			strconv.FormatUint(c.P90Ms, 10), strconv.FormatUint(c.P99Ms, 10))
+		if err != nil {
+			log.Logf(1, "failed to export syscalls: %v", err)
+			return
+		}
+	}
+	ew.close()
+}
+
+func (mgr *Manager) collectExportSyscalls() []ExportSyscall {
+	mgr.mu.Lock()
+	defer mgr.mu.Unlock()
+	inConfig := make(map[int]bool)
+	for _, id := range mgr.cfg.Syscalls {
+		inConfig[id] = true
+	}
+	info := mgr.collectSyscallInfoUnlocked()
+	signals := make(map[string]signal.Signal)
+	for _, inp := range mgr.corpus {
+		sign := signals[inp.Call]
+		sign.Merge(inp.Signal.Deserialize())
+		signals[inp.Call] = sign
+	}
+	calls := make([]ExportSyscall, len(mgr.target.Syscalls))
+	for i, call := range mgr.target.Syscalls {
+		c := &calls[i]
+		c.Name = call.Name
+		c.ID = call.ID
+		c.Enabled = mgr.targetEnabledSyscalls[call]
+		if !c.Enabled && mgr.checkResult != nil {
+			switch {
+			case !inConfig[call.ID]:
+				c.DisabledReason = "disabled in the config"
+			case mgr.disabledReasons[call.ID] != "":
+				c.DisabledReason = mgr.disabledReasons[call.ID]
+			default:
+				c.DisabledReason = "disabled by the machine check"
+			}
+		}
+		if cc := info[call.Name]; cc != nil {
+			c.Inputs = cc.count
+			c.Cover = len(cc.cov)
+		}
+		c.Signal = signals[call.Name].Len()
+		if res := mgr.callResults[call.ID]; res != nil {
+			c.Execs = res.execs
+			c.Success = res.success
+			c.Timeouts = res.timeouts
+			c.P50Ms = uint64(res.durationPercentile(0.5) / time.Millisecond)
+			c.P90Ms = uint64(res.durationPercentile(0.9) / time.Millisecond)
+			c.P99Ms = uint64(res.durationPercentile(0.99) / time.Millisecond)
+			c.errnos = mgr.topErrnos(res)
+			if len(c.errnos) != 0 {
+				c.Errnos = make(map[string]uint64)
+				for _, e := range c.errnos {
+					c.Errnos[e.name] = e.count
+				}
+			}
+		}
+		if flakes := mgr.callFlakes[call.ID]; flakes != nil {
+			c.Triaged = flakes.triaged
+			c.Flaky = flakes.flaky
+		}
+	}
+	return calls
+}
+
+func (mgr *Manager) exportCorpus(w http.ResponseWriter, format, call string) {
+	// Corpus items are never modified in place, so we can serialize them without holding the mutex.
+	mgr.mu.Lock()
+	var sigs []string
+	items := make(map[string]CorpusItem)
+	for sig, inp := range mgr.corpus {
+		if call != "" && call != inp.Call {
+			continue
+		}
+		sigs = append(sigs, sig)
+		items[sig] = inp
+	}
+	mgr.mu.Unlock()
+	sort.Strings(sigs)
+
+	ew, err := newExportWriter(w, format, []string{"sig", "call", "signal", "cover", "origin", "prog"})
+	if err != nil {
+		return
+	}
+	for _, sig := range sigs {
+		inp := items[sig]
+		row := ExportInput{
+			Sig:    sig,
+			Call:   inp.Call,
+			Signal: len(inp.Signal.Elems),
+			Cover:  len(inp.Cover),
+			Origin: inp.Origin,
+			Prog:   string(inp.Prog),
+		}
+		err := ew.row(row, row.Sig, row.Call, strconv.Itoa(row.Signal), strconv.Itoa(row.Cover),
+			row.Origin, row.Prog)
+		if err != nil {
+			log.Logf(1, "failed to export corpus: %v", err)
+			return
+		}
+	}
+	ew.close()
+}
+
+// exportPrio exports the choice table that fuzzers would build for the current corpus,
+// if call is not empty, only the row for that call is exported.
+func (mgr *Manager) exportPrio(w http.ResponseWriter, format, callName string) {
+	mgr.mu.Lock()
+	if mgr.targetEnabledSyscalls == nil {
+		mgr.mu.Unlock()
+		http.Error(w, "machine check is not finished yet", http.StatusServiceUnavailable)
+		return
+	}
+	enabled := make(map[*prog.Syscall]bool)
+	for call := range mgr.targetEnabledSyscalls {
+		enabled[call] = true
+	}
+	var corpus []*prog.Prog
+	for _, inp := range mgr.corpus {
+		p, err := mgr.target.Deserialize(inp.Prog, prog.NonStrict)
+		if err != nil {
+			mgr.mu.Unlock()
+			http.Error(w, fmt.Sprintf("failed to deserialize program: %v", err), http.StatusInternalServerError)
+			return
+		}
+		if programEnabled(p, enabled) {
+			corpus = append(corpus, p)
+		}
+	}
+	learned := mgr.learnedCallPriorsTableLocked()
+	mgr.mu.Unlock()
+
+	ct := mgr.target.BuildChoiceTableWithPriors(corpus, enabled, mgr.cfg.CallPriorsTable, learned)
+	var calls []*prog.Syscall
+	for _, call := range mgr.target.Syscalls {
+		if ct.Generatable(call.ID) {
+			calls = append(calls, call)
+		}
+	}
+	rows := calls
+	if callName != "" {
+		call := mgr.target.SyscallMap[callName]
+		if call == nil || !ct.Generatable(call.ID) {
+			http.Error(w, fmt.Sprintf("unknown or disabled call: %v", callName), http.StatusBadRequest)
+			return
+		}
+		rows = []*prog.Syscall{call}
+	}
+
+	header := []string{"call"}
+	for _, call := range calls {
+		header = append(header, call.Name)
+	}
+	ew, err := newExportWriter(w, format, header)
+	if err != nil {
+		return
+	}
+	for _, bias := range rows {
+		row := ExportPrioRow{
+			Call:    bias.Name,
+			Weights: make(map[string]int32, len(calls)),
+		}
+		fields := []string{bias.Name}
+		for _, call := range calls {
+			weight := ct.Weight(bias.ID, call.ID)
+			row.Weights[call.Name] = weight
+			fields = append(fields, strconv.Itoa(int(weight)))
+		}
+		if err := ew.row(row, fields...); err != nil {
+			log.Logf(1, "failed to export priorities: %v", err)
+			return
+		}
+	}
+	ew.close()
+}
+
+// programEnabled says if all calls of the program are enabled
+// (the corpus can contain disabled calls if the config has changed).
+func programEnabled(p *prog.Prog, enabled map[*prog.Syscall]bool) bool {
+	for _, c := range p.Calls {
+		if !enabled[c.Meta] {
+			return false
+		}
+	}
+	return true
+}
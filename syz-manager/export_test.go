@@ -0,0 +1,50 @@
+// Copyright 2026 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestExportWriter(t *testing.T) {
+	rows := []ExportPrioRow{
+		{Call: "foo", Weights: map[string]int32{"foo": 1, "bar": 2}},
+		{Call: "bar", Weights: map[string]int32{"foo": 3, "bar": 4}},
+	}
+	export := func(format string, rows []ExportPrioRow) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		ew, err := newExportWriter(w, format, []string{"call", "foo", "bar"})
+		if err != nil {
+			return w
+		}
+		for _, row := range rows {
+			if err := ew.row(row, row.Call, "x", "y"); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := ew.close(); err != nil {
+			t.Fatal(err)
+		}
+		return w
+	}
+	for _, n := range []int{0, 1, 2} {
+		var got []ExportPrioRow
+		w := export("json", rows[:n])
+		if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+			t.Fatalf("bad json %q: %v", w.Body.String(), err)
+		}
+		if len(got) != n || n > 0 && got[n-1].Weights["bar"] != rows[n-1].Weights["bar"] {
+			t.Fatalf("bad json %q", w.Body.String())
+		}
+	}
+	if got, want := export("csv", rows).Body.String(), "call,foo,bar\nfoo,x,y\nbar,x,y\n"; got != want {
+		t.Fatalf("bad csv %q, want %q", got, want)
+	}
+	if w := export("xml", rows); w.Code != http.StatusBadRequest {
+		t.Fatalf("unknown format: got code %v", w.Code)
+	}
+}
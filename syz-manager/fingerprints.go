@@ -0,0 +1,131 @@
+// Copyright 2026 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"github.com/google/syzkaller/pkg/hash"
+	"github.com/google/syzkaller/pkg/rpctype"
+	"github.com/google/syzkaller/prog"
+)
+
+// Near-duplicate candidates (see mgrconfig.Config.MaxCandidatesPerFingerprint).
+// The manager counts corpus inputs and pending candidates per structural fingerprint
+// (see prog.Prog.Fingerprint) and does not admit external candidates with a fingerprint
+// that is already counted max_candidates_per_fingerprint times.
+// A candidate is pending from the moment it's queued until the fuzzer reports that it has
+// finished triaging it (or the fuzzer instance finishes), corpus inputs are counted
+// from the moment they are added to the corpus until they are minimized out of it.
+
+type pendingCandidate struct {
+	fingerprint string
+	fuzzer      string // the fuzzer the candidate was handed out to, if any
+}
+
+// fingerprintLocked returns the fingerprint of the program,
+// or an empty string if the number of candidates per fingerprint is not limited.
+func (mgr *Manager) fingerprintLocked(data []byte) string {
+	if mgr.cfg.MaxCandidatesPerFingerprint == 0 {
+		return ""
+	}
+	p, err := mgr.target.Deserialize(data, prog.NonStrict)
+	if err != nil {
+		// Let loadProg deal with broken programs.
+		return ""
+	}
+	return p.Fingerprint()
+}
+
+func (mgr *Manager) fingerprintAdmitsLocked(fingerprint string) bool {
+	return fingerprint == "" || mgr.fingerprints[fingerprint] < mgr.cfg.MaxCandidatesPerFingerprint
+}
+
+// admitCandidateLocked returns false if there are already max_candidates_per_fingerprint
+// corpus inputs and pending candidates near-identical to the program (unless force is set).
+func (mgr *Manager) admitCandidateLocked(data []byte, force bool) bool {
+	if force || mgr.fingerprintAdmitsLocked(mgr.fingerprintLocked(data)) {
+		return true
+	}
+	mgr.stats.candidatesNearDup.inc()
+	return false
+}
+
+// pushCandidateLocked queues the candidate and counts it as pending.
+func (mgr *Manager) pushCandidateLocked(src CandidateSource, cand rpctype.Candidate) {
+	if !mgr.candidates.push(src, cand) {
+		return
+	}
+	fingerprint := mgr.fingerprintLocked(cand.Prog)
+	if fingerprint == "" {
+		return
+	}
+	sig := hash.String(cand.Prog)
+	if mgr.pendingCandidates[sig] == nil {
+		mgr.pendingCandidates[sig] = &pendingCandidate{fingerprint: fingerprint}
+		mgr.fingerprints[fingerprint]++
+	}
+}
+
+func (mgr *Manager) candidateHandedOutLocked(name string, cand rpctype.Candidate) {
+	if pending := mgr.pendingCandidates[hash.String(cand.Prog)]; pending != nil {
+		pending.fuzzer = name
+	}
+}
+
+// releaseCandidateLocked stops counting the candidate as pending,
+// unless it's still queued to be handed out once more.
+func (mgr *Manager) releaseCandidateLocked(sig string) {
+	pending := mgr.pendingCandidates[sig]
+	if pending == nil || mgr.candidates.contains(sig) {
+		return
+	}
+	delete(mgr.pendingCandidates, sig)
+	mgr.releaseFingerprintLocked(pending.fingerprint)
+}
+
+// candidatesTriaged is called when the fuzzer has finished triaging the candidates.
+// Corpus inputs the candidates gave are already counted by then.
+func (mgr *Manager) candidatesTriaged(sigs []string) {
+	if len(sigs) == 0 {
+		return
+	}
+	mgr.mu.Lock()
+	defer mgr.mu.Unlock()
+	for _, sig := range sigs {
+		mgr.releaseCandidateLocked(sig)
+	}
+}
+
+// forgetCandidates stops counting candidates handed out to the fuzzer as pending,
+// should be called once the instance has finished.
+func (mgr *Manager) forgetCandidates(name string) {
+	mgr.mu.Lock()
+	defer mgr.mu.Unlock()
+	for sig, pending := range mgr.pendingCandidates {
+		if pending.fuzzer == name {
+			mgr.releaseCandidateLocked(sig)
+		}
+	}
+}
+
+func (mgr *Manager) corpusAddedLocked(sig string, data []byte) {
+	if fingerprint := mgr.fingerprintLocked(data); fingerprint != "" {
+		mgr.corpusFingerprints[sig] = fingerprint
+		mgr.fingerprints[fingerprint]++
+	}
+}
+
+func (mgr *Manager) corpusRemovedLocked(sig string) {
+	if fingerprint, ok := mgr.corpusFingerprints[sig]; ok {
+		delete(mgr.corpusFingerprints, sig)
+		mgr.releaseFingerprintLocked(fingerprint)
+	}
+}
+
+func (mgr *Manager) releaseFingerprintLocked(fingerprint string) {
+	if mgr.fingerprints[fingerprint] <= 1 {
+		delete(mgr.fingerprints, fingerprint)
+	} else {
+		mgr.fingerprints[fingerprint]--
+	}
+}
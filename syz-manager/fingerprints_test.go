@@ -0,0 +1,80 @@
+// Copyright 2026 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/google/syzkaller/pkg/hash"
+	"github.com/google/syzkaller/pkg/mgrconfig"
+	"github.com/google/syzkaller/pkg/rpctype"
+	"github.com/google/syzkaller/prog"
+	"github.com/google/syzkaller/sys/targets"
+)
+
+func TestCandidateFingerprints(t *testing.T) {
+	target, err := prog.GetTarget(targets.TestOS, targets.TestArch64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mgr := &Manager{
+		cfg:                &mgrconfig.Config{MaxCandidatesPerFingerprint: 1},
+		target:             target,
+		stats:              new(Stats),
+		candidates:         newCandidateQueues(nil, rand.New(rand.NewSource(0))),
+		fingerprints:       make(map[string]int),
+		corpusFingerprints: make(map[string]string),
+		pendingCandidates:  make(map[string]*pendingCandidate),
+	}
+	// The programs differ only in an integer value, so they are near-duplicates.
+	data0 := []byte("test$int(0x0, 0x0, 0x0, 0x0, 0x0)\n")
+	data1 := []byte("test$int(0x1, 0x2, 0x3, 0x4, 0x5)\n")
+	sig0, sig1 := hash.String(data0), hash.String(data1)
+	if mgr.fingerprintLocked(data0) != mgr.fingerprintLocked(data1) {
+		t.Fatalf("programs have different fingerprints")
+	}
+	if !mgr.admitCandidateLocked(data0, false) {
+		t.Fatalf("the first candidate is not admitted")
+	}
+	mgr.pushCandidateLocked(SourceHub, rpctype.Candidate{Prog: data0})
+	if mgr.admitCandidateLocked(data1, false) {
+		t.Fatalf("a near-duplicate of a queued candidate is admitted")
+	}
+	// The candidate is still pending while the fuzzer triages it.
+	cand, _, _ := mgr.candidates.next(time.Now())
+	mgr.candidateHandedOutLocked("vm-0", cand)
+	if mgr.admitCandidateLocked(data1, false) {
+		t.Fatalf("a near-duplicate of a candidate being triaged is admitted")
+	}
+	// The candidate gave a corpus input with the same fingerprint.
+	mgr.corpusAddedLocked(sig0, data0)
+	mgr.candidatesTriaged([]string{sig0})
+	if mgr.admitCandidateLocked(data1, false) {
+		t.Fatalf("a near-duplicate of a corpus input is admitted")
+	}
+	// Inputs that were never counted don't change the counts.
+	mgr.corpusRemovedLocked(sig1)
+	mgr.candidatesTriaged([]string{sig1})
+	if mgr.admitCandidateLocked(data1, false) {
+		t.Fatalf("unrelated inputs released the fingerprint")
+	}
+	mgr.corpusRemovedLocked(sig0)
+	if len(mgr.fingerprints) != 0 || !mgr.admitCandidateLocked(data1, false) {
+		t.Fatalf("fingerprint is not released after the input left the corpus: %v", mgr.fingerprints)
+	}
+	// Candidates of finished instances are released.
+	mgr.pushCandidateLocked(SourceHub, rpctype.Candidate{Prog: data1})
+	cand, _, _ = mgr.candidates.next(time.Now())
+	mgr.candidateHandedOutLocked("vm-0", cand)
+	mgr.forgetCandidates("vm-1")
+	if mgr.admitCandidateLocked(data0, false) {
+		t.Fatalf("candidate of another instance is released")
+	}
+	mgr.forgetCandidates("vm-0")
+	if len(mgr.fingerprints) != 0 || len(mgr.pendingCandidates) != 0 {
+		t.Fatalf("candidate is not released: %v", mgr.fingerprints)
+	}
+}
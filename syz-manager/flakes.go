@@ -0,0 +1,59 @@
+// Copyright 2026 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"github.com/google/syzkaller/pkg/log"
+	"github.com/google/syzkaller/pkg/rpctype"
+)
+
+// Fuzzers report how flaky new signal of triaged inputs is (see syz-fuzzer/flakes.go),
+// the manager aggregates the stats per syscall, shows them on the /syscalls page
+// and sends the learned flakiness to new fuzzers to adapt the number of triage runs.
+
+type callFlakes struct {
+	triaged  uint64
+	flaky    uint64
+	signal   uint64
+	unstable uint64
+}
+
+// The number of triaged inputs after which flakiness of a call is sent to fuzzers.
+const flakeMinSamples = 10
+
+func (mgr *Manager) mergeCallFlakes(flakes []rpctype.CallFlakeStats) {
+	if len(flakes) == 0 {
+		return
+	}
+	mgr.mu.Lock()
+	defer mgr.mu.Unlock()
+	for _, stats := range flakes {
+		if stats.ID < 0 || stats.ID >= len(mgr.target.Syscalls) {
+			log.Logf(0, "bad call flake stats id %v", stats.ID)
+			continue
+		}
+		res := mgr.callFlakes[stats.ID]
+		if res == nil {
+			res = &callFlakes{}
+			mgr.callFlakes[stats.ID] = res
+		}
+		res.triaged += uint64(stats.Triaged)
+		res.flaky += uint64(stats.Flaky)
+		res.signal += uint64(stats.Signal)
+		res.unstable += uint64(stats.Unstable)
+	}
+}
+
+// callFlakiness returns the share of flaky triaged inputs for syscalls with enough triaged inputs.
+func (mgr *Manager) callFlakiness() map[int]float64 {
+	mgr.mu.Lock()
+	defer mgr.mu.Unlock()
+	res := make(map[int]float64)
+	for id, flakes := range mgr.callFlakes {
+		if flakes.triaged >= flakeMinSamples {
+			res[id] = float64(flakes.flaky) / float64(flakes.triaged)
+		}
+	}
+	return res
+}
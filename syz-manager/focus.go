@@ -0,0 +1,125 @@
+// Copyright 2026 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/syzkaller/pkg/html/pages"
+	"github.com/google/syzkaller/pkg/log"
+	"github.com/google/syzkaller/pkg/mgrconfig"
+	"github.com/google/syzkaller/pkg/rpctype"
+)
+
+// Focus mode biases fuzzers towards a set of syscalls for a while (see mgrconfig.Config.Focus).
+// The focus is set on start from the config or at runtime on the /focus page,
+// and is sent to fuzzers with every poll until it expires.
+
+func (mgr *Manager) setFocus(focus *mgrconfig.Focus) {
+	mgr.mu.Lock()
+	defer mgr.mu.Unlock()
+	mgr.focus = focus
+	if focus == nil {
+		log.Logf(0, "focus is cleared")
+		return
+	}
+	mgr.focusExpires = time.Now().Add(focus.Length)
+	log.Logf(0, "focus on %v (%v calls) with strength %v for %v",
+		strings.Join(focus.Calls, ", "), len(focus.Syscalls), focus.Strength, focus.Length)
+}
+
+// currentFocus returns the focus for fuzzers, or nil if there is none.
+func (mgr *Manager) currentFocus() *rpctype.Focus {
+	mgr.mu.Lock()
+	defer mgr.mu.Unlock()
+	focus := mgr.focusLocked()
+	if focus == nil {
+		return nil
+	}
+	return &rpctype.Focus{
+		Calls:    focus.Syscalls,
+		Strength: focus.Strength,
+	}
+}
+
+func (mgr *Manager) focusLocked() *mgrconfig.Focus {
+	if mgr.focus != nil && time.Now().After(mgr.focusExpires) {
+		log.Logf(0, "focus on %v has expired", strings.Join(mgr.focus.Calls, ", "))
+		mgr.focus = nil
+	}
+	return mgr.focus
+}
+
+func (mgr *Manager) httpFocus(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodPost {
+		var focus *mgrconfig.Focus
+		if r.FormValue("clear") == "" {
+			focus = &mgrconfig.Focus{
+				Calls:    strings.Fields(strings.ReplaceAll(r.FormValue("calls"), ",", " ")),
+				Duration: r.FormValue("duration"),
+			}
+			if strength := r.FormValue("strength"); strength != "" {
+				var err error
+				if focus.Strength, err = strconv.ParseFloat(strength, 64); err != nil {
+					http.Error(w, fmt.Sprintf("bad strength: %v", err), http.StatusBadRequest)
+					return
+				}
+			}
+			if err := mgrconfig.ParseFocus(mgr.target, focus); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+		mgr.setFocus(focus)
+		http.Redirect(w, r, "/focus", http.StatusSeeOther)
+		return
+	}
+	mgr.mu.Lock()
+	data := &UIFocusData{}
+	if focus := mgr.focusLocked(); focus != nil {
+		data.Calls = strings.Join(focus.Calls, ", ")
+		data.Syscalls = len(focus.Syscalls)
+		data.Strength = focus.Strength
+		data.Expires = mgr.focusExpires
+	}
+	mgr.mu.Unlock()
+	executeTemplate(w, focusTemplate, data)
+}
+
+type UIFocusData struct {
+	Calls    string
+	Syscalls int
+	Strength float64
+	Expires  time.Time
+}
+
+var focusTemplate = pages.Create(`
+<!doctype html>
+<html>
+<head>
+	<title>syzkaller focus</title>
+	{{HEAD}}
+</head>
+<body>
+{{if $.Calls}}
+<p>Focus on {{$.Calls}} ({{$.Syscalls}} calls) with strength {{$.Strength}} until {{formatTime $.Expires}}.</p>
+<form method="post" action="/focus">
+	<input type="hidden" name="clear" value="1">
+	<input type="submit" value="clear">
+</form>
+{{else}}
+<p>No focus.</p>
+{{end}}
+<form method="post" action="/focus">
+	<label>Calls: <input type="text" name="calls" size="60" placeholder="io_uring_setup, syz_io_uring_*"></label>
+	<label>Strength: <input type="text" name="strength" size="4" placeholder="0.5"></label>
+	<label>Duration: <input type="text" name="duration" size="6" placeholder="1h"></label>
+	<input type="submit" value="set focus">
+</form>
+</body></html>
+`)
@@ -56,6 +56,10 @@ func (mgr *Manager) initHTTP() {
 	handle("/input", mgr.httpInput)
 	handle("/debuginput", mgr.httpDebugInput)
 	handle("/modules", mgr.modulesInfo)
+	handle("/quarantine", mgr.httpQuarantine)
+	handle("/focus", mgr.httpFocus)
+	handle("/directed", mgr.httpDirected)
+	handle("/shards", mgr.httpShards)
 	// Browsers like to request this, without special handler this goes to / handler.
 	handle("/favicon.ico", func(w http.ResponseWriter, r *http.Request) {})
 
@@ -94,21 +98,40 @@ func (mgr *Manager) httpConfig(w http.ResponseWriter, r *http.Request) {
 }
 
 func (mgr *Manager) httpSyscalls(w http.ResponseWriter, r *http.Request) {
+	if format := r.FormValue("format"); format != "" {
+		mgr.exportSyscalls(w, format)
+		return
+	}
 	data := &UISyscallsData{
 		Name: mgr.cfg.Name,
 	}
-	for c, cc := range mgr.collectSyscallInfo() {
-		var syscallID *int
-		if syscall, ok := mgr.target.SyscallMap[c]; ok {
-			syscallID = &syscall.ID
-		}
-		data.Calls = append(data.Calls, UICallType{
+	mgr.mu.Lock()
+	for c, cc := range mgr.collectSyscallInfoUnlocked() {
+		call := UICallType{
 			Name:   c,
-			ID:     syscallID,
 			Inputs: cc.count,
 			Cover:  len(cc.cov),
-		})
+		}
+		if syscall, ok := mgr.target.SyscallMap[c]; ok {
+			call.ID = &syscall.ID
+			if res := mgr.callResults[syscall.ID]; res != nil && res.execs != 0 {
+				call.Execs = res.execs
+				call.Success = fmt.Sprintf("%.1f%%", float64(res.success)*100/float64(res.execs))
+				call.Errnos = formatErrnos(mgr.topErrnos(res), 3)
+				call.Durations = res.formatDurations()
+				call.Timeouts = res.timeouts
+			}
+			if flakes := mgr.callFlakes[syscall.ID]; flakes != nil && flakes.triaged != 0 {
+				call.Triaged = flakes.triaged
+				call.Flaky = fmt.Sprintf("%.1f%%", float64(flakes.flaky)*100/float64(flakes.triaged))
+				if flakes.signal != 0 {
+					call.Unstable = fmt.Sprintf("%.1f%%", float64(flakes.unstable)*100/float64(flakes.signal))
+				}
+			}
+		}
+		data.Calls = append(data.Calls, call)
 	}
+	mgr.mu.Unlock()
 	sort.Slice(data.Calls, func(i, j int) bool {
 		return data.Calls[i].Name < data.Calls[j].Name
 	})
@@ -131,9 +154,35 @@ func (mgr *Manager) collectStats() []UIStat {
 		{Name: "uptime", Value: fmt.Sprint(time.Since(mgr.startTime) / 1e9 * 1e9)},
 		{Name: "fuzzing", Value: fmt.Sprint(mgr.fuzzingTime / 60e9 * 60e9)},
 		{Name: "corpus", Value: fmt.Sprint(len(mgr.corpus)), Link: "/corpus"},
-		{Name: "triage queue", Value: fmt.Sprint(len(mgr.candidates))},
+		{Name: "triage queue", Value: fmt.Sprintf("%v (corpus %v, hub %v, enrich %v)",
+			mgr.candidates.total(), mgr.candidates.len(SourceCorpus),
+			mgr.candidates.len(SourceHub), mgr.candidates.len(SourceEnrich))},
 		{Name: "signal", Value: fmt.Sprint(rawStats["signal"])},
 		{Name: "coverage", Value: fmt.Sprint(rawStats["coverage"]), Link: "/cover"},
+		{Name: "quarantine", Value: fmt.Sprint(len(mgr.quarantine)), Link: "/quarantine"},
+	}
+	if phase := mgr.curPhaseLocked(); phase != nil {
+		stats = append(stats, UIStat{Name: "phase", Value: phase.Name})
+	}
+	focus := "none"
+	if f := mgr.focusLocked(); f != nil {
+		focus = strings.Join(f.Calls, ", ")
+	}
+	stats = append(stats, UIStat{Name: "focus", Value: focus, Link: "/focus"})
+	if shards := mgr.cfg.CorpusShards; shards != nil {
+		stats = append(stats, UIStat{
+			Name:  "corpus shards",
+			Value: fmt.Sprintf("%v KB budget", shards.Budget),
+			Link:  "/shards",
+		})
+	}
+	if mgr.cfg.Directed != nil {
+		reached, total := mgr.directedReachedLocked()
+		stats = append(stats, UIStat{
+			Name:  "directed",
+			Value: fmt.Sprintf("%v / %v targets reached", reached, total),
+			Link:  "/directed",
+		})
 	}
 	if mgr.coverFilter != nil {
 		stats = append(stats, UIStat{
@@ -147,6 +196,19 @@ func (mgr *Manager) collectStats() []UIStat {
 	delete(rawStats, "signal")
 	delete(rawStats, "coverage")
 	delete(rawStats, "filtered coverage")
+	for op := prog.MutationOp(0); op < prog.MutationOpCount; op++ {
+		name := "mutation " + op.String()
+		applied, succeeded := rawStats[name], rawStats[name+" new input"]
+		delete(rawStats, name)
+		delete(rawStats, name+" new input")
+		if applied == 0 {
+			continue
+		}
+		stats = append(stats, UIStat{
+			Name:  name,
+			Value: fmt.Sprintf("%v (%v new inputs, %.3f%%)", applied, succeeded, float64(succeeded)*100/float64(applied)),
+		})
+	}
 	if mgr.checkResult != nil {
 		stats = append(stats, UIStat{
 			Name:  "syscalls",
@@ -195,6 +257,10 @@ func (mgr *Manager) httpCrash(w http.ResponseWriter, r *http.Request) {
 }
 
 func (mgr *Manager) httpCorpus(w http.ResponseWriter, r *http.Request) {
+	if format := r.FormValue("format"); format != "" {
+		mgr.exportCorpus(w, format, r.FormValue("call"))
+		return
+	}
 	mgr.mu.Lock()
 	defer mgr.mu.Unlock()
 
@@ -408,6 +474,10 @@ func (mgr *Manager) httpFileCover(w http.ResponseWriter, r *http.Request) {
 }
 
 func (mgr *Manager) httpPrio(w http.ResponseWriter, r *http.Request) {
+	if format := r.FormValue("format"); format != "" {
+		mgr.exportPrio(w, format, r.FormValue("call"))
+		return
+	}
 	mgr.mu.Lock()
 	defer mgr.mu.Unlock()
 
@@ -428,10 +498,24 @@ func (mgr *Manager) httpPrio(w http.ResponseWriter, r *http.Request) {
 		corpus = append(corpus, p)
 	}
 	prios := mgr.target.CalculatePriorities(corpus)
-
-	data := &UIPrioData{Call: callName}
+	priors, learned := mgr.cfg.CallPriorsTable, mgr.learnedCallPriorsTableLocked()
+	_, hasPriors := priors.Prior(call.ID, call.ID)
+	_, hasLearned := learned.Prior(call.ID, call.ID)
+	calculated := append([]int32{}, prios[call.ID]...)
+	priors.Blend(prios)
+	learned.Blend(prios)
+
+	data := &UIPrioData{Call: callName, HasPriors: hasPriors, HasLearned: hasLearned}
 	for i, p := range prios[call.ID] {
-		data.Prios = append(data.Prios, UIPrio{mgr.target.Syscalls[i].Name, p})
+		prior, _ := priors.Prior(call.ID, i)
+		learnedPrior, _ := learned.Prior(call.ID, i)
+		data.Prios = append(data.Prios, UIPrio{
+			Call:       mgr.target.Syscalls[i].Name,
+			Prio:       p,
+			Calculated: calculated[i],
+			Prior:      prior,
+			Learned:    learnedPrior,
+		})
 	}
 	sort.Slice(data.Prios, func(i, j int) bool {
 		return data.Prios[i].Prio > data.Prios[j].Prio
@@ -441,7 +525,8 @@ func (mgr *Manager) httpPrio(w http.ResponseWriter, r *http.Request) {
 
 func (mgr *Manager) httpFile(w http.ResponseWriter, r *http.Request) {
 	file := filepath.Clean(r.FormValue("name"))
-	if !strings.HasPrefix(file, "crashes/") && !strings.HasPrefix(file, "corpus/") {
+	if !strings.HasPrefix(file, "crashes/") && !strings.HasPrefix(file, "corpus/") &&
+		!strings.HasPrefix(file, "quarantine/") {
 		http.Error(w, "oh, oh, oh!", http.StatusInternalServerError)
 		return
 	}
@@ -466,6 +551,37 @@ func (mgr *Manager) httpInput(w http.ResponseWriter, r *http.Request) {
 	}
 	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
 	w.Write(inp.Prog)
+	if parentSig, parent := mgr.inputParentLocked(r.FormValue("sig")); parent != nil {
+		p0, err0 := mgr.target.Deserialize(parent.Prog, prog.NonStrict)
+		p1, err1 := mgr.target.Deserialize(inp.Prog, prog.NonStrict)
+		if err0 != nil || err1 != nil {
+			return
+		}
+		fmt.Fprintf(w, "\n# diff against the parent input %v:\n", parentSig)
+		for _, line := range strings.SplitAfter(prog.Diff(p0, p1).String(), "\n") {
+			if line != "" {
+				fmt.Fprintf(w, "# %v", line)
+			}
+		}
+	}
+}
+
+// inputParentLocked returns the corpus input the input was mutated from,
+// it is known only if the input trace was recorded (see trace_mutations config).
+func (mgr *Manager) inputParentLocked(sig string) (string, *CorpusItem) {
+	data, err := os.ReadFile(filepath.Join(mgr.cfg.Workdir, "traces", sig+".json"))
+	if err != nil {
+		return "", nil
+	}
+	trace := new(prog.Trace)
+	if err := json.Unmarshal(data, trace); err != nil || trace.Parent == "" {
+		return "", nil
+	}
+	parent, ok := mgr.corpus[trace.Parent]
+	if !ok {
+		return "", nil
+	}
+	return trace.Parent, &parent
 }
 
 func (mgr *Manager) httpDebugInput(w http.ResponseWriter, r *http.Request) {
@@ -663,6 +779,10 @@ func readCrash(workdir, dir string, repros map[string]bool, start time.Time, ful
 			if osutil.IsExist(filepath.Join(workdir, reportFile)) {
 				crash.Report = reportFile
 			}
+			recentFile := filepath.Join("crashes", dir, "recent-progs"+index)
+			if osutil.IsExist(filepath.Join(workdir, recentFile)) {
+				crash.RecentProgs = recentFile
+			}
 		}
 		sort.Slice(crashes, func(i, j int) bool {
 			return crashes[i].Time.After(crashes[j].Time)
@@ -738,12 +858,13 @@ type UICrashType struct {
 }
 
 type UICrash struct {
-	Index  int
-	Time   time.Time
-	Active bool
-	Log    string
-	Report string
-	Tag    string
+	Index       int
+	Time        time.Time
+	Active      bool
+	Log         string
+	Report      string
+	RecentProgs string
+	Tag         string
 }
 
 type UIStat struct {
@@ -753,10 +874,20 @@ type UIStat struct {
 }
 
 type UICallType struct {
-	Name   string
-	ID     *int
-	Inputs int
-	Cover  int
+	Name    string
+	ID      *int
+	Inputs  int
+	Cover   int
+	Execs   uint64
+	Success string
+	Errnos  string // top errnos of failed executions
+	// Upper bounds of p50/p90/p99 of call durations and the number of calls that hit the timeout.
+	Durations string
+	Timeouts  uint64
+	Triaged   uint64
+	Flaky     string // share of triaged inputs with unstable new signal
+	// Share of new signal of triaged inputs that was not reproduced by all triage runs.
+	Unstable string
 }
 
 type UICorpus struct {
@@ -845,11 +976,19 @@ var syscallsTemplate = pages.Create(`
 <body>
 
 <table class="list_table">
-	<caption>Per-syscall coverage:</caption>
+	<caption>Per-syscall coverage (<a href="/syscalls?format=json">json</a>, <a href="/syscalls?format=csv">csv</a>):</caption>
 	<tr>
 		<th><a onclick="return sortTable(this, 'Syscall', textSort)" href="#">Syscall</a></th>
 		<th><a onclick="return sortTable(this, 'Inputs', numSort)" href="#">Inputs</a></th>
 		<th><a onclick="return sortTable(this, 'Coverage', numSort)" href="#">Coverage</a></th>
+		<th><a onclick="return sortTable(this, 'Execs', numSort)" href="#">Execs</a></th>
+		<th><a onclick="return sortTable(this, 'Success', floatSort)" href="#">Success</a></th>
+		<th>Errnos</th>
+		<th>Duration p50 / p90 / p99</th>
+		<th><a onclick="return sortTable(this, 'Timeouts', numSort)" href="#">Timeouts</a></th>
+		<th><a onclick="return sortTable(this, 'Triaged', numSort)" href="#">Triaged</a></th>
+		<th><a onclick="return sortTable(this, 'Flaky', floatSort)" href="#">Flaky</a></th>
+		<th><a onclick="return sortTable(this, 'Unstable signal', floatSort)" href="#">Unstable signal</a></th>
 		<th>Prio</th>
 	</tr>
 	{{range $c := $.Calls}}
@@ -857,6 +996,14 @@ var syscallsTemplate = pages.Create(`
 		<td>{{$c.Name}}{{if $c.ID }} [{{$c.ID}}]{{end}}</td>
 		<td><a href='/corpus?call={{$c.Name}}'>{{$c.Inputs}}</a></td>
 		<td><a href='/cover?call={{$c.Name}}'>{{$c.Cover}}</a></td>
+		<td>{{$c.Execs}}</td>
+		<td>{{$c.Success}}</td>
+		<td>{{$c.Errnos}}</td>
+		<td>{{$c.Durations}}</td>
+		<td>{{$c.Timeouts}}</td>
+		<td>{{$c.Triaged}}</td>
+		<td>{{$c.Flaky}}</td>
+		<td>{{$c.Unstable}}</td>
 		<td><a href='/prio?call={{$c.Name}}'>prio</a></td>
 	</tr>
 	{{end}}
@@ -889,7 +1036,12 @@ Report: <a href="/report?id={{.ID}}">{{.Triaged}}</a>
 	{{range $c := $.Crashes}}
 	<tr>
 		<td>{{$c.Index}}</td>
-		<td><a href="/file?name={{$c.Log}}">log</a></td>
+		<td>
+			<a href="/file?name={{$c.Log}}">log</a>
+			{{if $c.RecentProgs}}
+				<a href="/file?name={{$c.RecentProgs}}">recent progs</a>
+			{{end}}
+		</td>
 		<td>
 			{{if $c.Report}}
 				<a href="/file?name={{$c.Report}}">report</a></td>
@@ -934,13 +1086,18 @@ var corpusTemplate = pages.Create(`
 `)
 
 type UIPrioData struct {
-	Call  string
-	Prios []UIPrio
+	Call       string
+	HasPriors  bool // there are external call priors for Call
+	HasLearned bool // there are learned call priors for Call
+	Prios      []UIPrio
 }
 
 type UIPrio struct {
-	Call string
-	Prio int32
+	Call       string
+	Prio       int32
+	Calculated int32 // priority before blending with call priors
+	Prior      int32
+	Learned    int32
 }
 
 var prioTemplate = pages.Create(`
@@ -955,11 +1112,29 @@ var prioTemplate = pages.Create(`
 	<caption>Priorities for {{$.Call}}:</caption>
 	<tr>
 		<th><a onclick="return sortTable(this, 'Prio', floatSort)" href="#">Prio</a></th>
+		{{if or $.HasPriors $.HasLearned}}
+		<th><a onclick="return sortTable(this, 'Calculated', floatSort)" href="#">Calculated</a></th>
+		{{end}}
+		{{if $.HasPriors}}
+		<th><a onclick="return sortTable(this, 'Prior', floatSort)" href="#">Prior</a></th>
+		{{end}}
+		{{if $.HasLearned}}
+		<th><a onclick="return sortTable(this, 'Learned', floatSort)" href="#">Learned</a></th>
+		{{end}}
 		<th><a onclick="return sortTable(this, 'Call', textSort)" href="#">Call</a></th>
 	</tr>
 	{{range $p := $.Prios}}
 	<tr>
 		<td>{{printf "%5v" $p.Prio}}</td>
+		{{if or $.HasPriors $.HasLearned}}
+		<td>{{printf "%5v" $p.Calculated}}</td>
+		{{end}}
+		{{if $.HasPriors}}
+		<td>{{printf "%5v" $p.Prior}}</td>
+		{{end}}
+		{{if $.HasLearned}}
+		<td>{{printf "%5v" $p.Learned}}</td>
+		{{end}}
 		<td><a href='/prio?call={{$p.Call}}'>{{$p.Call}}</a></td>
 	</tr>
 	{{end}}
@@ -75,12 +75,16 @@ type HubManagerView interface {
 	getMinimizedCorpus() (corpus, repros [][]byte)
 	addNewCandidates(candidates []rpctype.Candidate)
 	hubIsUnreachable()
+	hubSyncPaused() bool
 }
 
 func (hc *HubConnector) loop() {
 	var hub *rpctype.RPCClient
 	var doneOnce bool
 	for query := 0; ; time.Sleep(10 * time.Minute) {
+		if hc.mgr.hubSyncPaused() {
+			continue
+		}
 		corpus, repros := hc.mgr.getMinimizedCorpus()
 		hc.newRepros = append(hc.newRepros, repros...)
 		if hub == nil {
@@ -30,11 +30,13 @@ import (
 	"github.com/google/syzkaller/pkg/log"
 	"github.com/google/syzkaller/pkg/mgrconfig"
 	"github.com/google/syzkaller/pkg/osutil"
+	"github.com/google/syzkaller/pkg/progring"
 	"github.com/google/syzkaller/pkg/report"
 	crash_pkg "github.com/google/syzkaller/pkg/report/crash"
 	"github.com/google/syzkaller/pkg/repro"
 	"github.com/google/syzkaller/pkg/rpctype"
 	"github.com/google/syzkaller/pkg/signal"
+	"github.com/google/syzkaller/pkg/summary"
 	"github.com/google/syzkaller/prog"
 	"github.com/google/syzkaller/sys/targets"
 	"github.com/google/syzkaller/vm"
@@ -89,8 +91,9 @@ type Manager struct {
 	mu                    sync.Mutex
 	phase                 int
 	targetEnabledSyscalls map[*prog.Syscall]bool
+	disabledReasons       map[int]string // syscall ID -> why the machine check disabled it
 
-	candidates       []rpctype.Candidate // untriaged inputs from corpus and hub
+	candidates       *CandidateQueues // untriaged inputs from corpus, hub and enrichment
 	disabledHashes   map[string]struct{}
 	corpus           map[string]CorpusItem
 	seeds            [][]byte
@@ -114,6 +117,56 @@ type Manager struct {
 	modulesInitialized bool
 
 	assetStorage *asset.Storage
+
+	enrichPeriod time.Duration
+	benchMu      sync.Mutex
+	benchFile    *os.File
+
+	// Experiment phase schedule state (see phases.go).
+	// These are protected by mu.
+	expPhase        int // index of the current phase in cfg.Phases, -1 before the schedule starts
+	expPhaseStart   time.Time
+	expPhaseBase    map[string]uint64
+	expPhaseResults []PhaseResult
+	// Closed to restart VMs when the phase changes the set of enabled syscalls.
+	expPhaseStop chan bool
+	// Candidates with syscalls that are disabled in the fuzzers that asked for them,
+	// they are queued again on the next phase change.
+	expPhaseHeld []heldCandidate
+
+	// Focus mode state (see focus.go), protected by mu.
+	focus        *mgrconfig.Focus
+	focusExpires time.Time
+
+	// Seed quarantine state (see quarantine.go), protected by mu.
+	seedsInFlight map[string]map[string]*seedInFlight // fuzzer name -> seed sig -> seed
+	quarantine    map[string]*QuarantinedSeed
+
+	// Campaign summary state (see summary.go), protected by mu.
+	coveredSyscalls map[string]bool
+	newCovered      bytes.Buffer      // covered syscalls not yet written to the workdir
+	inputOrigins    map[string]uint64 // number of corpus inputs per rpctype.Input.Origin
+	timelineMu      sync.Mutex
+
+	// Near-duplicate candidate accounting (see fingerprints.go), protected by mu.
+	fingerprints       map[string]int               // corpus inputs and pending candidates per prog.Prog.Fingerprint
+	corpusFingerprints map[string]string            // corpus input sig -> fingerprint
+	pendingCandidates  map[string]*pendingCandidate // candidate sig -> pending candidate
+	nearDupSeeds       map[string]string            // enriched seed file -> fingerprint, for rejected seeds
+
+	// Aggregated outcomes of call insertions (see callpairs.go), protected by mu.
+	callPairs map[prog.CallPair]*callPairOutcomes
+	// Call priors learned from callPairs and their version (see callpairs.go), protected by mu.
+	learnedPriors        map[string]map[string]float64
+	learnedPriorsVersion int
+	// Aggregated results of executed calls per syscall ID (see callresults.go), protected by mu.
+	callResults map[int]*callResults
+	// Aggregated flakiness of triaged inputs per syscall ID (see flakes.go), protected by mu.
+	callFlakes map[int]*callFlakes
+	// Directed fuzzing targets and progress (see directed.go), protected by mu.
+	directed *directedState
+	// Corpus subsets of shards (see shards.go), protected by mu, nil if sharding is disabled.
+	shards *shardSet
 }
 
 type CorpusItemUpdate struct {
@@ -127,6 +180,7 @@ type CorpusItem struct {
 	Signal  signal.Serial
 	Cover   []uint32
 	Updates []CorpusItemUpdate
+	Origin  string // what produced the input, see rpctype.Input.Origin
 }
 
 func (item *CorpusItem) RPCInput() rpctype.Input {
@@ -160,6 +214,7 @@ type Crash struct {
 	hub     bool // this crash was created based on a repro from hub
 	*report.Report
 	machineInfo []byte
+	recentProgs []byte // programs dumped by fuzzer procs on executor failures, see attachRecentProgs
 }
 
 func main() {
@@ -217,29 +272,48 @@ func RunManager(cfg *mgrconfig.Config) {
 	}
 
 	mgr := &Manager{
-		cfg:              cfg,
-		vmPool:           vmPool,
-		target:           cfg.Target,
-		sysTarget:        cfg.SysTarget,
-		reporter:         reporter,
-		crashdir:         crashdir,
-		startTime:        time.Now(),
-		stats:            &Stats{haveHub: cfg.HubClient != ""},
-		crashTypes:       make(map[string]bool),
-		corpus:           make(map[string]CorpusItem),
-		disabledHashes:   make(map[string]struct{}),
-		memoryLeakFrames: make(map[string]bool),
-		dataRaceFrames:   make(map[string]bool),
-		fresh:            true,
-		vmStop:           make(chan bool),
-		hubReproQueue:    make(chan *Crash, 10),
-		needMoreRepros:   make(chan chan bool),
-		reproRequest:     make(chan chan map[string]bool),
-		usedFiles:        make(map[string]time.Time),
-		saturatedCalls:   make(map[string]bool),
-	}
+		cfg:                cfg,
+		vmPool:             vmPool,
+		target:             cfg.Target,
+		sysTarget:          cfg.SysTarget,
+		reporter:           reporter,
+		crashdir:           crashdir,
+		startTime:          time.Now(),
+		stats:              &Stats{haveHub: cfg.HubClient != ""},
+		crashTypes:         make(map[string]bool),
+		corpus:             make(map[string]CorpusItem),
+		disabledHashes:     make(map[string]struct{}),
+		memoryLeakFrames:   make(map[string]bool),
+		dataRaceFrames:     make(map[string]bool),
+		fresh:              true,
+		vmStop:             make(chan bool),
+		hubReproQueue:      make(chan *Crash, 10),
+		needMoreRepros:     make(chan chan bool),
+		reproRequest:       make(chan chan map[string]bool),
+		usedFiles:          make(map[string]time.Time),
+		saturatedCalls:     make(map[string]bool),
+		expPhase:           -1,
+		expPhaseStop:       make(chan bool),
+		seedsInFlight:      make(map[string]map[string]*seedInFlight),
+		quarantine:         make(map[string]*QuarantinedSeed),
+		coveredSyscalls:    make(map[string]bool),
+		inputOrigins:       make(map[string]uint64),
+		fingerprints:       make(map[string]int),
+		corpusFingerprints: make(map[string]string),
+		pendingCandidates:  make(map[string]*pendingCandidate),
+		nearDupSeeds:       make(map[string]string),
+		callPairs:          make(map[prog.CallPair]*callPairOutcomes),
+		callResults:        make(map[int]*callResults),
+		callFlakes:         make(map[int]*callFlakes),
+	}
+	if cfg.CorpusShards != nil {
+		mgr.shards = newShardSet(cfg.CorpusShards.Budget << 10)
+	}
+	mgr.candidates = newCandidateQueues(cfg.CandidateQueues, rand.New(rand.NewSource(time.Now().UnixNano())))
 
 	mgr.recordCmd()
+	mgr.initSummary()
+	mgr.loadQuarantine()
 	mgr.preloadCorpus()
 	mgr.initStats() // Initializes prometheus variables.
 	mgr.initHTTP()  // Creates HTTP server.
@@ -282,7 +356,7 @@ func RunManager(cfg *mgrconfig.Config) {
 			corpusCover := mgr.stats.corpusCover.get()
 			corpusSignal := mgr.stats.corpusSignal.get()
 			maxSignal := mgr.stats.maxSignal.get()
-			triageQLen := len(mgr.candidates)
+			triageQLen := mgr.candidates.total()
 			mgr.mu.Unlock()
 			numReproducing := atomic.LoadUint32(&mgr.numReproducing)
 			numFuzzing := atomic.LoadUint32(&mgr.numFuzzing)
@@ -292,34 +366,21 @@ func RunManager(cfg *mgrconfig.Config) {
 		}
 	}()
 
-	go func() {
-		if *flagEnrich != "" && *flagPeriod != "" {
-			// Parse the duration string
-			duration, err := time.ParseDuration(*flagPeriod)
-			if err != nil {
-				log.Logf(0, "error parsing duration: %v. Exit go func.", err)
-				return
-			}
-			seeEndFlag := 0
-			for {
-				log.Logf(0, "[+] enrichCorpus sleep with period: %v to go", duration)
-				time.Sleep(duration)
-				// check the EndFlag: GENERATION_END
-				if _, err := os.Stat(filepath.Join(mgr.cfg.Workdir, "GENERATION_END")); err == nil {
-					seeEndFlag++
-					log.Logf(0, "[+] GENERATION_END flag detected. seeEndFlag: %d", seeEndFlag)
-				}
-				if seeEndFlag == 2 {
-					log.Logf(0, "[+] seeEndFlag reached 2. Exit enrichCorpus loop")
-					break
-				}
-				if *flagRepair {
-					mgr.repairCorpus()
-				}
-				mgr.enrichCorpus()
-			}
+	if *flagPeriod != "" {
+		mgr.enrichPeriod, err = time.ParseDuration(*flagPeriod)
+		if err != nil {
+			log.Logf(0, "error parsing duration: %v. Enrichment is disabled.", err)
 		}
-	}()
+	}
+	go mgr.enrichLoop()
+	mgr.initLearnedPriors()
+
+	if len(cfg.Phases) != 0 {
+		go mgr.phaseLoop()
+	}
+	if cfg.Focus != nil {
+		mgr.setFocus(cfg.Focus)
+	}
 
 	go func() {
 		if *flagStatCall {
@@ -336,6 +397,7 @@ func RunManager(cfg *mgrconfig.Config) {
 	if *flagBench != "" {
 		mgr.initBench()
 	}
+	go mgr.benchLoop()
 
 	if *flagBackup != "" {
 		mgr.initBackup()
@@ -351,9 +413,11 @@ func RunManager(cfg *mgrconfig.Config) {
 		log.Logf(0, "you are supposed to start syz-fuzzer manually as:")
 		log.Logf(0, "syz-fuzzer -manager=manager.ip:%v [other flags as necessary]", mgr.serv.port)
 		<-vm.Shutdown
+		mgr.writeSummary()
 		return
 	}
 	mgr.vmLoop()
+	mgr.writeSummary()
 }
 
 func (mgr *Manager) recordCmd() {
@@ -433,35 +497,66 @@ func (mgr *Manager) initBench() {
 	if err != nil {
 		log.Fatalf("failed to open bench file: %v", err)
 	}
-	go func() {
-		for {
-			time.Sleep(time.Minute)
-			vals := mgr.stats.all()
-			mgr.mu.Lock()
-			if mgr.firstConnect.IsZero() {
-				mgr.mu.Unlock()
-				continue
-			}
-			mgr.minimizeCorpus()
-			vals["corpus"] = uint64(len(mgr.corpus))
-			vals["uptime"] = uint64(time.Since(mgr.firstConnect)) / 1e9
-			vals["fuzzing"] = uint64(mgr.fuzzingTime) / 1e9
-			vals["candidates"] = uint64(len(mgr.candidates))
-			vals["EnabledSyscalls"] = uint64(len(mgr.targetEnabledSyscalls))
-			vals["syscalls"] = uint64(len(gCoverCalls))
-			vals["EnrichCnt"] = uint64(enrichCnt)
-			vals["costT"] = uint64(costT) / 1e9
-			mgr.mu.Unlock()
+	mgr.benchFile = f
+}
 
-			data, err := json.MarshalIndent(vals, "", "  ")
-			if err != nil {
-				log.Fatalf("failed to serialize bench data")
-			}
-			if _, err := f.Write(append(data, '\n')); err != nil {
-				log.Fatalf("failed to write bench data")
-			}
-		}
-	}()
+// benchLoop periodically writes stats to the bench file (if any) and to the summary timeline.
+func (mgr *Manager) benchLoop() {
+	for {
+		time.Sleep(time.Minute)
+		mgr.writeBench()
+	}
+}
+
+func (mgr *Manager) writeBench() {
+	mgr.mu.Lock()
+	if mgr.benchFile != nil && !mgr.firstConnect.IsZero() {
+		mgr.minimizeCorpus()
+	}
+	vals, covered := mgr.summaryDataLocked()
+	mgr.mu.Unlock()
+	mgr.writeSummaryData(vals, covered)
+	if mgr.benchFile == nil || vals == nil {
+		return
+	}
+
+	data, err := json.MarshalIndent(vals, "", "  ")
+	if err != nil {
+		log.Fatalf("failed to serialize bench data")
+	}
+	mgr.benchMu.Lock()
+	defer mgr.benchMu.Unlock()
+	if _, err := mgr.benchFile.Write(append(data, '\n')); err != nil {
+		log.Fatalf("failed to write bench data")
+	}
+}
+
+// benchValsLocked returns the stats written to the bench file and to the summary timeline.
+func (mgr *Manager) benchValsLocked() map[string]uint64 {
+	vals := mgr.stats.all()
+	vals["corpus"] = uint64(len(mgr.corpus))
+	vals["uptime"] = uint64(time.Since(mgr.firstConnect)) / 1e9
+	vals["fuzzing"] = uint64(mgr.fuzzingTime) / 1e9
+	vals["candidates"] = uint64(mgr.candidates.total())
+	for src := CandidateSource(0); src < SourceCount; src++ {
+		vals["candidates "+src.String()] = uint64(mgr.candidates.len(src))
+	}
+	vals["EnabledSyscalls"] = uint64(len(mgr.targetEnabledSyscalls))
+	vals["syscalls"] = uint64(len(gCoverCalls))
+	vals["EnrichCnt"] = uint64(enrichCnt)
+	vals["costT"] = uint64(costT) / 1e9
+	for origin, n := range mgr.inputOrigins {
+		vals["origin "+origin] = n
+	}
+	mgr.addPhaseBenchLocked(vals)
+	if mgr.focusLocked() != nil {
+		vals["focus"] = 1
+	}
+	if mgr.directed != nil {
+		reached, _ := mgr.directedReachedLocked()
+		vals["directed targets reached"] = uint64(reached)
+	}
+	return vals
 }
 
 type RunResult struct {
@@ -502,6 +597,7 @@ func (mgr *Manager) vmLoop() {
 	for shutdown != nil || instances.Len() != vmCount {
 		mgr.mu.Lock()
 		phase := mgr.phase
+		reproEnabled := mgr.reproduceEnabledLocked()
 		mgr.mu.Unlock()
 
 		for crash := range pendingRepro {
@@ -522,7 +618,7 @@ func (mgr *Manager) vmLoop() {
 			len(pendingRepro), len(reproducing), len(reproQueue))
 
 		canRepro := func() bool {
-			return phase >= phaseTriagedHub && len(reproQueue) != 0 &&
+			return phase >= phaseTriagedHub && reproEnabled && len(reproQueue) != 0 &&
 				(int(atomic.LoadUint32(&mgr.numReproducing))+1)*instancesPerRepro <= maxReproVMs
 		}
 
@@ -576,13 +672,18 @@ func (mgr *Manager) vmLoop() {
 			instances.Put(res.idx)
 			// On shutdown qemu crashes with "qemu: terminating on signal 2",
 			// which we detect as "lost connection". Don't save that as crash.
+			instanceName := fmt.Sprintf("vm-%d", res.idx)
 			if shutdown != nil && res.crash != nil {
+				mgr.attachRecentProgs(res.crash)
+				mgr.quarantineSeeds(instanceName, res.crash)
 				needRepro := mgr.saveCrash(res.crash)
 				if needRepro {
 					log.Logf(1, "loop: add pending repro for '%v'", res.crash.Title)
 					pendingRepro[res.crash] = true
 				}
 			}
+			mgr.forgetSeeds(instanceName)
+			mgr.forgetCandidates(instanceName)
 		case res := <-reproDone:
 			atomic.AddUint32(&mgr.numReproducing, ^uint32(0))
 			crepro := false
@@ -743,8 +844,42 @@ func (pool *ResourcePool) TakeOne() *int {
 	return &ret[0]
 }
 
-func (mgr *Manager) repairCorpus() {
-	enrichDir := *flagEnrich
+func (mgr *Manager) enrichLoop() {
+	seeEndFlag := 0
+	for {
+		enrichDir, period := mgr.enrichSettings()
+		if enrichDir == "" || period == 0 {
+			// Enrichment is disabled, but may be enabled later by an experiment phase.
+			if len(mgr.cfg.Phases) == 0 {
+				return
+			}
+			time.Sleep(time.Minute)
+			continue
+		}
+		log.Logf(0, "[+] enrichCorpus sleep with period: %v to go", period)
+		time.Sleep(period)
+		// check the EndFlag: GENERATION_END
+		if _, err := os.Stat(filepath.Join(mgr.cfg.Workdir, "GENERATION_END")); err == nil {
+			seeEndFlag++
+			log.Logf(0, "[+] GENERATION_END flag detected. seeEndFlag: %d", seeEndFlag)
+		}
+		if seeEndFlag == 2 {
+			log.Logf(0, "[+] seeEndFlag reached 2. Exit enrichCorpus loop")
+			mgr.writeSummary()
+			break
+		}
+		// The phase may have changed while we were sleeping.
+		if enrichDir, _ = mgr.enrichSettings(); enrichDir == "" {
+			continue
+		}
+		if *flagRepair {
+			mgr.repairCorpus(enrichDir)
+		}
+		mgr.enrichCorpus(enrichDir)
+	}
+}
+
+func (mgr *Manager) repairCorpus(enrichDir string) {
 	log.Logf(0, "[+] Start to repair corpus %v", enrichDir)
 	repairBin := filepath.Join(mgr.cfg.Syzkaller, "bin", "syz-repair")
 	if _, err := osutil.RunCmd(2*time.Minute, "", repairBin, enrichDir, enrichDir); err != nil {
@@ -753,20 +888,15 @@ func (mgr *Manager) repairCorpus() {
 	log.Logf(0, "[+] Success to repair corpus %v", enrichDir)
 }
 
-func (mgr *Manager) enrichCorpus() {
+func (mgr *Manager) enrichCorpus(enrichDir string) {
 	mgr.mu.Lock()
 	defer mgr.mu.Unlock()
-	enrichDir := *flagEnrich
 	log.Logf(0, "[+] Start to enrich corpus, try to load progs from %v", enrichDir)
 	if osutil.IsExist(enrichDir) {
 		seeds, err := os.ReadDir(enrichDir)
 		if err != nil {
 			log.Fatalf("failed to read enrich dir: %v", err)
 		}
-		canShuffle := false
-		if len(mgr.candidates) == 0 {
-			canShuffle = true
-		}
 		for _, seed := range seeds {
 			loadedSeedsMu.Lock()
 			_, loaded := loadedSeeds[seed.Name()]
@@ -776,30 +906,29 @@ func (mgr *Manager) enrichCorpus() {
 				continue
 			}
 
+			// Seeds rejected as near-duplicates are retried later, once programs
+			// with the same fingerprint leave the corpus or fail triage.
+			if fingerprint, ok := mgr.nearDupSeeds[seed.Name()]; ok && !mgr.fingerprintAdmitsLocked(fingerprint) {
+				continue
+			}
 			data, err := os.ReadFile(filepath.Join(enrichDir, seed.Name()))
 			if err != nil {
 				log.Fatalf("failed to read enriched seed %v: %v", seed.Name(), err)
 			}
-
+			if !mgr.admitCandidateLocked(data, false) {
+				mgr.nearDupSeeds[seed.Name()] = mgr.fingerprintLocked(data)
+				continue
+			}
+			delete(mgr.nearDupSeeds, seed.Name())
 			loadedSeedsMu.Lock()
 			loadedSeeds[seed.Name()] = struct{}{}
 			loadedSeedsMu.Unlock()
 
-			if mgr.loadProg(data, true, false) {
+			if mgr.loadProg(data, true, false, SourceEnrich) {
 				enrichCnt += 1
 			}
 		}
 		log.Logf(0, "%-24v: %v/%v", "enriched seeds", enrichCnt, len(seeds))
-
-		// shuffle or not?
-		if canShuffle {
-			log.Logf(0, "[+] Can shuffle")
-			mgr.candidates = append(mgr.candidates, mgr.candidates...)
-			shuffle := mgr.candidates[len(mgr.candidates)/2:]
-			rand.Shuffle(len(shuffle), func(i, j int) {
-				shuffle[i], shuffle[j] = shuffle[j], shuffle[i]
-			})
-		}
 	}
 }
 
@@ -855,7 +984,7 @@ func (mgr *Manager) loadCorpus() {
 	}
 	broken := 0
 	for key, rec := range mgr.corpusDB.Records {
-		if !mgr.loadProg(rec.Val, minimized, smashed) {
+		if !mgr.loadProg(rec.Val, minimized, smashed, SourceCorpus) {
 			mgr.corpusDB.Delete(key)
 			broken++
 		} else if *flagStatCall {
@@ -863,34 +992,29 @@ func (mgr *Manager) loadCorpus() {
 		}
 	}
 	mgr.fresh = len(mgr.corpusDB.Records) == 0
-	corpusSize := len(mgr.candidates)
+	corpusSize := mgr.candidates.len(SourceCorpus)
 	log.Logf(0, "%-24v: %v (deleted %v broken)", "corpus", corpusSize, broken)
 
 	for _, seed := range mgr.seeds {
-		if mgr.loadProg(seed, true, false) && *flagStatCall {
+		if !mgr.admitCandidateLocked(seed, false) {
+			continue
+		}
+		if mgr.loadProg(seed, true, false, SourceCorpus) && *flagStatCall {
 			mgr.statCallFromByte(seed)
 		}
 	}
-	log.Logf(0, "%-24v: %v/%v", "seeds", len(mgr.candidates)-corpusSize, len(mgr.seeds))
+	log.Logf(0, "%-24v: %v/%v", "seeds", mgr.candidates.len(SourceCorpus)-corpusSize, len(mgr.seeds))
 	mgr.seeds = nil
 
-	// We duplicate all inputs in the corpus and shuffle the second part.
-	// This solves the following problem. A fuzzer can crash while triaging candidates,
-	// in such case it will also lost all cached candidates. Or, the input can be somewhat flaky
-	// and doesn't give the coverage on first try. So we give each input the second chance.
-	// Shuffling should alleviate deterministically losing the same inputs on fuzzer crashing.
-	mgr.candidates = append(mgr.candidates, mgr.candidates...)
-	shuffle := mgr.candidates[len(mgr.candidates)/2:]
-	rand.Shuffle(len(shuffle), func(i, j int) {
-		shuffle[i], shuffle[j] = shuffle[j], shuffle[i]
-	})
+	// We give each input from the corpus the second chance (see candidateQueue.secondChance).
+	mgr.candidates.enableSecondChance(SourceCorpus)
 	if mgr.phase != phaseInit {
 		panic(fmt.Sprintf("loadCorpus: bad phase %v", mgr.phase))
 	}
 	mgr.phase = phaseLoadedCorpus
 }
 
-func (mgr *Manager) loadProg(data []byte, minimized, smashed bool) bool {
+func (mgr *Manager) loadProg(data []byte, minimized, smashed bool, src CandidateSource) bool {
 	bad, disabled := checkProgram(mgr.target, mgr.targetEnabledSyscalls, data)
 	if bad {
 		return false
@@ -907,7 +1031,7 @@ func (mgr *Manager) loadProg(data []byte, minimized, smashed bool) bool {
 			// deleted from the corpus.
 			leftover := programLeftover(mgr.target, mgr.targetEnabledSyscalls, data)
 			if len(leftover) > 0 {
-				mgr.candidates = append(mgr.candidates, rpctype.Candidate{
+				mgr.pushCandidateLocked(src, rpctype.Candidate{
 					Prog:      leftover,
 					Minimized: false,
 					Smashed:   smashed,
@@ -916,7 +1040,7 @@ func (mgr *Manager) loadProg(data []byte, minimized, smashed bool) bool {
 		}
 		return true
 	}
-	mgr.candidates = append(mgr.candidates, rpctype.Candidate{
+	mgr.pushCandidateLocked(src, rpctype.Candidate{
 		Prog:      data,
 		Minimized: minimized,
 		Smashed:   smashed,
@@ -1044,7 +1168,23 @@ func (mgr *Manager) runInstanceInner(index int, instanceName string) (*report.Re
 		},
 	}
 	cmd := instance.FuzzerCmd(args)
-	outc, errc, err := inst.Run(mgr.cfg.Timeouts.VMRunningTime, mgr.vmStop, cmd)
+	// The instance is stopped on request of the manager loop or when the experiment phase
+	// changes the set of enabled syscalls.
+	mgr.mu.Lock()
+	phaseStop := mgr.expPhaseStop
+	mgr.mu.Unlock()
+	stop, done := make(chan bool), make(chan bool)
+	defer close(done)
+	go func() {
+		select {
+		case <-mgr.vmStop:
+		case <-phaseStop:
+		case <-done:
+			return
+		}
+		close(stop)
+	}()
+	outc, errc, err := inst.Run(mgr.cfg.Timeouts.VMRunningTime, stop, cmd)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to run fuzzer: %w", err)
 	}
@@ -1150,6 +1290,9 @@ func (mgr *Manager) saveCrash(crash *Crash) bool {
 	if err := osutil.WriteFile(filepath.Join(dir, "description"), []byte(crash.Title+"\n")); err != nil {
 		log.Logf(0, "failed to write crash: %v", err)
 	}
+	if firstSeen := filepath.Join(dir, summary.FirstSeenFile); !osutil.IsExist(firstSeen) {
+		osutil.WriteFile(firstSeen, []byte(time.Now().Format(time.RFC3339)+"\n"))
+	}
 
 	// Save up to mgr.cfg.MaxCrashLogs reports, overwrite the oldest once we've reached that number.
 	// Newer reports are generally more useful. Overwriting is also needed
@@ -1182,13 +1325,29 @@ func (mgr *Manager) saveCrash(crash *Crash) bool {
 	writeOrRemove("tag", []byte(mgr.cfg.Tag))
 	writeOrRemove("report", crash.Report.Report)
 	writeOrRemove("machineInfo", crash.machineInfo)
+	writeOrRemove("recent-progs", crash.recentProgs)
 	return mgr.needLocalRepro(crash)
 }
 
+// attachRecentProgs extracts programs that fuzzer procs dumped on executor failures from the crash log.
+// If the log does not contain executed programs otherwise (fuzzers run with -output=none),
+// the extracted programs are appended to the log, so that they are used by repro.
+func (mgr *Manager) attachRecentProgs(crash *Crash) {
+	crash.recentProgs = progring.Extract(crash.Output)
+	if len(crash.recentProgs) == 0 {
+		return
+	}
+	log.Logf(0, "vm-%v: extracted %v recent programs", crash.vmIndex,
+		len(mgr.target.ParseLog(crash.recentProgs)))
+	if len(mgr.target.ParseLog(crash.Output)) == 0 {
+		crash.Output = append(append([]byte{}, crash.Output...), crash.recentProgs...)
+	}
+}
+
 const maxReproAttempts = 3
 
 func (mgr *Manager) needLocalRepro(crash *Crash) bool {
-	if !mgr.cfg.Reproduce || crash.Corrupted || crash.Suppressed {
+	if !mgr.reproduceEnabled() || crash.Corrupted || crash.Suppressed {
 		return false
 	}
 	sig := hash.Hash([]byte(crash.Title))
@@ -1402,9 +1561,9 @@ func fullReproLog(stats *repro.Stats) []byte {
 	if stats == nil {
 		return nil
 	}
-	return []byte(fmt.Sprintf("Extracting prog: %v\nMinimizing prog: %v\n"+
+	return []byte(fmt.Sprintf("Extracting prog: %v\nMinimizing prog: %v (%v runs)\n"+
 		"Simplifying prog options: %v\nExtracting C: %v\nSimplifying C: %v\n\n\n%s",
-		stats.ExtractProgTime, stats.MinimizeProgTime,
+		stats.ExtractProgTime, stats.MinimizeProgTime, stats.MinimizeProgRuns,
 		stats.SimplifyProgTime, stats.ExtractCTime, stats.SimplifyCTime, stats.Log))
 }
 
@@ -1424,7 +1583,11 @@ func (mgr *Manager) getMinimizedCorpus() (corpus, repros [][]byte) {
 func (mgr *Manager) addNewCandidates(candidates []rpctype.Candidate) {
 	mgr.mu.Lock()
 	defer mgr.mu.Unlock()
-	mgr.candidates = append(mgr.candidates, candidates...)
+	for _, cand := range candidates {
+		if mgr.admitCandidateLocked(cand.Prog, false) {
+			mgr.pushCandidateLocked(SourceHub, cand)
+		}
+	}
 	if mgr.phase == phaseTriagedCorpus {
 		mgr.phase = phaseQueriedHub
 	}
@@ -1449,8 +1612,16 @@ func (mgr *Manager) minimizeCorpus() {
 		newCorpus[hash.String(inp.Prog)] = inp
 	}
 	log.Logf(1, "minimized corpus: %v -> %v", len(mgr.corpus), len(newCorpus))
+	for sig := range mgr.corpus {
+		if _, ok := newCorpus[sig]; !ok {
+			mgr.corpusRemovedLocked(sig)
+		}
+	}
 	mgr.corpus = newCorpus
 	mgr.lastMinCorpus = len(newCorpus)
+	if mgr.shards != nil {
+		mgr.shards.stale = true
+	}
 
 	// From time to time we get corpus explosion due to different reason:
 	// generic bugs, per-OS bugs, problems with fallback coverage, kcov bugs, etc.
@@ -1541,10 +1712,6 @@ func (mgr *Manager) fuzzerConnect(modules []host.KernelModule) (
 	defer mgr.mu.Unlock()
 
 	mgr.minimizeCorpus()
-	corpus := make([]rpctype.Input, 0, len(mgr.corpus))
-	for _, inp := range mgr.corpus {
-		corpus = append(corpus, inp.RPCInput())
-	}
 	frames := BugFrames{
 		memoryLeaks: make([]string, 0, len(mgr.memoryLeakFrames)),
 		dataRaces:   make([]string, 0, len(mgr.dataRaceFrames)),
@@ -1562,16 +1729,40 @@ func (mgr *Manager) fuzzerConnect(modules []host.KernelModule) (
 		if err != nil {
 			log.Fatalf("failed to create coverage filter: %v", err)
 		}
+		mgr.directed, err = mgr.createDirected()
+		if err != nil {
+			log.Fatalf("failed to set up directed fuzzing: %v", err)
+		}
 		mgr.modulesInitialized = true
 	}
+	// Directed distances of the inputs are known only after directed fuzzing is set up.
+	corpus := mgr.corpusInputsLocked()
 	return corpus, frames, mgr.coverFilter, mgr.execCoverFilter, nil
 }
 
+func (mgr *Manager) corpusInputsLocked() []rpctype.Input {
+	corpus := make([]rpctype.Input, 0, len(mgr.corpus))
+	for _, item := range mgr.corpus {
+		corpus = append(corpus, mgr.corpusInputLocked(&item))
+	}
+	return corpus
+}
+
+func (mgr *Manager) corpusInputLocked(item *CorpusItem) rpctype.Input {
+	inp := item.RPCInput()
+	inp.DirectedDistance = mgr.directedDistanceLocked(item.Cover)
+	return inp
+}
+
 func (mgr *Manager) machineChecked(a *rpctype.CheckArgs, enabledSyscalls map[*prog.Syscall]bool) {
 	mgr.mu.Lock()
 	defer mgr.mu.Unlock()
 	mgr.checkResult = a
 	mgr.targetEnabledSyscalls = enabledSyscalls
+	mgr.disabledReasons = make(map[int]string)
+	for _, dc := range a.DisabledCalls[mgr.cfg.Sandbox] {
+		mgr.disabledReasons[dc.ID] = dc.Reason
+	}
 	mgr.target.UpdateGlobs(a.GlobFiles)
 	mgr.loadCorpus()
 	mgr.firstConnect = time.Now()
@@ -1668,11 +1859,13 @@ func storeCallName(name, filename string) {
 	}
 }
 
-func (mgr *Manager) newInput(inp rpctype.Input, sign signal.Signal) bool {
+// newInput adds the input to the corpus and returns false if it was not accepted.
+// With corpus sharding it also returns the shards whose corpus subsets took the input.
+func (mgr *Manager) newInput(inp rpctype.Input, sign signal.Signal) (bool, map[int]bool) {
 	mgr.mu.Lock()
 	defer mgr.mu.Unlock()
 	if mgr.saturatedCalls[inp.Call] {
-		return false
+		return false, nil
 	}
 	update := CorpusItemUpdate{
 		CallID:   inp.CallID,
@@ -1681,8 +1874,12 @@ func (mgr *Manager) newInput(inp rpctype.Input, sign signal.Signal) bool {
 	sig := hash.String(inp.Prog)
 	if old, ok := mgr.corpus[sig]; ok {
 		// The input is already present, but possibly with diffent signal/coverage/call.
+		oldSize := inputSize(&old)
 		sign.Merge(old.Signal.Deserialize())
 		old.Signal = sign.Serialize()
+		if mgr.shards != nil {
+			mgr.shards.add(sig, &old, sign, oldSize)
+		}
 		var cov cover.Cover
 		cov.Merge(old.Cover)
 		cov.Merge(inp.Cover)
@@ -1700,12 +1897,34 @@ func (mgr *Manager) newInput(inp rpctype.Input, sign signal.Signal) bool {
 			Signal:  inp.Signal,
 			Cover:   inp.Cover,
 			Updates: []CorpusItemUpdate{update},
+			Origin:  inp.Origin,
+		}
+		if mgr.shards != nil {
+			item := mgr.corpus[sig]
+			mgr.shards.add(sig, &item, sign, 0)
+		}
+		mgr.corpusAddedLocked(sig, inp.Prog)
+		if inp.Origin != "" {
+			mgr.inputOrigins[inp.Origin]++
+		}
+		mgr.recordCoveredSyscallsLocked(inp.CoverCalls)
+		if len(inp.Trace) != 0 {
+			traceFile := filepath.Join(mgr.cfg.Workdir, "traces", sig+".json")
+			osutil.MkdirAll(filepath.Dir(traceFile))
+			if err := osutil.WriteFile(traceFile, inp.Trace); err != nil {
+				log.Logf(0, "failed to write trace: %v", err)
+			}
 		}
 		mgr.corpusDB.Save(sig, inp.Prog, 0)
 		if err := mgr.corpusDB.Flush(); err != nil {
 			log.Errorf("failed to save corpus database: %v", err)
 		}
 	}
+	mgr.directedInputLocked(sig, mgr.corpus[sig].Cover)
+	var shards map[int]bool
+	if mgr.shards != nil {
+		shards = mgr.shards.takenBy(sig, mgr.corpus)
+	}
 
 	if *flagDump != "" {
 		dumpCoverDir := filepath.Join(*flagDump, "coverages")
@@ -1733,31 +1952,47 @@ func (mgr *Manager) newInput(inp rpctype.Input, sign signal.Signal) bool {
 		mgr.statCallFromMap(inp.CoverCalls)
 	}
 
-	return true
+	return true, shards
 }
 
-func (mgr *Manager) candidateBatch(size int) []rpctype.Candidate {
+// candidateBatch returns up to size candidates for the fuzzer,
+// enabled restricts the syscalls candidates can use (nil if all syscalls are enabled).
+func (mgr *Manager) candidateBatch(name string, size int, enabled map[string]bool) []rpctype.Candidate {
 	mgr.mu.Lock()
 	defer mgr.mu.Unlock()
 	var res []rpctype.Candidate
-	for i := 0; i < size && len(mgr.candidates) > 0; i++ {
-		last := len(mgr.candidates) - 1
-		res = append(res, mgr.candidates[last])
-		mgr.candidates[last] = rpctype.Candidate{}
-		mgr.candidates = mgr.candidates[:last]
-	}
-	if len(mgr.candidates) == 0 {
-		mgr.candidates = nil
-		if mgr.phase == phaseLoadedCorpus {
-			if mgr.cfg.HubClient != "" {
-				mgr.phase = phaseTriagedCorpus
-				go mgr.hubSyncLoop(pickGetter(mgr.cfg.HubKey))
-			} else {
+	now := time.Now()
+	for len(res) < size {
+		cand, src, ok := mgr.candidates.next(now)
+		if !ok {
+			break
+		}
+		if enabled != nil && !callsEnabled(enabled, cand.Prog) {
+			mgr.expPhaseHeld = append(mgr.expPhaseHeld, heldCandidate{src, cand})
+			continue
+		}
+		if src != SourceCorpus && !mgr.trackSeedLocked(name, src, cand, now) {
+			mgr.releaseCandidateLocked(hash.String(cand.Prog))
+			continue
+		}
+		mgr.candidateHandedOutLocked(name, cand)
+		cand.Source = src.String()
+		cand.Seed = src == SourceEnrich
+		res = append(res, cand)
+	}
+	if mgr.phase == phaseLoadedCorpus && mgr.candidates.len(SourceCorpus) == 0 {
+		if mgr.cfg.HubClient != "" {
+			mgr.phase = phaseTriagedCorpus
+			if mgr.hubSyncPausedLocked() {
+				// The current experiment phase disables hub sync, don't wait for it.
 				mgr.phase = phaseTriagedHub
 			}
-		} else if mgr.phase == phaseQueriedHub {
+			go mgr.hubSyncLoop(pickGetter(mgr.cfg.HubKey))
+		} else {
 			mgr.phase = phaseTriagedHub
 		}
+	} else if mgr.phase == phaseQueriedHub && mgr.candidates.len(SourceHub) == 0 {
+		mgr.phase = phaseTriagedHub
 	}
 	return res
 }
@@ -0,0 +1,230 @@
+// Copyright 2026 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"time"
+
+	"github.com/google/syzkaller/pkg/log"
+	"github.com/google/syzkaller/pkg/mgrconfig"
+	"github.com/google/syzkaller/pkg/osutil"
+	"github.com/google/syzkaller/pkg/rpctype"
+	"github.com/google/syzkaller/prog"
+)
+
+// Experiment phases are scheduled configuration changes during a campaign (see mgrconfig.Config.Phases).
+// The manager walks the schedule once, starting when the first fuzzer connects.
+
+// PhaseResult describes a finished experiment phase.
+type PhaseResult struct {
+	Name  string    `json:"name"`
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+	// Growth of the main stats over the phase (see phaseStatsLocked for the list).
+	Deltas map[string]uint64 `json:"deltas"`
+}
+
+func (mgr *Manager) phaseLoop() {
+	for {
+		time.Sleep(10 * time.Second)
+		mgr.mu.Lock()
+		started := !mgr.firstConnect.IsZero()
+		mgr.mu.Unlock()
+		if started {
+			break
+		}
+	}
+	for i, phase := range mgr.cfg.Phases {
+		mgr.startPhase(i)
+		time.Sleep(phase.Length)
+		mgr.finishPhase()
+	}
+	mgr.mu.Lock()
+	mgr.switchPhaseLocked(len(mgr.cfg.Phases))
+	mgr.mu.Unlock()
+	log.Logf(0, "experiment phase schedule is finished, returning to the base config")
+	mgr.writeBench()
+}
+
+func (mgr *Manager) startPhase(idx int) {
+	mgr.mu.Lock()
+	mgr.switchPhaseLocked(idx)
+	mgr.expPhaseStart = time.Now()
+	mgr.expPhaseBase = mgr.phaseStatsLocked()
+	if mgr.phase == phaseTriagedCorpus && mgr.hubSyncPausedLocked() {
+		// Don't wait for the first hub sync that won't happen during this phase.
+		mgr.phase = phaseTriagedHub
+	}
+	mgr.mu.Unlock()
+	phase := &mgr.cfg.Phases[idx]
+	log.Logf(0, "starting experiment phase %v (%v/%v) for %v",
+		phase.Name, idx+1, len(mgr.cfg.Phases), phase.Length)
+	mgr.writeBench()
+}
+
+// heldCandidate is a candidate that could not be handed out during the current phase
+// because it uses syscalls the phase disables.
+type heldCandidate struct {
+	src  CandidateSource
+	cand rpctype.Candidate
+}
+
+// switchPhaseLocked makes the phase idx current. If either the previous or the new phase
+// restricts syscalls, all VMs are restarted, so that they reconnect with the new set of syscalls
+// (see RPCServer.Connect), and candidates held because of the previous restriction are queued again.
+func (mgr *Manager) switchPhaseLocked(idx int) {
+	prev := mgr.curPhaseLocked()
+	mgr.expPhase = idx
+	cur := mgr.curPhaseLocked()
+	if (prev == nil || len(prev.Syscalls) == 0) && (cur == nil || len(cur.Syscalls) == 0) {
+		return
+	}
+	log.Logf(0, "experiment phase changes enabled syscalls, restarting VMs")
+	close(mgr.expPhaseStop)
+	mgr.expPhaseStop = make(chan bool)
+	for _, held := range mgr.expPhaseHeld {
+		mgr.pushCandidateLocked(held.src, held.cand)
+	}
+	mgr.expPhaseHeld = nil
+}
+
+func (mgr *Manager) finishPhase() {
+	mgr.mu.Lock()
+	res := PhaseResult{
+		Name:   mgr.cfg.Phases[mgr.expPhase].Name,
+		Start:  mgr.expPhaseStart,
+		End:    time.Now(),
+		Deltas: mgr.phaseDeltasLocked(),
+	}
+	mgr.expPhaseResults = append(mgr.expPhaseResults, res)
+	data, err := json.MarshalIndent(mgr.expPhaseResults, "", "\t")
+	mgr.mu.Unlock()
+	if err != nil {
+		log.Fatalf("failed to serialize phase results: %v", err)
+	}
+	log.Logf(0, "finished experiment phase %v: %v", res.Name, res.Deltas)
+	if err := osutil.WriteFile(filepath.Join(mgr.cfg.Workdir, "phases.json"), data); err != nil {
+		log.Logf(0, "failed to write phase results: %v", err)
+	}
+}
+
+// phaseStatsLocked returns the stats used to compute per-phase deltas.
+func (mgr *Manager) phaseStatsLocked() map[string]uint64 {
+	return map[string]uint64{
+		"coverage":    mgr.stats.corpusCover.get(),
+		"signal":      mgr.stats.corpusSignal.get(),
+		"max signal":  mgr.stats.maxSignal.get(),
+		"crashes":     mgr.stats.crashes.get(),
+		"crash types": mgr.stats.crashTypes.get(),
+		"exec total":  mgr.stats.execTotal.get(),
+		"new inputs":  mgr.stats.newInputs.get(),
+		"corpus":      uint64(len(mgr.corpus)),
+		"syscalls":    uint64(len(gCoverCalls)),
+		"EnrichCnt":   uint64(enrichCnt),
+	}
+}
+
+func (mgr *Manager) phaseDeltasLocked() map[string]uint64 {
+	deltas := mgr.phaseStatsLocked()
+	for k, v := range deltas {
+		// Corpus can shrink due to minimization, don't report negative growth.
+		if base := mgr.expPhaseBase[k]; v > base {
+			deltas[k] = v - base
+		} else {
+			deltas[k] = 0
+		}
+	}
+	return deltas
+}
+
+// addPhaseBenchLocked marks the current experiment phase in bench output.
+// Phase 0 means that the schedule has not started yet, len(phases)+1 means that it has finished.
+func (mgr *Manager) addPhaseBenchLocked(vals map[string]uint64) {
+	if len(mgr.cfg.Phases) == 0 {
+		return
+	}
+	vals["phase"] = uint64(mgr.expPhase + 1)
+	if mgr.curPhaseLocked() == nil {
+		return
+	}
+	for k, v := range mgr.phaseDeltasLocked() {
+		vals["phase "+k] = v
+	}
+}
+
+func (mgr *Manager) curPhaseLocked() *mgrconfig.Phase {
+	if mgr.expPhase < 0 || mgr.expPhase >= len(mgr.cfg.Phases) {
+		return nil
+	}
+	return &mgr.cfg.Phases[mgr.expPhase]
+}
+
+// enrichSettings returns the enrichment directory and period in effect
+// (empty dir or zero period mean that enrichment is disabled).
+func (mgr *Manager) enrichSettings() (string, time.Duration) {
+	mgr.mu.Lock()
+	defer mgr.mu.Unlock()
+	dir, period := *flagEnrich, mgr.enrichPeriod
+	if phase := mgr.curPhaseLocked(); phase != nil {
+		if phase.Enrich == "none" {
+			dir = ""
+		} else if phase.Enrich != "" {
+			dir = phase.Enrich
+		}
+		if phase.EnrichDur != 0 {
+			period = phase.EnrichDur
+		}
+	}
+	return dir, period
+}
+
+func (mgr *Manager) reproduceEnabled() bool {
+	mgr.mu.Lock()
+	defer mgr.mu.Unlock()
+	return mgr.reproduceEnabledLocked()
+}
+
+func (mgr *Manager) reproduceEnabledLocked() bool {
+	if phase := mgr.curPhaseLocked(); phase != nil && phase.Reproduce != nil {
+		return *phase.Reproduce
+	}
+	return mgr.cfg.Reproduce
+}
+
+// hubSyncPaused says if the current experiment phase disables hub sync.
+func (mgr *Manager) hubSyncPaused() bool {
+	mgr.mu.Lock()
+	defer mgr.mu.Unlock()
+	return mgr.hubSyncPausedLocked()
+}
+
+func (mgr *Manager) hubSyncPausedLocked() bool {
+	phase := mgr.curPhaseLocked()
+	return phase != nil && phase.HubSync != nil && !*phase.HubSync
+}
+
+// phaseSyscalls returns the set of syscalls VMs are restricted to
+// by the current experiment phase, or nil if there is no restriction.
+func (mgr *Manager) phaseSyscalls() map[*prog.Syscall]bool {
+	mgr.mu.Lock()
+	defer mgr.mu.Unlock()
+	phase := mgr.curPhaseLocked()
+	if phase == nil || len(phase.Syscalls) == 0 || mgr.targetEnabledSyscalls == nil {
+		return nil
+	}
+	calls := make(map[*prog.Syscall]bool)
+	for _, id := range phase.Syscalls {
+		if call := mgr.target.Syscalls[id]; mgr.targetEnabledSyscalls[call] {
+			calls[call] = true
+		}
+	}
+	calls, _ = mgr.target.TransitivelyEnabledCalls(calls)
+	if len(calls) == 0 {
+		log.Logf(0, "experiment phase %v: no enabled syscalls left, not restricting", phase.Name)
+		return nil
+	}
+	return calls
+}
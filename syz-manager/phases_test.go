@@ -0,0 +1,67 @@
+// Copyright 2026 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/google/syzkaller/pkg/mgrconfig"
+	"github.com/google/syzkaller/pkg/rpctype"
+	"github.com/google/syzkaller/prog"
+	"github.com/google/syzkaller/sys/targets"
+)
+
+func TestPhaseSyscalls(t *testing.T) {
+	target, err := prog.GetTarget(targets.TestOS, targets.TestArch64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mgr := &Manager{
+		cfg: &mgrconfig.Config{Phases: []mgrconfig.Phase{
+			{Syscalls: []int{target.SyscallMap["test$int"].ID}},
+			{},
+		}},
+		target:       target,
+		stats:        new(Stats),
+		candidates:   newCandidateQueues(nil, rand.New(rand.NewSource(0))),
+		expPhase:     -1,
+		expPhaseStop: make(chan bool),
+	}
+	intProg := []byte("test$int(0x0, 0x0, 0x0, 0x0, 0x0)\n")
+	blobProg := []byte("test$blob0(&(0x7f0000000000))\n")
+	mgr.pushCandidateLocked(SourceCorpus, rpctype.Candidate{Prog: intProg})
+	mgr.pushCandidateLocked(SourceCorpus, rpctype.Candidate{Prog: blobProg})
+
+	stop := mgr.expPhaseStop
+	mgr.switchPhaseLocked(0)
+	select {
+	case <-stop:
+	default:
+		t.Fatalf("VMs are not restarted when a restricting phase starts")
+	}
+	// Fuzzers of the phase get only candidates with the enabled syscalls.
+	enabled := map[string]bool{"test$int": true}
+	if cands := mgr.candidateBatch("vm-0", 10, enabled); len(cands) != 1 || string(cands[0].Prog) != string(intProg) {
+		t.Fatalf("got candidates %+v, want only the test$int program", cands)
+	}
+	stop = mgr.expPhaseStop
+	mgr.switchPhaseLocked(1)
+	select {
+	case <-stop:
+	default:
+		t.Fatalf("VMs are not restarted when a restricting phase ends")
+	}
+	// Candidates held during the phase are handed out after it.
+	if cands := mgr.candidateBatch("vm-1", 10, nil); len(cands) != 1 || string(cands[0].Prog) != string(blobProg) {
+		t.Fatalf("got candidates %+v, want the held test$blob0 program", cands)
+	}
+	stop = mgr.expPhaseStop
+	mgr.switchPhaseLocked(2)
+	select {
+	case <-stop:
+		t.Fatalf("VMs are restarted between phases that don't restrict syscalls")
+	default:
+	}
+}
@@ -0,0 +1,289 @@
+// Copyright 2026 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/google/syzkaller/pkg/hash"
+	"github.com/google/syzkaller/pkg/html/pages"
+	"github.com/google/syzkaller/pkg/log"
+	"github.com/google/syzkaller/pkg/osutil"
+	crash_pkg "github.com/google/syzkaller/pkg/report/crash"
+	"github.com/google/syzkaller/pkg/rpctype"
+	"github.com/google/syzkaller/prog"
+)
+
+// Seed quarantine.
+// External candidates (hub inputs and enriched seeds) that crash or hang a VM within their
+// first few executions are moved to workdir/quarantine together with the report title.
+// Quarantined seeds are not handed out to fuzzers anymore, unless re-admitted via /quarantine.
+// Each quarantined seed is stored in quarantine/<sig>/ as prog, description and source files.
+
+const (
+	// A seed is only suspected if it was executed at most that many times in the crash log.
+	quarantineMaxExecs = 3
+	// Seeds handed out to a fuzzer that did not crash for that long are not tracked anymore.
+	seedTrackTime = time.Hour
+)
+
+// These are reported by vm.MonitorExecution, the last program is not necessarily the culprit,
+// so we suspect the last program of each proc.
+var hangTitles = map[string]bool{
+	"no output from test machine":     true,
+	"lost connection to test machine": true,
+}
+
+type QuarantinedSeed struct {
+	Sig    string
+	Title  string
+	Source CandidateSource
+	Time   time.Time
+	Prog   []byte
+}
+
+type seedInFlight struct {
+	src     CandidateSource
+	cand    rpctype.Candidate
+	handout time.Time
+}
+
+func (mgr *Manager) quarantineDir() string {
+	return filepath.Join(mgr.cfg.Workdir, "quarantine")
+}
+
+func (mgr *Manager) loadQuarantine() {
+	dirs, err := os.ReadDir(mgr.quarantineDir())
+	if err != nil && !os.IsNotExist(err) {
+		log.Fatalf("failed to read quarantine dir: %v", err)
+	}
+	for _, dir := range dirs {
+		sig := dir.Name()
+		seedDir := filepath.Join(mgr.quarantineDir(), sig)
+		data, err := os.ReadFile(filepath.Join(seedDir, "prog"))
+		if err != nil {
+			log.Logf(0, "failed to read quarantined seed %v: %v", sig, err)
+			continue
+		}
+		seed := &QuarantinedSeed{
+			Sig:   sig,
+			Title: string(trimNewLines(readFileOrEmpty(filepath.Join(seedDir, "description")))),
+			Prog:  data,
+		}
+		src := string(trimNewLines(readFileOrEmpty(filepath.Join(seedDir, "source"))))
+		for i, name := range sourceNames {
+			if name == src {
+				seed.Source = CandidateSource(i)
+			}
+		}
+		if info, err := os.Stat(filepath.Join(seedDir, "prog")); err == nil {
+			seed.Time = info.ModTime()
+		}
+		mgr.quarantine[sig] = seed
+	}
+	if len(mgr.quarantine) != 0 {
+		log.Logf(0, "%-24v: %v", "quarantined seeds", len(mgr.quarantine))
+	}
+}
+
+func readFileOrEmpty(file string) []byte {
+	data, _ := os.ReadFile(file)
+	return data
+}
+
+// seedSig returns the signature used to match candidates with programs in crash logs.
+// Fuzzers print programs re-serialized, so we do the same here.
+func (mgr *Manager) seedSig(data []byte) string {
+	if p, err := mgr.target.Deserialize(data, prog.NonStrict); err == nil {
+		data = p.Serialize()
+	}
+	return hash.String(data)
+}
+
+// trackSeedLocked remembers that the external candidate was handed out to the fuzzer.
+// Returns false if the candidate is quarantined and must not be handed out.
+func (mgr *Manager) trackSeedLocked(name string, src CandidateSource, cand rpctype.Candidate, now time.Time) bool {
+	sig := mgr.seedSig(cand.Prog)
+	if mgr.quarantine[sig] != nil {
+		return false
+	}
+	seeds := mgr.seedsInFlight[name]
+	if seeds == nil {
+		seeds = make(map[string]*seedInFlight)
+		mgr.seedsInFlight[name] = seeds
+	}
+	// VMs are normally restarted more frequently than that, but manually started
+	// fuzzers (type "none") are never restarted.
+	for sig, seed := range seeds {
+		if now.Sub(seed.handout) > seedTrackTime {
+			delete(seeds, sig)
+		}
+	}
+	seeds[sig] = &seedInFlight{src: src, cand: cand, handout: now}
+	return true
+}
+
+// forgetSeeds drops seeds handed out to the fuzzer, should be called once the instance has finished.
+func (mgr *Manager) forgetSeeds(name string) {
+	mgr.mu.Lock()
+	defer mgr.mu.Unlock()
+	delete(mgr.seedsInFlight, name)
+}
+
+// quarantineSeeds quarantines seeds that are guilty of the crash of the fuzzer instance.
+func (mgr *Manager) quarantineSeeds(name string, crash *Crash) {
+	mgr.mu.Lock()
+	tracked := len(mgr.seedsInFlight[name]) != 0
+	mgr.mu.Unlock()
+	if !tracked {
+		return
+	}
+	execs := make(map[string]int)
+	lastPerProc := make(map[int]string)
+	last := ""
+	for _, ent := range mgr.target.ParseLog(crash.Output) {
+		sig := hash.String(ent.P.Serialize())
+		execs[sig]++
+		lastPerProc[ent.Proc] = sig
+		last = sig
+	}
+	suspects := map[string]bool{last: true}
+	if hangTitles[crash.Title] || crash.Type == crash_pkg.Hang {
+		for _, sig := range lastPerProc {
+			suspects[sig] = true
+		}
+	}
+	guilty := make(map[string]*seedInFlight)
+	mgr.mu.Lock()
+	for sig := range suspects {
+		if seed := mgr.seedsInFlight[name][sig]; seed != nil && execs[sig] <= quarantineMaxExecs {
+			guilty[sig] = seed
+		}
+	}
+	mgr.mu.Unlock()
+	for sig, seed := range guilty {
+		mgr.quarantineSeed(sig, seed, crash.Title)
+	}
+}
+
+func (mgr *Manager) quarantineSeed(sig string, seed *seedInFlight, title string) {
+	log.Logf(0, "quarantining %v seed %v: %v", seed.src, sig, title)
+	qs := &QuarantinedSeed{
+		Sig:    sig,
+		Title:  title,
+		Source: seed.src,
+		Time:   time.Now(),
+		Prog:   seed.cand.Prog,
+	}
+	mgr.mu.Lock()
+	mgr.quarantine[sig] = qs
+	mgr.mu.Unlock()
+	mgr.stats.seedsQuarantined.inc()
+	dir := filepath.Join(mgr.quarantineDir(), sig)
+	osutil.MkdirAll(dir)
+	for name, data := range map[string][]byte{
+		"prog":        qs.Prog,
+		"description": []byte(qs.Title + "\n"),
+		"source":      []byte(qs.Source.String() + "\n"),
+	} {
+		if err := osutil.WriteFile(filepath.Join(dir, name), data); err != nil {
+			log.Logf(0, "failed to write quarantined seed: %v", err)
+		}
+	}
+}
+
+// readmitSeed removes the seed from quarantine and queues it for triage again.
+// If it crashes the VM again, it will be quarantined again.
+func (mgr *Manager) readmitSeed(sig string) error {
+	mgr.mu.Lock()
+	defer mgr.mu.Unlock()
+	seed := mgr.quarantine[sig]
+	if seed == nil {
+		return fmt.Errorf("no quarantined seed %v", sig)
+	}
+	if err := os.RemoveAll(filepath.Join(mgr.quarantineDir(), sig)); err != nil {
+		return fmt.Errorf("failed to remove quarantined seed: %w", err)
+	}
+	delete(mgr.quarantine, sig)
+	mgr.pushCandidateLocked(seed.Source, rpctype.Candidate{Prog: seed.Prog})
+	log.Logf(0, "re-admitted %v seed %v", seed.Source, sig)
+	return nil
+}
+
+func (mgr *Manager) httpQuarantine(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodPost {
+		if err := mgr.readmitSeed(r.FormValue("readmit")); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Redirect(w, r, "/quarantine", http.StatusSeeOther)
+		return
+	}
+	mgr.mu.Lock()
+	data := &UIQuarantineData{}
+	for _, seed := range mgr.quarantine {
+		data.Seeds = append(data.Seeds, UIQuarantinedSeed{
+			Sig:    seed.Sig,
+			Title:  seed.Title,
+			Source: seed.Source.String(),
+			Time:   seed.Time,
+		})
+	}
+	mgr.mu.Unlock()
+	sort.Slice(data.Seeds, func(i, j int) bool {
+		return data.Seeds[i].Time.After(data.Seeds[j].Time)
+	})
+	executeTemplate(w, quarantineTemplate, data)
+}
+
+type UIQuarantineData struct {
+	Seeds []UIQuarantinedSeed
+}
+
+type UIQuarantinedSeed struct {
+	Sig    string
+	Title  string
+	Source string
+	Time   time.Time
+}
+
+var quarantineTemplate = pages.Create(`
+<!doctype html>
+<html>
+<head>
+	<title>syzkaller quarantine</title>
+	{{HEAD}}
+</head>
+<body>
+<table class="list_table">
+	<caption>Quarantined seeds:</caption>
+	<tr>
+		<th><a onclick="return sortTable(this, 'Time', textSort, true)" href="#">Time</a></th>
+		<th><a onclick="return sortTable(this, 'Source', textSort)" href="#">Source</a></th>
+		<th><a onclick="return sortTable(this, 'Title', textSort)" href="#">Title</a></th>
+		<th>Program</th>
+		<th></th>
+	</tr>
+	{{range $s := $.Seeds}}
+	<tr>
+		<td class="time">{{formatTime $s.Time}}</td>
+		<td>{{$s.Source}}</td>
+		<td class="title">{{$s.Title}}</td>
+		<td><a href="/file?name=quarantine/{{$s.Sig}}/prog">{{$s.Sig}}</a></td>
+		<td>
+			<form method="post" action="/quarantine">
+				<input type="hidden" name="readmit" value="{{$s.Sig}}">
+				<input type="submit" value="re-admit">
+			</form>
+		</td>
+	</tr>
+	{{end}}
+</table>
+</body></html>
+`)
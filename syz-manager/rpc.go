@@ -39,6 +39,9 @@ type RPCServer struct {
 	rotator       *prog.Rotator
 	rnd           *rand.Rand
 	checkFailures int
+	// Corpus shards (see shards.go), only the first shardCount shards are in use.
+	shards     []*corpusShard
+	shardCount int
 }
 
 type Fuzzer struct {
@@ -49,6 +52,13 @@ type Fuzzer struct {
 	rotatedSignal signal.Signal
 	machineInfo   []byte
 	instModules   *cover.CanonicalizerInstance
+	shard         int // index of the corpus shard, -1 if the fuzzer gets the full corpus
+	shardTime     time.Time
+	corpusSize    int    // the number of corpus inputs sent to the fuzzer on connect or shard switch
+	memory        uint64 // heap memory in use reported by the fuzzer
+	learnedPriors int    // version of the learned call priors sent to the fuzzer
+	// Names of syscalls enabled in the fuzzer by the experiment phase, nil if all syscalls are enabled.
+	enabled map[string]bool
 }
 
 type BugFrames struct {
@@ -61,9 +71,19 @@ type RPCManagerView interface {
 	fuzzerConnect([]host.KernelModule) (
 		[]rpctype.Input, BugFrames, map[uint32]uint32, map[uint32]uint32, error)
 	machineChecked(result *rpctype.CheckArgs, enabledSyscalls map[*prog.Syscall]bool)
-	newInput(inp rpctype.Input, sign signal.Signal) bool
-	candidateBatch(size int) []rpctype.Candidate
+	newInput(inp rpctype.Input, sign signal.Signal) (bool, map[int]bool)
+	candidateBatch(name string, size int, enabled map[string]bool) []rpctype.Candidate
 	rotateCorpus() bool
+	phaseSyscalls() map[*prog.Syscall]bool
+	mergeCallPairs(pairs []rpctype.CallPairStats)
+	mergeCallResults(results []rpctype.CallResultStats)
+	mergeCallFlakes(flakes []rpctype.CallFlakeStats)
+	candidatesTriaged(sigs []string)
+	callFlakiness() map[int]float64
+	learnedCallPriors() (map[string]map[string]float64, int)
+	currentFocus() *rpctype.Focus
+	corpusShard(choose func(count int) int) (int, []rpctype.Input, int, int)
+	directedDistances() map[uint32]uint32
 }
 
 func startRPCServer(mgr *Manager) (*RPCServer, error) {
@@ -109,6 +129,7 @@ func (serv *RPCServer) Connect(a *rpctype.ConnectArgs, r *rpctype.ConnectRes) er
 		name:        a.Name,
 		machineInfo: a.MachineInfo,
 		instModules: serv.canonicalModules.NewInstance(a.Modules),
+		shard:       -1,
 	}
 	serv.fuzzers[a.Name] = f
 	r.MemoryLeakFrames = bugFrames.memoryLeaks
@@ -118,17 +139,40 @@ func (serv *RPCServer) Connect(a *rpctype.ConnectArgs, r *rpctype.ConnectRes) er
 	r.CoverFilterBitmap = createCoverageBitmap(serv.cfg.SysTarget, instCoverFilter)
 	r.EnabledCalls = serv.cfg.Syscalls
 	r.NoMutateCalls = serv.cfg.NoMutateCalls
+	r.CallPriors = serv.cfg.CallPriorWeights
+	r.CallPriorsFactor = serv.cfg.CallPriorsFactor
+	r.LearnedCallPriors, f.learnedPriors = serv.mgr.learnedCallPriors()
+	r.LearnedCallPriorsFactor = serv.cfg.LearnedPriorsFactor
+	r.TraceMutations = serv.cfg.TraceMutations
+	r.PowerSchedule = serv.cfg.PowerSchedule
+	r.Templates = serv.cfg.TemplateData
+	r.CallFlakiness = serv.mgr.callFlakiness()
+	if dist := serv.mgr.directedDistances(); dist != nil {
+		r.Directed = &rpctype.Directed{
+			Distances:   f.instModules.DecanonicalizeFilter(dist),
+			Strength:    serv.cfg.Directed.Strength,
+			MaxDistance: serv.cfg.Directed.MaxDistance,
+		}
+	}
 	r.GitRevision = prog.GitRevision
 	r.TargetRevision = serv.cfg.Target.Revision
-	if serv.mgr.rotateCorpus() && serv.rnd.Intn(5) == 0 {
+	if calls := serv.mgr.phaseSyscalls(); calls != nil && serv.checkResult != nil {
+		// The current experiment phase restricts the set of syscalls.
+		r.CheckResult = serv.phaseCorpus(f, corpus, calls)
+	} else if serv.mgr.rotateCorpus() && serv.rnd.Intn(5) == 0 {
 		// We do rotation every other time because there are no objective
 		// proofs regarding its efficiency either way.
 		// Also, rotation gives significantly skewed syscall selection
 		// (run prog.TestRotationCoverage), it may or may not be OK.
 		r.CheckResult = serv.rotateCorpus(f, corpus)
+	} else if serv.cfg.CorpusShards != nil {
+		r.CheckResult = serv.checkResult
+		serv.assignShard(f)
+		f.newMaxSignal = serv.maxSignal.Copy()
 	} else {
 		r.CheckResult = serv.checkResult
 		f.inputs = corpus
+		f.corpusSize = len(corpus)
 		f.newMaxSignal = serv.maxSignal.Copy()
 	}
 	return nil
@@ -163,8 +207,12 @@ func (serv *RPCServer) rotateCorpus(f *Fuzzer, corpus []rpctype.Input) *rpctype.
 	//
 	// Note: at no point we drop anything globally and permanently.
 	// Everything we remove during this process is temporal and specific to a single VM.
-	calls := serv.rotator.Select()
+	return serv.restrictCorpus(f, corpus, serv.rotator.Select())
+}
 
+// restrictCorpus makes the fuzzer run in isolation on the given subset of syscalls.
+func (serv *RPCServer) restrictCorpus(f *Fuzzer, corpus []rpctype.Input,
+	calls map[*prog.Syscall]bool) *rpctype.CheckArgs {
 	var callIDs []int
 	callNames := make(map[string]bool)
 	for call := range calls {
@@ -177,12 +225,51 @@ func (serv *RPCServer) rotateCorpus(f *Fuzzer, corpus []rpctype.Input) *rpctype.
 	// be used to accept new inputs from this manager.
 	f.rotatedSignal = serv.corpusSignal.Intersection(f.newMaxSignal)
 	f.rotated = true
+	f.corpusSize = len(f.inputs)
+
+	result := *serv.checkResult
+	result.EnabledCalls = map[string][]int{serv.cfg.Sandbox: callIDs}
+	return &result
+}
+
+// phaseCorpus makes the fuzzer use only the given subset of syscalls.
+// Unlike rotation, the fuzzer is not isolated: it gets candidates and corpus inputs
+// that use only the enabled syscalls, and its inputs are accepted as usual.
+func (serv *RPCServer) phaseCorpus(f *Fuzzer, corpus []rpctype.Input,
+	calls map[*prog.Syscall]bool) *rpctype.CheckArgs {
+	var callIDs []int
+	f.enabled = make(map[string]bool)
+	for call := range calls {
+		f.enabled[call.Name] = true
+		callIDs = append(callIDs, call.ID)
+	}
+	for _, inp := range corpus {
+		if callsEnabled(f.enabled, inp.Prog) {
+			f.inputs = append(f.inputs, inp)
+		}
+	}
+	f.corpusSize = len(f.inputs)
+	f.newMaxSignal = serv.maxSignal.Copy()
 
 	result := *serv.checkResult
 	result.EnabledCalls = map[string][]int{serv.cfg.Sandbox: callIDs}
 	return &result
 }
 
+// callsEnabled says if the program uses only the enabled syscalls.
+func callsEnabled(enabled map[string]bool, data []byte) bool {
+	calls, _, err := prog.CallSet(data)
+	if err != nil {
+		panic(fmt.Sprintf("CallSet failed: %v\n%s", err, data))
+	}
+	for call := range calls {
+		if !enabled[call] {
+			return false
+		}
+	}
+	return true
+}
+
 func (serv *RPCServer) selectInputs(enabled map[string]bool, inputs0 []rpctype.Input, signal0 signal.Signal) (
 	inputs []rpctype.Input, signal signal.Signal) {
 	signal = signal0.Copy()
@@ -281,7 +368,8 @@ func (serv *RPCServer) NewInput(a *rpctype.NewInputArgs, r *int) error {
 	if !genuine && !rotated {
 		return nil
 	}
-	if !serv.mgr.newInput(a.Input, inputSignal) {
+	accepted, shards := serv.mgr.newInput(a.Input, inputSignal)
+	if !accepted {
 		return nil
 	}
 
@@ -305,6 +393,9 @@ func (serv *RPCServer) NewInput(a *rpctype.NewInputArgs, r *int) error {
 		serv.stats.corpusCoverFiltered.add(filtered)
 	}
 	serv.stats.newInputs.inc()
+	if f != nil && f.shard >= 0 {
+		serv.shards[f.shard].newInputs++
+	}
 	if rotated {
 		serv.stats.rotatedInputs.inc()
 	}
@@ -316,7 +407,11 @@ func (serv *RPCServer) NewInput(a *rpctype.NewInputArgs, r *int) error {
 		a.Input.Cover = nil // Don't send coverage back to all fuzzers.
 		a.Input.RawCover = nil
 		for _, other := range serv.fuzzers {
-			if other == f || other.rotated {
+			if other == f || other.rotated || other.enabled != nil && !callsEnabled(other.enabled, a.Input.Prog) {
+				continue
+			}
+			if other.shard >= 0 && !shards[other.shard] {
+				// With sharding, VMs get only inputs of their shard subsets.
 				continue
 			}
 			other.inputs = append(other.inputs, a.Input)
@@ -327,6 +422,10 @@ func (serv *RPCServer) NewInput(a *rpctype.NewInputArgs, r *int) error {
 
 func (serv *RPCServer) Poll(a *rpctype.PollArgs, r *rpctype.PollRes) error {
 	serv.stats.mergeNamed(a.Stats)
+	serv.mgr.mergeCallPairs(a.CallPairs)
+	serv.mgr.mergeCallResults(a.CallResults)
+	serv.mgr.mergeCallFlakes(a.CallFlakes)
+	serv.mgr.candidatesTriaged(a.TriagedCandidates)
 
 	serv.mu.Lock()
 	defer serv.mu.Unlock()
@@ -338,6 +437,11 @@ func (serv *RPCServer) Poll(a *rpctype.PollArgs, r *rpctype.PollRes) error {
 		log.Logf(1, "poll: fuzzer %v is not connected", a.Name)
 		return nil
 	}
+	f.memory = a.Memory
+	serv.updateVMStats()
+	if f.shard >= 0 {
+		serv.shards[f.shard].execs += a.Stats["exec total"]
+	}
 	newMaxSignal := serv.maxSignal.Diff(a.MaxSignal.Deserialize())
 	if !newMaxSignal.Empty() {
 		serv.maxSignal.Merge(newMaxSignal)
@@ -353,9 +457,18 @@ func (serv *RPCServer) Poll(a *rpctype.PollArgs, r *rpctype.PollRes) error {
 		// Let rotated VMs run in isolation, don't send them anything.
 		return nil
 	}
+	r.Focus = serv.mgr.currentFocus()
+	if priors, version := serv.mgr.learnedCallPriors(); version != f.learnedPriors {
+		r.LearnedCallPriors = priors
+		f.learnedPriors = version
+	}
 	r.MaxSignal = f.newMaxSignal.Split(2000).Serialize()
 	if a.NeedCandidates {
-		r.Candidates = serv.mgr.candidateBatch(serv.batchSize)
+		r.Candidates = serv.mgr.candidateBatch(a.Name, serv.batchSize, f.enabled)
+	}
+	if serv.shardSwapDue(f) {
+		serv.assignShard(f)
+		r.ResetCorpus = true
 	}
 	if len(r.Candidates) == 0 {
 		batchSize := serv.batchSize
@@ -394,5 +507,6 @@ func (serv *RPCServer) shutdownInstance(name string) []byte {
 		return nil
 	}
 	delete(serv.fuzzers, name)
+	serv.leaveShard(fuzzer)
 	return fuzzer.machineInfo
 }
@@ -0,0 +1,358 @@
+// Copyright 2026 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"hash/crc32"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/google/syzkaller/pkg/html/pages"
+	"github.com/google/syzkaller/pkg/log"
+	"github.com/google/syzkaller/pkg/rpctype"
+	"github.com/google/syzkaller/pkg/signal"
+)
+
+// Corpus sharding (see mgrconfig.Config.CorpusShards).
+// Instead of sending the full corpus to every VM, the manager splits the corpus into shards
+// (by consistent hash of programs), so that every shard fits into the budget, and sends each VM
+// a subset for one of the shards (see shardSubset).
+// New VMs get the shard with the least number of VMs, running VMs switch to another shard
+// every swap period. New genuine inputs are sent only to VMs whose shard subset takes them.
+// Memory use of VMs ("vm memory MB" stat) and the amount of corpus sent to VMs ("vm corpus" stat)
+// are also collected without sharding, so both can be compared against full replication.
+
+type corpusShard struct {
+	fuzzers     map[string]bool // VMs that currently fuzz the shard
+	assignments uint64
+	execs       uint64
+	newInputs   uint64
+	// The last selected subset of the corpus for the shard.
+	inputs int
+	size   int
+	signal int
+}
+
+// inputSize estimates the amount of memory the input takes in a VM.
+func inputSize(inp *CorpusItem) int {
+	// Signal is sent as uint32 elements and uint8 priorities.
+	return len(inp.Prog) + 5*len(inp.Signal.Elems)
+}
+
+// shardOf returns the shard of the program out of count shards.
+// Shards are assigned with consistent hashing, so when the number of shards grows,
+// only programs that move to the new shard change their shard.
+func shardOf(prog []byte, count int) int {
+	// Jump consistent hash (https://arxiv.org/abs/1406.2294).
+	key := uint64(crc32.ChecksumIEEE(prog))
+	b, j := int64(-1), int64(0)
+	for j < int64(count) {
+		b = j
+		key = key*2862933555777941757 + 1
+		j = int64(float64(b+1) * (float64(int64(1)<<31) / float64((key>>33)+1)))
+	}
+	return int(b)
+}
+
+// shardSet keeps the corpus subsets of all shards, it's maintained by the manager under mgr.mu.
+// The subsets are updated as inputs are added to the corpus, so signal of inputs is not deserialized
+// on every shard assignment. They are rebuilt from scratch only after the corpus is minimized
+// or the number of shards changes.
+type shardSet struct {
+	budget  int
+	count   int
+	total   int // estimated size of the corpus
+	subsets []*shardSubset
+	stale   bool // the subsets need to be rebuilt
+}
+
+// shardSubset is the subset of the corpus sent to VMs that fuzz the shard. The subset preserves
+// coverage as far as the budget allows: programs of the shard are taken first, programs
+// of other shards are taken only if they cover signal not covered yet, then the rest of the budget
+// is filled with programs of the shard.
+type shardSubset struct {
+	inputs  []string // hashes of the corpus inputs
+	taken   map[string]bool
+	covered signal.Signal
+	size    int
+}
+
+func newShardSet(budget int) *shardSet {
+	return &shardSet{budget: budget, count: 1, stale: true}
+}
+
+func (set *shardSet) shardCount(total int) int {
+	count := (total + set.budget - 1) / set.budget
+	if count == 0 {
+		count = 1
+	}
+	return count
+}
+
+// add updates the subsets after the input was added to the corpus, or an existing corpus input
+// got more signal (sign is the full signal of the input, oldSize is the previous size of the input).
+func (set *shardSet) add(sig string, inp *CorpusItem, sign signal.Signal, oldSize int) {
+	size := inputSize(inp)
+	set.total += size - oldSize
+	if set.stale || set.shardCount(set.total) != set.count {
+		set.stale = true
+		return
+	}
+	own := shardOf(inp.Prog, set.count)
+	for idx, sub := range set.subsets {
+		if sub.taken[sig] {
+			sub.covered.Merge(sign)
+			sub.size += size - oldSize
+			continue
+		}
+		newSignal := !sub.covered.Diff(sign).Empty()
+		if sub.size+size > set.budget {
+			if newSignal {
+				// Don't lose signal, rebuild the subsets to make room for the input.
+				set.stale = true
+			}
+			continue
+		}
+		if idx == own || newSignal {
+			sub.add(sig, size, sign)
+		}
+	}
+}
+
+// takenBy returns the shards whose subsets contain the input, the subsets are rebuilt first if they are stale.
+func (set *shardSet) takenBy(sig string, corpus map[string]CorpusItem) map[int]bool {
+	if set.stale {
+		set.rebuild(corpus)
+	}
+	shards := make(map[int]bool)
+	for idx, sub := range set.subsets {
+		if sub.taken[sig] {
+			shards[idx] = true
+		}
+	}
+	return shards
+}
+
+func (set *shardSet) rebuild(corpus map[string]CorpusItem) {
+	type input struct {
+		sig  string
+		size int
+		own  int
+		sign signal.Signal
+	}
+	set.total = 0
+	for _, item := range corpus {
+		set.total += inputSize(&item)
+	}
+	set.count = set.shardCount(set.total)
+	inputs := make([]input, 0, len(corpus))
+	for sig, item := range corpus {
+		inputs = append(inputs, input{
+			sig:  sig,
+			size: inputSize(&item),
+			own:  shardOf(item.Prog, set.count),
+			sign: item.Signal.Deserialize(),
+		})
+	}
+	sort.Slice(inputs, func(i, j int) bool {
+		if len(inputs[i].sign) != len(inputs[j].sign) {
+			return len(inputs[i].sign) > len(inputs[j].sign)
+		}
+		return inputs[i].sig < inputs[j].sig
+	})
+	set.subsets = nil
+	for idx := 0; idx < set.count; idx++ {
+		sub := &shardSubset{taken: make(map[string]bool)}
+		// Own inputs that add signal, then inputs of other shards that add signal, then the rest of own inputs.
+		// The rest of own inputs leave some room for new inputs, so that they can be added
+		// without rebuilding the subsets (a single shard holds the whole corpus anyway).
+		for pass := 0; pass < 3; pass++ {
+			budget := set.budget
+			if pass == 2 && set.count > 1 {
+				budget = budget * 9 / 10
+			}
+			for i := range inputs {
+				inp := &inputs[i]
+				if (inp.own == idx) != (pass != 1) || sub.taken[inp.sig] || sub.size+inp.size > budget {
+					continue
+				}
+				if pass != 2 && sub.covered.Diff(inp.sign).Empty() {
+					continue
+				}
+				sub.add(inp.sig, inp.size, inp.sign)
+			}
+		}
+		set.subsets = append(set.subsets, sub)
+	}
+	set.stale = false
+}
+
+func (sub *shardSubset) add(sig string, size int, sign signal.Signal) {
+	sub.inputs = append(sub.inputs, sig)
+	sub.taken[sig] = true
+	sub.covered.Merge(sign)
+	sub.size += size
+}
+
+// corpusShard calls choose with the current number of shards to select a shard and returns
+// the index of the shard and its subset of the corpus, along with its estimated size and amount of signal.
+func (mgr *Manager) corpusShard(choose func(count int) int) (int, []rpctype.Input, int, int) {
+	mgr.mu.Lock()
+	defer mgr.mu.Unlock()
+	if mgr.shards.stale {
+		mgr.shards.rebuild(mgr.corpus)
+	}
+	idx := choose(mgr.shards.count)
+	sub := mgr.shards.subsets[idx]
+	inputs := make([]rpctype.Input, 0, len(sub.inputs))
+	for _, sig := range sub.inputs {
+		item := mgr.corpus[sig]
+		inputs = append(inputs, mgr.corpusInputLocked(&item))
+	}
+	return idx, inputs, sub.size, sub.covered.Len()
+}
+
+// assignShard switches the fuzzer to the least used shard (other than the current one)
+// and selects the inputs to send to it.
+func (serv *RPCServer) assignShard(f *Fuzzer) {
+	prev := f.shard
+	serv.leaveShard(f)
+	idx, inputs, size, sign := serv.mgr.corpusShard(func(count int) int {
+		serv.shardCount = count
+		for len(serv.shards) < count {
+			serv.shards = append(serv.shards, &corpusShard{fuzzers: make(map[string]bool)})
+		}
+		idx := -1
+		for i, shard := range serv.shards[:count] {
+			if i == prev && count > 1 {
+				continue
+			}
+			if idx == -1 || len(shard.fuzzers) < len(serv.shards[idx].fuzzers) ||
+				len(shard.fuzzers) == len(serv.shards[idx].fuzzers) &&
+					shard.assignments < serv.shards[idx].assignments {
+				idx = i
+			}
+		}
+		return idx
+	})
+	shard := serv.shards[idx]
+	shard.fuzzers[f.name] = true
+	shard.assignments++
+	shard.inputs, shard.size, shard.signal = len(inputs), size, sign
+	f.shard, f.shardTime = idx, time.Now()
+	f.inputs = inputs
+	f.corpusSize = len(f.inputs)
+	log.Logf(1, "fuzzer %v: corpus shard %v/%v, %v inputs", f.name, idx, serv.shardCount, len(f.inputs))
+}
+
+func (serv *RPCServer) leaveShard(f *Fuzzer) {
+	if f.shard >= 0 {
+		delete(serv.shards[f.shard].fuzzers, f.name)
+		f.shard = -1
+	}
+}
+
+// shardSwapDue says whether it's time to switch the fuzzer to another shard.
+// Switching is postponed until the fuzzer receives all inputs of the current shard.
+func (serv *RPCServer) shardSwapDue(f *Fuzzer) bool {
+	shards := serv.cfg.CorpusShards
+	return shards != nil && shards.SwapPeriod > 0 && f.shard >= 0 && serv.shardCount > 1 &&
+		len(f.inputs) == 0 && time.Since(f.shardTime) >= time.Duration(shards.SwapPeriod)*time.Minute
+}
+
+// updateVMStats updates average memory use and corpus size of VMs.
+func (serv *RPCServer) updateVMStats() {
+	var memory, corpus uint64
+	n := 0
+	for _, f := range serv.fuzzers {
+		if f.memory == 0 {
+			continue
+		}
+		memory += f.memory
+		corpus += uint64(f.corpusSize)
+		n++
+	}
+	if n != 0 {
+		serv.stats.vmMemory.set(int(memory / uint64(n) >> 20))
+		serv.stats.vmCorpus.set(int(corpus / uint64(n)))
+	}
+}
+
+func (mgr *Manager) httpShards(w http.ResponseWriter, r *http.Request) {
+	data := &UIShardsData{}
+	serv := mgr.serv
+	serv.mu.Lock()
+	data.Count = serv.shardCount
+	for i, shard := range serv.shards {
+		ui := UIShard{
+			Index:       i,
+			Inputs:      shard.inputs,
+			Size:        shard.size >> 10,
+			Signal:      shard.signal,
+			Assignments: shard.assignments,
+			Execs:       shard.execs,
+			NewInputs:   shard.newInputs,
+		}
+		for name := range shard.fuzzers {
+			ui.Fuzzers = append(ui.Fuzzers, name)
+		}
+		sort.Strings(ui.Fuzzers)
+		data.Shards = append(data.Shards, ui)
+	}
+	serv.mu.Unlock()
+	executeTemplate(w, shardsTemplate, data)
+}
+
+type UIShardsData struct {
+	Count  int
+	Shards []UIShard
+}
+
+type UIShard struct {
+	Index       int
+	Inputs      int
+	Size        int // KB
+	Signal      int
+	Fuzzers     []string
+	Assignments uint64
+	Execs       uint64
+	NewInputs   uint64
+}
+
+var shardsTemplate = pages.Create(`
+<!doctype html>
+<html>
+<head>
+	<title>syzkaller corpus shards</title>
+	{{HEAD}}
+</head>
+<body>
+<table class="list_table">
+	<caption>Corpus shards ({{$.Count}} in use):</caption>
+	<tr>
+		<th>Shard</th>
+		<th>Inputs</th>
+		<th>Size, KB</th>
+		<th>Signal</th>
+		<th>VMs</th>
+		<th>Assignments</th>
+		<th>Execs</th>
+		<th>New inputs</th>
+	</tr>
+	{{range $s := $.Shards}}
+	<tr>
+		<td>{{$s.Index}}</td>
+		<td>{{$s.Inputs}}</td>
+		<td>{{$s.Size}}</td>
+		<td>{{$s.Signal}}</td>
+		<td>{{range $f := $s.Fuzzers}}{{$f}} {{end}}</td>
+		<td>{{$s.Assignments}}</td>
+		<td>{{$s.Execs}}</td>
+		<td>{{$s.NewInputs}}</td>
+	</tr>
+	{{end}}
+</table>
+</body></html>
+`)
@@ -0,0 +1,217 @@
+// Copyright 2026 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/google/syzkaller/pkg/hash"
+	"github.com/google/syzkaller/pkg/mgrconfig"
+	"github.com/google/syzkaller/pkg/signal"
+)
+
+func shardTestItem(i int) CorpusItem {
+	// Every 10 inputs cover the same signal.
+	raw := []uint32{uint32(i / 10), 1000}
+	return CorpusItem{
+		Prog:   []byte(fmt.Sprintf("prog%v\n", i)),
+		Signal: signal.FromRaw(raw, 0).Serialize(),
+	}
+}
+
+func shardTestCorpus() map[string]CorpusItem {
+	corpus := make(map[string]CorpusItem)
+	for i := 0; i < 100; i++ {
+		item := shardTestItem(i)
+		corpus[hash.String(item.Prog)] = item
+	}
+	return corpus
+}
+
+func checkShardSet(t *testing.T, set *shardSet, corpus map[string]CorpusItem) {
+	var all signal.Signal
+	for _, item := range corpus {
+		all.Merge(item.Signal.Deserialize())
+	}
+	seen := make(map[string]bool)
+	for idx, sub := range set.subsets {
+		if sub.size > set.budget {
+			t.Fatalf("shard %v: size %v exceeds budget %v", idx, sub.size, set.budget)
+		}
+		if sub.covered.Len() != all.Len() {
+			t.Fatalf("shard %v: covers %v signal, corpus covers %v", idx, sub.covered.Len(), all.Len())
+		}
+		size := 0
+		inputs := make(map[string]bool)
+		for _, sig := range sub.inputs {
+			item := corpus[sig]
+			size += inputSize(&item)
+			if inputs[sig] {
+				t.Fatalf("shard %v: duplicate input %v", idx, sig)
+			}
+			inputs[sig] = true
+			seen[sig] = true
+		}
+		if size != sub.size || len(sub.inputs) != len(sub.taken) {
+			t.Fatalf("shard %v: %v inputs of size %v, reported size %v", idx, len(sub.inputs), size, sub.size)
+		}
+	}
+	if len(seen) < len(corpus)*3/4 {
+		t.Fatalf("shards cover only %v inputs out of %v", len(seen), len(corpus))
+	}
+}
+
+func TestShardSet(t *testing.T) {
+	corpus := shardTestCorpus()
+	total := 0
+	for _, item := range corpus {
+		total += inputSize(&item)
+	}
+	// The full corpus fits into a single shard.
+	set := newShardSet(total)
+	set.rebuild(corpus)
+	if set.count != 1 || len(set.subsets[0].inputs) != len(corpus) {
+		t.Fatalf("corpus of %v bytes does not fit into a single shard: %v shards", total, set.count)
+	}
+	set = newShardSet(total * 2 / 7)
+	set.rebuild(corpus)
+	if set.count != 4 || set.stale {
+		t.Fatalf("got %v shards for %v bytes, stale %v", set.count, total, set.stale)
+	}
+	checkShardSet(t, set, corpus)
+	// Add new signal to existing inputs, and new inputs that keep the number of shards.
+	for i := 0; i < 100; i += 50 {
+		item := shardTestItem(i)
+		sig := hash.String(item.Prog)
+		oldSize := inputSize(&item)
+		sign := item.Signal.Deserialize()
+		sign.Merge(signal.FromRaw([]uint32{uint32(2000 + i)}, 0))
+		item.Signal = sign.Serialize()
+		corpus[sig] = item
+		set.add(sig, &item, sign, oldSize)
+		if shards := set.takenBy(sig, corpus); len(shards) != set.count {
+			t.Fatalf("input with new signal is taken only by shards %v", shards)
+		}
+	}
+	if set.stale {
+		t.Fatalf("shard set became stale after signal updates")
+	}
+	checkShardSet(t, set, corpus)
+	// An input without new signal is taken only by its own shard.
+	dup := CorpusItem{Prog: []byte("dup\n"), Signal: shardTestItem(0).Signal}
+	dupSig := hash.String(dup.Prog)
+	corpus[dupSig] = dup
+	set.add(dupSig, &dup, dup.Signal.Deserialize(), 0)
+	if shards := set.takenBy(dupSig, corpus); len(shards) != 1 || !shards[shardOf(dup.Prog, set.count)] {
+		t.Fatalf("input without new signal is taken by shards %v", shards)
+	}
+	for i := 100; set.shardCount(set.total) == 4; i++ {
+		item := shardTestItem(i)
+		sig := hash.String(item.Prog)
+		corpus[sig] = item
+		set.add(sig, &item, item.Signal.Deserialize(), 0)
+		if !set.stale {
+			checkShardSet(t, set, corpus)
+		}
+	}
+	if !set.stale {
+		t.Fatalf("shard set is not stale after the number of shards changed")
+	}
+	set.rebuild(corpus)
+	if set.count != 5 {
+		t.Fatalf("got %v shards after rebuild", set.count)
+	}
+	checkShardSet(t, set, corpus)
+}
+
+func TestShardOf(t *testing.T) {
+	const count = 4
+	moved := 0
+	for i := 0; i < 1000; i++ {
+		prog := []byte(fmt.Sprintf("prog%v\n", i))
+		prev, cur := shardOf(prog, count), shardOf(prog, count+1)
+		if prev < 0 || prev >= count {
+			t.Fatalf("program %v: shard %v out of %v", i, prev, count)
+		}
+		if prev != cur {
+			if cur != count {
+				t.Fatalf("program %v moved from shard %v to an old shard %v", i, prev, cur)
+			}
+			moved++
+		}
+	}
+	if moved < 100 || moved > 300 {
+		t.Fatalf("%v programs out of 1000 moved to the new shard", moved)
+	}
+}
+
+func TestAssignShard(t *testing.T) {
+	corpus := shardTestCorpus()
+	total := 0
+	for _, item := range corpus {
+		total += inputSize(&item)
+	}
+	mgr := &Manager{
+		corpus: corpus,
+		shards: newShardSet(total),
+	}
+	serv := &RPCServer{
+		mgr: mgr,
+		cfg: &mgrconfig.Config{
+			CorpusShards: &mgrconfig.CorpusShards{Budget: total>>10 + 1, SwapPeriod: 30},
+		},
+		stats:   new(Stats),
+		fuzzers: make(map[string]*Fuzzer),
+	}
+	var fuzzers []*Fuzzer
+	for i := 0; i < 4; i++ {
+		f := &Fuzzer{name: fmt.Sprintf("vm%v", i), shard: -1}
+		serv.fuzzers[f.name] = f
+		serv.assignShard(f)
+		fuzzers = append(fuzzers, f)
+	}
+	if serv.shardCount != 1 || fuzzers[0].shard != 0 || len(fuzzers[0].inputs) != len(corpus) {
+		t.Fatalf("corpus of %v bytes does not fit into a single shard: %v shards", total, serv.shardCount)
+	}
+	// Reconnect all VMs after the corpus is minimized with a smaller budget.
+	mgr.shards = newShardSet(total / 3)
+	for _, f := range fuzzers {
+		serv.leaveShard(f)
+	}
+	for _, f := range fuzzers {
+		serv.assignShard(f)
+	}
+	if serv.shardCount < 2 {
+		t.Fatalf("got %v shards for %v bytes", serv.shardCount, total)
+	}
+	for i, shard := range serv.shards[:serv.shardCount] {
+		if len(shard.fuzzers) > 4/serv.shardCount+1 {
+			t.Fatalf("shard %v is fuzzed by %v VMs", i, len(shard.fuzzers))
+		}
+	}
+	f := fuzzers[0]
+	prev := f.shard
+	f.inputs = nil
+	if serv.shardSwapDue(f) {
+		t.Fatalf("shard swap is due right after assignment")
+	}
+	f.shardTime = f.shardTime.Add(-31 * 60e9)
+	if !serv.shardSwapDue(f) {
+		t.Fatalf("shard swap is not due")
+	}
+	serv.assignShard(f)
+	if f.shard == prev || serv.shards[prev].fuzzers[f.name] || !serv.shards[f.shard].fuzzers[f.name] {
+		t.Fatalf("fuzzer did not switch from shard %v to another one", prev)
+	}
+	if len(f.inputs) != len(mgr.shards.subsets[f.shard].inputs) {
+		t.Fatalf("fuzzer got %v inputs, shard has %v", len(f.inputs), len(mgr.shards.subsets[f.shard].inputs))
+	}
+	serv.leaveShard(f)
+	for i, shard := range serv.shards {
+		if shard.fuzzers[f.name] {
+			t.Fatalf("fuzzer is still in shard %v", i)
+		}
+	}
+}
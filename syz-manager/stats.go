@@ -32,6 +32,10 @@ type Stats struct {
 	corpusCoverFiltered Stat
 	corpusSignal        Stat
 	maxSignal           Stat
+	seedsQuarantined    Stat
+	candidatesNearDup   Stat
+	vmMemory            Stat
+	vmCorpus            Stat
 
 	mu         sync.Mutex
 	namedStats map[string]uint64
@@ -73,6 +77,10 @@ func (stats *Stats) all() map[string]uint64 {
 		"filtered coverage": stats.corpusCoverFiltered.get(),
 		"signal":            stats.corpusSignal.get(),
 		"max signal":        stats.maxSignal.get(),
+		"quarantined seeds": stats.seedsQuarantined.get(),
+		"dup candidates":    stats.candidatesNearDup.get(), // dropped by max_candidates_per_fingerprint
+		"vm memory MB":      stats.vmMemory.get(),          // average over VMs
+		"vm corpus":         stats.vmCorpus.get(),          // average number of corpus inputs sent to VMs
 	}
 	if stats.haveHub {
 		m["hub: send prog add"] = stats.hubSendProgAdd.get()
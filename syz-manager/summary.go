@@ -0,0 +1,133 @@
+// Copyright 2026 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/syzkaller/pkg/log"
+	"github.com/google/syzkaller/pkg/osutil"
+	"github.com/google/syzkaller/pkg/summary"
+)
+
+// The manager records everything needed for the final campaign report in the workdir
+// (see pkg/summary), the report itself is written on exit and when GENERATION_END is seen.
+// tools/syz-summary can regenerate it from the workdir later.
+// The workdir files are written by the same periodic writer as the -bench file (see benchLoop).
+
+// timelineStats are the bench values kept in the summary timeline (see pkg/summary),
+// plus all "origin *" values. The full set of stats goes to the -bench file.
+var timelineStats = []string{"uptime", "fuzzing", "exec total", "coverage", "signal",
+	"max signal", "corpus", "EnrichCnt"}
+
+func (mgr *Manager) initSummary() {
+	data, err := json.MarshalIndent(mgr.cfg, "", "\t")
+	if err != nil {
+		log.Fatalf("failed to serialize config: %v", err)
+	}
+	if err := osutil.WriteFile(filepath.Join(mgr.cfg.Workdir, summary.ConfigFile), data); err != nil {
+		log.Logf(0, "failed to write config: %v", err)
+	}
+	mgr.loadCoveredSyscalls()
+}
+
+func (mgr *Manager) loadCoveredSyscalls() {
+	data, err := os.ReadFile(filepath.Join(mgr.cfg.Workdir, summary.CoveredSyscallsFile))
+	if err != nil {
+		return
+	}
+	for s := bufio.NewScanner(bytes.NewReader(data)); s.Scan(); {
+		if _, name, ok := strings.Cut(s.Text(), " "); ok {
+			mgr.coveredSyscalls[name] = true
+		}
+	}
+}
+
+// recordCoveredSyscallsLocked remembers when syscalls first appeared in the corpus.
+// The records are written to the workdir later by writeSummaryData.
+func (mgr *Manager) recordCoveredSyscallsLocked(calls map[string]struct{}) {
+	now := time.Now().Unix()
+	for name := range calls {
+		if !mgr.coveredSyscalls[name] {
+			mgr.coveredSyscalls[name] = true
+			fmt.Fprintf(&mgr.newCovered, "%v %v\n", now, name)
+		}
+	}
+}
+
+// summaryDataLocked returns the bench values (nil if fuzzing has not started yet)
+// and the covered syscall records that need to be written to the workdir.
+func (mgr *Manager) summaryDataLocked() (map[string]uint64, []byte) {
+	var vals map[string]uint64
+	if !mgr.firstConnect.IsZero() {
+		vals = mgr.benchValsLocked()
+	}
+	covered := append([]byte{}, mgr.newCovered.Bytes()...)
+	mgr.newCovered.Reset()
+	return vals, covered
+}
+
+// writeSummaryData appends the data returned by summaryDataLocked to the workdir files.
+func (mgr *Manager) writeSummaryData(vals map[string]uint64, covered []byte) {
+	mgr.timelineMu.Lock()
+	defer mgr.timelineMu.Unlock()
+	if len(covered) != 0 {
+		appendFile(filepath.Join(mgr.cfg.Workdir, summary.CoveredSyscallsFile), covered)
+	}
+	if vals == nil {
+		return
+	}
+	point := map[string]uint64{
+		"time": uint64(time.Now().Unix()),
+	}
+	for _, k := range timelineStats {
+		point[k] = vals[k]
+	}
+	for k, v := range vals {
+		if strings.HasPrefix(k, "origin ") {
+			point[k] = v
+		}
+	}
+	data, err := json.Marshal(point)
+	if err != nil {
+		log.Fatalf("failed to serialize timeline data: %v", err)
+	}
+	appendFile(filepath.Join(mgr.cfg.Workdir, summary.TimelineFile), append(data, '\n'))
+}
+
+func appendFile(file string, data []byte) {
+	f, err := os.OpenFile(file, os.O_APPEND|os.O_CREATE|os.O_WRONLY, osutil.DefaultFilePerm)
+	if err != nil {
+		log.Logf(0, "failed to open %v: %v", file, err)
+		return
+	}
+	defer f.Close()
+	if _, err := f.Write(data); err != nil {
+		log.Logf(0, "failed to write %v: %v", file, err)
+	}
+}
+
+func (mgr *Manager) writeSummary() {
+	mgr.mu.Lock()
+	vals, covered := mgr.summaryDataLocked()
+	mgr.mu.Unlock()
+	mgr.writeSummaryData(vals, covered)
+	s, err := summary.Generate(mgr.cfg.Workdir)
+	if err != nil {
+		log.Logf(0, "failed to generate summary: %v", err)
+		return
+	}
+	if err := s.Write(mgr.cfg.Workdir); err != nil {
+		log.Logf(0, "failed to write summary: %v", err)
+		return
+	}
+	log.Logf(0, "wrote campaign summary to %v", filepath.Join(mgr.cfg.Workdir, "summary.html"))
+}
@@ -10,6 +10,7 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -27,6 +28,7 @@ func main() {
 		flagVersion = flag.Uint64("version", 0, "database version")
 		flagOS      = flag.String("os", "", "target OS")
 		flagArch    = flag.String("arch", "", "target arch")
+		flagTop     = flag.Int("top", 20, "number of largest clusters to show in the redundancy report")
 	)
 	flag.Parse()
 	args := flag.Args()
@@ -44,6 +46,17 @@ func main() {
 		bench(target, args[1])
 		return
 	}
+	if args[0] == "redundancy" {
+		if len(args) != 2 {
+			usage()
+		}
+		target, err := prog.GetTarget(*flagOS, *flagArch)
+		if err != nil {
+			tool.Failf("failed to find target: %v", err)
+		}
+		redundancy(target, args[1], *flagTop)
+		return
+	}
 	var target *prog.Target
 	if *flagOS != "" || *flagArch != "" {
 		var err error
@@ -85,6 +98,7 @@ func usage() {
 	fmt.Fprintf(os.Stderr, "  syz-db parse corpus.db dir\n")
 	fmt.Fprintf(os.Stderr, "  syz-db merge dst-corpus.db add-corpus.db* add-prog*\n")
 	fmt.Fprintf(os.Stderr, "  syz-db bench corpus.db\n")
+	fmt.Fprintf(os.Stderr, "  syz-db [-top N] redundancy corpus.db\n")
 	os.Exit(1)
 }
 
@@ -303,3 +317,61 @@ func bench(target *prog.Target, file string) {
 }
 
 var sink interface{}
+
+// redundancy reports clusters of structurally near-identical programs (see prog.Prog.Fingerprint).
+func redundancy(target *prog.Target, file string, top int) {
+	db, err := db.Open(file, false)
+	if err != nil {
+		tool.Failf("failed to open database: %v", err)
+	}
+	type cluster struct {
+		keys []string
+		prog *prog.Prog
+	}
+	clusters := make(map[string]*cluster)
+	broken := 0
+	for key, rec := range db.Records {
+		p, err := target.Deserialize(rec.Val, prog.NonStrict)
+		if err != nil {
+			broken++
+			continue
+		}
+		fingerprint := p.Fingerprint()
+		c := clusters[fingerprint]
+		if c == nil {
+			c = &cluster{prog: p}
+			clusters[fingerprint] = c
+		}
+		c.keys = append(c.keys, key)
+	}
+	var sorted []*cluster
+	total, redundant := 0, 0
+	for _, c := range clusters {
+		sorted = append(sorted, c)
+		total += len(c.keys)
+		redundant += len(c.keys) - 1
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if len(sorted[i].keys) != len(sorted[j].keys) {
+			return len(sorted[i].keys) > len(sorted[j].keys)
+		}
+		return sorted[i].prog.String() < sorted[j].prog.String()
+	})
+	share := 0.0
+	if total != 0 {
+		share = float64(redundant) * 100 / float64(total)
+	}
+	fmt.Printf("programs: %v (%v broken), fingerprints: %v, redundant: %v (%.1f%%)\n",
+		total, broken, len(clusters), redundant, share)
+	for i, c := range sorted {
+		if i == top || len(c.keys) == 1 {
+			break
+		}
+		sort.Strings(c.keys)
+		examples := c.keys
+		if len(examples) > 3 {
+			examples = examples[:3]
+		}
+		fmt.Printf("%6v  %v\n        e.g. %v\n", len(c.keys), c.prog, strings.Join(examples, " "))
+	}
+}
@@ -0,0 +1,238 @@
+// Copyright 2026 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+// syz-depgraph exports the graph of resources produced and consumed by syscalls of a target.
+// Calls that can't be used because their input resources can't be created by the enabled calls
+// and resources that can't be created are highlighted.
+// Usage:
+//
+//	syz-depgraph -os linux -arch amd64 -enable 'socket$inet,accept$inet' -format dot | dot -Tsvg > graph.svg
+//	syz-depgraph -root 'ioctl$KVM_RUN' -format json
+//	syz-depgraph -resource sock_nl_route -format graphml
+package main
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"strings"
+
+	"github.com/google/syzkaller/pkg/mgrconfig"
+	"github.com/google/syzkaller/pkg/tool"
+	"github.com/google/syzkaller/prog"
+	_ "github.com/google/syzkaller/sys"
+)
+
+var (
+	flagOS       = flag.String("os", runtime.GOOS, "target os")
+	flagArch     = flag.String("arch", runtime.GOARCH, "target arch")
+	flagEnable   = flag.String("enable", "", "comma-separated list of enabled syscalls (default: all)")
+	flagFormat   = flag.String("format", "dot", "output format: dot, graphml or json")
+	flagResource = flag.String("resource", "", "show only resources of this kind (the resource and its subtypes)")
+	flagRoot     = flag.String("root", "", "show only calls needed to create inputs of this syscall "+
+		"and calls that consume its outputs")
+)
+
+func main() {
+	flag.Parse()
+	target, err := prog.GetTarget(*flagOS, *flagArch)
+	if err != nil {
+		tool.Fail(err)
+	}
+	var enabled map[*prog.Syscall]bool
+	if *flagEnable != "" {
+		ids, err := mgrconfig.ParseEnabledSyscalls(target, strings.Split(*flagEnable, ","), nil)
+		if err != nil {
+			tool.Failf("failed to parse enabled syscalls: %v", err)
+		}
+		enabled = make(map[*prog.Syscall]bool)
+		for _, id := range ids {
+			enabled[target.Syscalls[id]] = true
+		}
+	}
+	g := target.ResourceGraph(enabled)
+	if *flagResource != "" {
+		if g, err = g.FilterKind(*flagResource); err != nil {
+			tool.Fail(err)
+		}
+	}
+	if *flagRoot != "" {
+		if g, err = g.FilterRoot(*flagRoot); err != nil {
+			tool.Fail(err)
+		}
+	}
+	switch *flagFormat {
+	case "dot":
+		err = writeDOT(os.Stdout, g)
+	case "graphml":
+		err = writeGraphML(os.Stdout, g)
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "\t")
+		err = enc.Encode(g)
+	default:
+		tool.Failf("unknown format %q", *flagFormat)
+	}
+	if err != nil {
+		tool.Fail(err)
+	}
+}
+
+func callID(name string) string {
+	return "call:" + name
+}
+
+func resourceID(name string) string {
+	return "res:" + name
+}
+
+func writeDOT(w io.Writer, g *prog.ResourceGraph) error {
+	buf := new(strings.Builder)
+	fmt.Fprintf(buf, "digraph resources {\n\trankdir=LR;\n")
+	for _, c := range g.Calls {
+		attrs := ""
+		if c.Unsupported != "" {
+			attrs = fmt.Sprintf(", style=filled, fillcolor=salmon, tooltip=%q", c.Unsupported)
+		}
+		fmt.Fprintf(buf, "\t%q [shape=box, label=%q%v];\n", callID(c.Name), c.Name, attrs)
+	}
+	for _, res := range g.Resources {
+		attrs := ""
+		if !res.Creatable {
+			attrs = ", color=red, fontcolor=red"
+		}
+		fmt.Fprintf(buf, "\t%q [shape=ellipse, label=%q%v];\n", resourceID(res.Name), res.Name, attrs)
+	}
+	present := make(map[string]bool)
+	for _, res := range g.Resources {
+		present[res.Name] = true
+	}
+	for _, res := range g.Resources {
+		if res.Parent != "" && present[res.Parent] {
+			fmt.Fprintf(buf, "\t%q -> %q [style=dashed, arrowhead=empty];\n",
+				resourceID(res.Name), resourceID(res.Parent))
+		}
+	}
+	for _, edge := range g.Edges {
+		from, to := resourceID(edge.Resource), callID(edge.Call)
+		if edge.Output {
+			from, to = to, from
+		}
+		fmt.Fprintf(buf, "\t%q -> %q [weight=%v];\n", from, to, edge.Weight)
+	}
+	fmt.Fprintf(buf, "}\n")
+	_, err := io.WriteString(w, buf.String())
+	return err
+}
+
+type graphML struct {
+	XMLName xml.Name     `xml:"graphml"`
+	XMLNS   string       `xml:"xmlns,attr"`
+	Keys    []graphMLKey `xml:"key"`
+	Graph   graphMLGraph `xml:"graph"`
+}
+
+type graphMLKey struct {
+	ID   string `xml:"id,attr"`
+	For  string `xml:"for,attr"`
+	Name string `xml:"attr.name,attr"`
+	Type string `xml:"attr.type,attr"`
+}
+
+type graphMLGraph struct {
+	ID          string        `xml:"id,attr"`
+	EdgeDefault string        `xml:"edgedefault,attr"`
+	Nodes       []graphMLNode `xml:"node"`
+	Edges       []graphMLEdge `xml:"edge"`
+}
+
+type graphMLNode struct {
+	ID   string        `xml:"id,attr"`
+	Data []graphMLData `xml:"data"`
+}
+
+type graphMLEdge struct {
+	Source string        `xml:"source,attr"`
+	Target string        `xml:"target,attr"`
+	Data   []graphMLData `xml:"data"`
+}
+
+type graphMLData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+func writeGraphML(w io.Writer, g *prog.ResourceGraph) error {
+	doc := &graphML{
+		XMLNS: "http://graphml.graphdrawing.org/xmlns",
+		Keys: []graphMLKey{
+			{"type", "node", "type", "string"},
+			{"label", "node", "label", "string"},
+			{"unsupported", "node", "unsupported", "string"},
+			{"creatable", "node", "creatable", "boolean"},
+			{"relation", "edge", "relation", "string"},
+			{"weight", "edge", "weight", "int"},
+		},
+		Graph: graphMLGraph{
+			ID:          "resources",
+			EdgeDefault: "directed",
+		},
+	}
+	for _, c := range g.Calls {
+		node := graphMLNode{
+			ID:   callID(c.Name),
+			Data: []graphMLData{{"type", "call"}, {"label", c.Name}},
+		}
+		if c.Unsupported != "" {
+			node.Data = append(node.Data, graphMLData{"unsupported", c.Unsupported})
+		}
+		doc.Graph.Nodes = append(doc.Graph.Nodes, node)
+	}
+	present := make(map[string]bool)
+	for _, res := range g.Resources {
+		present[res.Name] = true
+		doc.Graph.Nodes = append(doc.Graph.Nodes, graphMLNode{
+			ID: resourceID(res.Name),
+			Data: []graphMLData{
+				{"type", "resource"},
+				{"label", res.Name},
+				{"creatable", fmt.Sprint(res.Creatable)},
+			},
+		})
+	}
+	for _, res := range g.Resources {
+		if res.Parent != "" && present[res.Parent] {
+			doc.Graph.Edges = append(doc.Graph.Edges, graphMLEdge{
+				Source: resourceID(res.Name),
+				Target: resourceID(res.Parent),
+				Data:   []graphMLData{{"relation", "subtype"}},
+			})
+		}
+	}
+	for _, edge := range g.Edges {
+		e := graphMLEdge{
+			Source: resourceID(edge.Resource),
+			Target: callID(edge.Call),
+			Data:   []graphMLData{{"relation", "consumes"}, {"weight", fmt.Sprint(edge.Weight)}},
+		}
+		if edge.Output {
+			e.Source, e.Target = e.Target, e.Source
+			e.Data[0].Value = "produces"
+		}
+		doc.Graph.Edges = append(doc.Graph.Edges, e)
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "\t")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,104 @@
+// Copyright 2026 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+// syz-diff prints structural differences between two programs.
+// Usage:
+//
+//	syz-diff [-json] [-color] old.syz new.syz
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+
+	"github.com/google/syzkaller/prog"
+	_ "github.com/google/syzkaller/sys"
+)
+
+var (
+	flagOS     = flag.String("os", runtime.GOOS, "target os")
+	flagArch   = flag.String("arch", runtime.GOARCH, "target arch")
+	flagJSON   = flag.Bool("json", false, "print the diff in JSON format")
+	flagColor  = flag.Bool("color", isTerminal(), "colorize the output (default if stdout is a terminal)")
+	flagStrict = flag.Bool("strict", true, "parse input programs in strict mode")
+)
+
+func main() {
+	flag.Parse()
+	if flag.NArg() != 2 {
+		fmt.Fprintf(os.Stderr, "usage: syz-diff [flags] old.syz new.syz\n")
+		flag.PrintDefaults()
+		os.Exit(1)
+	}
+	target, err := prog.GetTarget(*flagOS, *flagArch)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+	diff := prog.Diff(readProg(target, flag.Arg(0)), readProg(target, flag.Arg(1)))
+	if *flagJSON {
+		data, err := json.MarshalIndent(diff, "", "\t")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to serialize the diff: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("%s\n", data)
+		return
+	}
+	for _, line := range strings.SplitAfter(diff.String(), "\n") {
+		if *flagColor {
+			line = colorize(line)
+		}
+		fmt.Print(line)
+	}
+}
+
+func readProg(target *prog.Target, file string) *prog.Prog {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to read prog file: %v\n", err)
+		os.Exit(1)
+	}
+	mode := prog.NonStrict
+	if *flagStrict {
+		mode = prog.Strict
+	}
+	p, err := target.Deserialize(data, mode)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to deserialize %v: %v\n", file, err)
+		os.Exit(1)
+	}
+	return p
+}
+
+const (
+	colorRed    = "\x1b[31m"
+	colorGreen  = "\x1b[32m"
+	colorYellow = "\x1b[33m"
+	colorReset  = "\x1b[0m"
+)
+
+// colorize colors removed calls red, inserted calls green and changed calls yellow.
+func colorize(line string) string {
+	color := ""
+	switch {
+	case strings.HasPrefix(line, "-"):
+		color = colorRed
+	case strings.HasPrefix(line, "+"):
+		color = colorGreen
+	case strings.HasPrefix(line, "~"):
+		color = colorYellow
+	default:
+		return line
+	}
+	return color + strings.TrimSuffix(line, "\n") + colorReset + "\n"
+}
+
+func isTerminal() bool {
+	stat, err := os.Stdout.Stat()
+	return err == nil && stat.Mode()&os.ModeCharDevice != 0
+}
@@ -5,6 +5,7 @@
 package main
 
 import (
+	"encoding/json"
 	"flag"
 	"fmt"
 	"math/rand"
@@ -30,6 +31,8 @@ var (
 	flagHintSrc  = flag.Uint64("hint-src", 0, "compared value in the program")
 	flagHintCmp  = flag.Uint64("hint-cmp", 0, "compare operand in the kernel")
 	flagStrict   = flag.Bool("strict", true, "parse input program in strict mode")
+	flagReplay   = flag.String("replay", "", "replay the trace from the given file "+
+		"(the parent program for mutation and hint traces is the program argument)")
 )
 
 func main() {
@@ -39,6 +42,10 @@ func main() {
 		fmt.Fprintf(os.Stderr, "%v\n", err)
 		os.Exit(1)
 	}
+	if *flagReplay != "" {
+		replay(target)
+		return
+	}
 	var syscalls map[*prog.Syscall]bool
 	if *flagEnable != "" {
 		enabled := strings.Split(*flagEnable, ",")
@@ -72,20 +79,7 @@ func main() {
 	if flag.NArg() == 0 {
 		p = target.Generate(rs, *flagLen, ct)
 	} else {
-		data, err := os.ReadFile(flag.Arg(0))
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "failed to read prog file: %v\n", err)
-			os.Exit(1)
-		}
-		mode := prog.NonStrict
-		if *flagStrict {
-			mode = prog.Strict
-		}
-		p, err = target.Deserialize(data, mode)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "failed to deserialize the program: %v\n", err)
-			os.Exit(1)
-		}
+		p = readProg(target, flag.Arg(0))
 		if *flagHintCall != -1 {
 			comps := make(prog.CompMap)
 			comps.AddComp(*flagHintSrc, *flagHintCmp)
@@ -99,3 +93,48 @@ func main() {
 	}
 	fmt.Printf("%s\n", p.Serialize())
 }
+
+func readProg(target *prog.Target, file string) *prog.Prog {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to read prog file: %v\n", err)
+		os.Exit(1)
+	}
+	mode := prog.NonStrict
+	if *flagStrict {
+		mode = prog.Strict
+	}
+	p, err := target.Deserialize(data, mode)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to deserialize the program: %v\n", err)
+		os.Exit(1)
+	}
+	return p
+}
+
+// replay reproduces a program from a trace recorded by the fuzzer (see trace_mutations manager config).
+// Traces are self-contained, so neither the corpus nor the enabled syscalls are needed.
+func replay(target *prog.Target) {
+	data, err := os.ReadFile(*flagReplay)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to read trace file: %v\n", err)
+		os.Exit(1)
+	}
+	trace := new(prog.Trace)
+	if err := json.Unmarshal(data, trace); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to parse trace: %v\n", err)
+		os.Exit(1)
+	}
+	var parent *prog.Prog
+	if flag.NArg() != 0 {
+		parent = readProg(target, flag.Arg(0))
+	}
+	p, err := target.Replay(trace, parent)
+	if p != nil {
+		fmt.Printf("%s\n", p.Serialize())
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+}
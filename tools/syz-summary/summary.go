@@ -0,0 +1,32 @@
+// Copyright 2026 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+// syz-summary regenerates the campaign summary report (summary.json and summary.html)
+// from a syz-manager workdir.
+// Usage:
+//
+//	syz-summary -workdir workdir
+package main
+
+import (
+	"flag"
+
+	"github.com/google/syzkaller/pkg/summary"
+	"github.com/google/syzkaller/pkg/tool"
+)
+
+var flagWorkdir = flag.String("workdir", "", "manager workdir")
+
+func main() {
+	defer tool.Init()()
+	if *flagWorkdir == "" {
+		tool.Failf("-workdir is required")
+	}
+	s, err := summary.Generate(*flagWorkdir)
+	if err != nil {
+		tool.Fail(err)
+	}
+	if err := s.Write(*flagWorkdir); err != nil {
+		tool.Fail(err)
+	}
+}